@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"context"
+
 	"gitee.com/orbit-w/orbit/app/proto/pb/pb_core"
 	"google.golang.org/protobuf/proto"
 )
@@ -8,7 +10,7 @@ import (
 type ExampleController struct {
 }
 
-func (e *ExampleController) HandleSearchBook(req *pb_core.Request_SearchBook) proto.Message {
+func (e *ExampleController) HandleSearchBook(ctx context.Context, req *pb_core.Request_SearchBook) proto.Message {
 	return &pb_core.Request_SearchBook_Rsp{
 		Result: &pb_core.Book{
 			Content: "Hello, World!",
@@ -16,6 +18,6 @@ func (e *ExampleController) HandleSearchBook(req *pb_core.Request_SearchBook) pr
 	}
 }
 
-func (e *ExampleController) HandleHeartBeat(req *pb_core.Request_HeartBeat) proto.Message {
+func (e *ExampleController) HandleHeartBeat(ctx context.Context, req *pb_core.Request_HeartBeat) proto.Message {
 	return &pb_core.OK{}
 }
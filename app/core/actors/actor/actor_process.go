@@ -1,6 +1,8 @@
 package actor
 
 import (
+	"context"
+	"errors"
 	sync "sync"
 	"time"
 
@@ -14,6 +16,8 @@ type Process struct {
 	Props     *Props
 	PID       *actor.PID
 	rw        sync.RWMutex // 读写锁, 用于保护ActorProcess的状态
+	mailbox   *mailbox
+	system    *ActorSystem
 }
 
 const (
@@ -48,26 +52,82 @@ func (p *Process) Stop() {
 	p.State = StateStopped
 }
 
+// root 返回Actor所属系统的RootContext，未绑定系统时使用默认的 System
+func (p *Process) root() *actor.RootContext {
+	if p.system != nil {
+		return p.system.actorSystem.Root
+	}
+	return System.actorSystem.Root
+}
+
+// owner 返回Actor所属的系统，未绑定系统时返回默认的 System
+func (p *Process) owner() *ActorSystem {
+	if p.system != nil {
+		return p.system
+	}
+	return System
+}
+
 func (p *Process) GetPID() *actor.PID {
 	return p.PID
 }
 
+// MailboxStats 返回Actor邮箱的统计信息
+func (p *Process) MailboxStats() (MailboxStats, bool) {
+	if p.mailbox == nil {
+		return MailboxStats{}, false
+	}
+	return p.mailbox.Stats(), true
+}
+
 func (p *Process) RequestFuture(msg any, timeout ...time.Duration) (any, error) {
+	return p.request(context.Background(), msg, parseTimeout(timeout...))
+}
+
+// RequestFutureContext 发送消息到Actor并等待回复，ctx 取消或到达截止时间时立即返回 ctx.Err()
+// ctx 没有截止时间时等待到取消为止，不能取消的ctx使用默认超时；被调用的Actor通过 IContext.Context 观察到取消
+func (p *Process) RequestFutureContext(ctx context.Context, msg any) (any, error) {
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return p.request(ctx, msg, timeout)
+}
+
+// request timeout 小于0时不设超时
+func (p *Process) request(ctx context.Context, msg any, timeout time.Duration) (any, error) {
 	p.rw.RLock()
 	if p.stopped() {
 		p.rw.RUnlock()
 		return nil, ErrActorStopped
 	}
+	if p.owner().overloaded(p.ActorName) {
+		p.rw.RUnlock()
+		return nil, ErrActorOverloaded
+	}
 
-	future := System.ActorSystem().Root.RequestFuture(p.PID, &RequestMessage{
-		MsgType: MessageTypeRequest,
-		Message: msg,
-	}, parseTimeout(timeout...))
+	rm := &RequestMessage{
+		MsgType:   MessageTypeRequest,
+		Message:   msg,
+		ActorName: p.ActorName,
+		Pattern:   p.Pattern,
+	}
+	rm.untrace()
+	rm.withContext(ctx, timeout)
+	root := p.root()
+	future := root.RequestFuture(p.PID, rm, timeout)
 
 	p.rw.RUnlock()
 
-	result, err := future.Result()
+	return requestResult(awaitFuture(ctx, root, future))
+}
+
+// requestResult 将future的结果转换为请求的返回值，Actor回复的错误作为请求的错误返回
+func requestResult(result any, err error) (any, error) {
 	if err != nil {
+		if errors.Is(err, actor.ErrDeadLetter) {
+			return nil, ErrDeadLetter
+		}
 		return nil, err
 	}
 
@@ -79,16 +139,46 @@ func (p *Process) RequestFuture(msg any, timeout ...time.Duration) (any, error)
 	}
 }
 
-func (p *Process) Send(msg any) error {
+func (p *Process) Send(msg any, ops ...SendOption) error {
+	return p.deliver(p.newSendMessage(msg, ops...))
+}
+
+// SendPriority 发送高优先级消息，在邮箱中优先于普通消息处理
+func (p *Process) SendPriority(msg any, ops ...SendOption) error {
+	return p.Send(msg, append(ops, WithPriority(PriorityHigh))...)
+}
+
+func (p *Process) newSendMessage(msg any, ops ...SendOption) *RequestMessage {
+	rm := &RequestMessage{
+		MsgType:   MessageTypeSend,
+		Message:   msg,
+		ActorName: p.ActorName,
+		Pattern:   p.Pattern,
+		props:     p.Props,
+	}
+	for i := range ops {
+		ops[i](rm)
+	}
+	rm.untrace()
+	return rm
+}
+
+// deliver 投递已经构造好的消息，死信重投时复用原消息
+func (p *Process) deliver(msg *RequestMessage) error {
 	p.rw.RLock()
 	defer p.rw.RUnlock()
 	if p.stopped() {
 		return ErrActorStopped
 	}
+	if p.mailbox != nil && p.mailbox.rejectsNow() {
+		p.mailbox.rejected.Add(1)
+		return ErrMailboxFull
+	}
 
-	System.ActorSystem().Root.Send(p.PID, &RequestMessage{
-		MsgType: MessageTypeSend,
-		Message: msg,
-	})
+	if msg.sender != nil {
+		p.root().RequestWithCustomSender(p.PID, msg, msg.sender)
+		return nil
+	}
+	p.root().Send(p.PID, msg)
 	return nil
 }
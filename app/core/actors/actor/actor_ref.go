@@ -1,11 +1,12 @@
 package actor
 
 import (
+	"context"
 	"errors"
 	"time"
 )
 
-// NewActorRef 创建一个新的ActorRef实例
+// NewActorRef 创建一个新的ActorRef实例，绑定到默认的 System，使用 ActorSystem.NewActorRef 绑定到指定的实例
 // ActorRef为Actor的代理。它主要的作用是支持向它所代表的Actor发送消息，
 // 从而实现Actor之间的通信。通过ActorRef，可以避免直接访问或操作Actor的内部信息和状态。
 func NewActorRef(props *Props, actorName, pattern string, ops ...PropsOption) *ActorRef {
@@ -27,27 +28,126 @@ func NewActorRef(props *Props, actorName, pattern string, ops ...PropsOption) *A
 // Send 发送消息到Actor
 // 如果Actor正在停止，则尝试排队订阅新Actor事件，
 // 当新的Actor启动就绪后，重新发送消息
-func (actorRef *ActorRef) Send(msg any) error {
-	if err := actorRef.ref().Send(msg); err != nil {
-		if errors.Is(err, ErrActorStopped) {
-			return actorRef.ref().Send(msg)
+// 重试后仍然失败的消息会发布到死信，可通过 WithRedelivery 要求在Actor重新激活后重投
+// Actor位于其他节点(Meta.Dispatcher.NodeId 或集群归属登记)时，消息经由 Remote 转发
+func (actorRef *ActorRef) Send(msg any, ops ...SendOption) error {
+	rm := &RequestMessage{
+		MsgType:   MessageTypeSend,
+		Message:   msg,
+		ActorName: actorRef.ActorName,
+		Pattern:   actorRef.Pattern,
+		props:     actorRef.Props,
+	}
+	for i := range ops {
+		ops[i](rm)
+	}
+	rm.untrace()
+	return actorRef.deliver(rm)
+}
+
+// deliver 投递已经构造好的消息，Pattern注册了 RouterPolicy 时由 Router 选择routee
+func (actorRef *ActorRef) deliver(rm *RequestMessage) error {
+	if r := actorRef.sys().Router(actorRef.ActorName, actorRef.Pattern); r != nil {
+		return r.deliver(actorRef.Props, rm)
+	}
+	return actorRef.deliverDirect(rm)
+}
+
+// deliverDirect 投递到ActorName对应的Actor本身
+func (actorRef *ActorRef) deliverDirect(rm *RequestMessage) error {
+	nodeId, err := actorRef.remoteNode()
+	switch {
+	case err != nil:
+	case nodeId != "":
+		if err = actorRef.sys().remote.Send(nodeId, rm); err != nil {
+			actorRef.invalidate(nodeId, err)
+		}
+	default:
+		if err = actorRef.deliverLocal(rm); errors.Is(err, ErrActorStopped) {
+			// Actor可能刚刚迁移到其他节点，重新确认所在节点
+			if nodeId, err = actorRef.remoteNode(); err == nil && nodeId != "" {
+				if err = actorRef.sys().remote.Send(nodeId, rm); err != nil {
+					actorRef.invalidate(nodeId, err)
+				}
+			} else if err == nil {
+				err = actorRef.deliverLocal(rm)
+			}
 		}
+	}
+
+	if err != nil {
+		actorRef.sys().DeadLetters().Publish(&DeadLetter{
+			ActorName: actorRef.ActorName,
+			Pattern:   actorRef.Pattern,
+			Message:   rm,
+			Reason:    err,
+		})
 		return err
 	}
 	return nil
 }
 
+// SendPriority 发送高优先级消息到Actor
+// 消息会排在目标Actor邮箱中所有普通消息之前，同优先级的消息保持发送顺序
+func (actorRef *ActorRef) SendPriority(msg any, ops ...SendOption) error {
+	return actorRef.Send(msg, append(ops, WithPriority(PriorityHigh))...)
+}
+
 // RequestFuture 发送消息到Actor并等待消息回复
 // 如果Actor正在停止，则尝试排队订阅新Actor事件，
 // 当新的Actor启动就绪后，重新发送消息
+// Pattern注册了 RouterPolicy 时由 Router 选择routee，广播模式不支持
 func (actorRef *ActorRef) RequestFuture(msg any, timeout ...time.Duration) (any, error) {
-	re, err := actorRef.ref().RequestFuture(msg, timeout...)
+	return actorRef.request(context.Background(), msg, parseTimeout(timeout...))
+}
+
+// RequestFutureContext 发送消息到Actor并等待回复，以 ctx 的截止时间作为超时，ctx 取消时立即返回 ctx.Err()
+// 在Actor内请求下游时传入 IContext.Context，截止时间和链路沿调用链传递
+//
+//	ref.RequestFutureContext(ctx.Context(), &pb.Request{})
+func (actorRef *ActorRef) RequestFutureContext(ctx context.Context, msg any) (any, error) {
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return actorRef.request(ctx, msg, timeout)
+}
+
+func (actorRef *ActorRef) request(ctx context.Context, msg any, timeout time.Duration) (any, error) {
+	if r := actorRef.sys().Router(actorRef.ActorName, actorRef.Pattern); r != nil {
+		return r.requestFuture(ctx, actorRef.Props, msg, timeout)
+	}
+	return actorRef.requestFutureDirect(ctx, msg, timeout)
+}
+
+func (actorRef *ActorRef) requestFutureDirect(ctx context.Context, msg any, timeout time.Duration) (any, error) {
+	nodeId, err := actorRef.remoteNode()
+	if err != nil {
+		return nil, err
+	}
+	if nodeId != "" {
+		re, err := actorRef.sys().remote.request(ctx, nodeId, actorRef.ActorName, actorRef.Pattern, msg, timeout)
+		if err != nil {
+			actorRef.invalidate(nodeId, err)
+		}
+		return re, err
+	}
+
+	re, err := actorRef.requestLocal(ctx, msg, timeout)
 	if err == nil {
 		return re, nil
 	}
 
 	if errors.Is(err, ErrActorStopped) {
-		re, err = actorRef.ref().RequestFuture(msg, timeout...)
+		// Actor可能刚刚迁移到其他节点，重新确认所在节点
+		if nodeId, _ = actorRef.remoteNode(); nodeId != "" {
+			re, err = actorRef.sys().remote.request(ctx, nodeId, actorRef.ActorName, actorRef.Pattern, msg, timeout)
+			if err != nil {
+				actorRef.invalidate(nodeId, err)
+			}
+			return re, err
+		}
+		re, err = actorRef.requestLocal(ctx, msg, timeout)
 		if err != nil {
 			return nil, err
 		}
@@ -60,14 +160,93 @@ func (actorRef *ActorRef) RequestFuture(msg any, timeout ...time.Duration) (any,
 // 当有新消息发送到目标Actor，会将Actor重新激活。
 // 调用此方法后，目标Actor将完成当前正在处理的消息，然后优雅地关闭
 // 注意: 停止操作是异步的，方法调用后立即返回，不等待Actor实际停止
+// Pattern注册了 RouterPolicy 时停止所有routee
 func (actorRef *ActorRef) Stop() {
-	_ = StopActor(actorRef.ActorName, actorRef.Pattern)
+	if r := actorRef.sys().Router(actorRef.ActorName, actorRef.Pattern); r != nil {
+		r.Stop()
+		return
+	}
+	nodeId, err := actorRef.remoteNode()
+	if err != nil {
+		return
+	}
+	if nodeId != "" {
+		_ = actorRef.sys().remote.StopActor(nodeId, actorRef.ActorName, actorRef.Pattern)
+		return
+	}
+	_ = actorRef.sys().StopActor(actorRef.ActorName, actorRef.Pattern)
+}
+
+// process 获取Actor的 Process 引用，Actor未激活时激活
+// 激活失败时返回错误，由调用者回复或发布到死信
+func (actorRef *ActorRef) process() (*Process, error) {
+	return actorRef.sys().GetOrStartActor(actorRef.ActorName, actorRef.Pattern, actorRef.Props)
+}
+
+func (actorRef *ActorRef) deliverLocal(rm *RequestMessage) error {
+	p, err := actorRef.process()
+	if err != nil {
+		return err
+	}
+	return p.deliver(rm)
 }
 
-// ref 获取Actor的 Process 引用
-// 此方法返回当前ActorRef对应的Process
-// 返回:
-//   - *Process: 返回当前Actor 引用
-func (actorRef *ActorRef) ref() *Process {
-	return actorRef.Props.getOrCreateActorPID(actorRef.ActorName, actorRef.Pattern)
+func (actorRef *ActorRef) requestLocal(ctx context.Context, msg any, timeout time.Duration) (any, error) {
+	p, err := actorRef.process()
+	if err != nil {
+		return nil, err
+	}
+	return p.request(ctx, msg, timeout)
+}
+
+// sys 返回ActorRef绑定的ActorSystem，未绑定时(NewActorRef、反序列化)使用默认的 System
+// 绑定关系记录在 Props 中，生成的protobuf结构只保留 Props 一个手工字段
+func (actorRef *ActorRef) sys() *ActorSystem {
+	if system := actorRef.Props.getSystem(); system != nil {
+		return system
+	}
+	return System
+}
+
+// remoteNode 返回Actor所在的远程节点ID，Actor位于当前节点或未启用 Remote 时返回空
+// Meta.Dispatcher.NodeId 为空时依次由 PlacementService(按Pattern的放置策略) 和 OwnershipRegistry 确定Actor所在节点
+// 解析结果为当前节点但Actor已经迁出时，返回迁移的目标节点；Actor正在迁出时返回当前节点
+func (actorRef *ActorRef) remoteNode() (string, error) {
+	system := actorRef.sys()
+	if system == nil || system.remote == nil {
+		return "", nil
+	}
+	if _, migrating := system.migrating.Load(actorRef.ActorName); migrating {
+		return "", nil
+	}
+
+	var err error
+	meta := actorRef.Props.GetMeta()
+	nodeId := meta.GetDispatcher().GetNodeId()
+	if nodeId == "" && system.placement != nil {
+		if nodeId, err = system.placement.Place(actorRef.ActorName, actorRef.Pattern, meta); err != nil {
+			return "", err
+		}
+	}
+	if nodeId == "" && system.ownership != nil {
+		if nodeId, err = system.ownership.Resolve(actorRef.ActorName, actorRef.Pattern); err != nil {
+			return "", err
+		}
+	}
+	if nodeId == "" || nodeId == system.remote.NodeId() {
+		// Actor已经从本节点迁出
+		return system.migratedTo(actorRef.ActorName), nil
+	}
+	return nodeId, nil
+}
+
+// invalidate 转发到 nodeId 失败时删除迁出记录和归属登记缓存的位置，下一次发送时重新解析
+func (actorRef *ActorRef) invalidate(nodeId string, err error) {
+	actorRef.sys().forgetMigration(actorRef.ActorName, nodeId, err)
+	if actorRef.sys().ownership == nil || actorRef.Props.GetMeta().GetDispatcher().GetNodeId() != "" {
+		return
+	}
+	if errors.Is(err, ErrRemoteUnavailable) || errors.Is(err, ErrNodeNotFound) {
+		actorRef.sys().ownership.Invalidate(actorRef.ActorName)
+	}
 }
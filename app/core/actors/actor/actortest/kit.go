@@ -0,0 +1,454 @@
+package actortest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/core/actors/actor"
+	protoactor "github.com/asynkron/protoactor-go/actor"
+)
+
+/*
+	Kit 单个 Behavior 的确定性测试工具
+
+	1. 每个Kit使用独立的 actor.ActorSystem 实例，不修改默认的 actor.System，测试可以并行执行；
+	   被测Actor通过 IContext.GetSystem().NewActorRef 访问的协作Actor运行在同一个实例中
+	2. 被测Actor运行真实的 ChildActor 逻辑(行为状态、暂存、定时器、保活检测)，
+	   Send/Request/Forward 在测试goroutine中同步处理，处理过程中Actor发给自己的消息在返回前按顺序处理完
+	3. 定时器和保活检测由 actor.MockClock 驱动，Advance 推进时间后同步处理到期的定时器，不需要sleep
+	4. 记录生命周期事件：初始化、停止中、已停止、被动化、崩溃
+	5. AwaitInit 的加载在独立的goroutine中执行，使用 Await 等待加载结果送达
+*/
+
+var (
+	ErrNoReply   = errors.New("actortest: request has no reply")
+	ErrStopped   = errors.New("actortest: actor stopped")
+	ErrAwaitTime = errors.New("actortest: await timeout")
+)
+
+// DefaultStart 默认的虚拟时钟起点
+var DefaultStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type EventKind int8
+
+const (
+	EventInit       EventKind = iota // HandleInit 执行完成，Err为初始化错误
+	EventStopping                    // HandleStopping 执行完成
+	EventStopped                     // HandleStopped 执行完成
+	EventPassivated                  // Actor请求父Actor停止自己(保活超时或异步初始化失败)
+	EventCrashed                     // 处理消息时panic，Err为 *actor.ActorCrash
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventInit:
+		return "init"
+	case EventStopping:
+		return "stopping"
+	case EventStopped:
+		return "stopped"
+	case EventPassivated:
+		return "passivated"
+	case EventCrashed:
+		return "crashed"
+	}
+	return "unknown(" + strconv.Itoa(int(k)) + ")"
+}
+
+type Event struct {
+	Kind EventKind
+	Err  error
+}
+
+type Option func(o *options)
+
+type options struct {
+	actorName string
+	pattern   string
+	start     time.Time
+	props     []actor.PropsOption
+	factories []factoryEntry
+}
+
+type factoryEntry struct {
+	pattern string
+	factory actor.BehaivorFactory
+}
+
+// WithName 设置被测Actor的ActorName和Pattern
+func WithName(actorName, pattern string) Option {
+	return func(o *options) {
+		o.actorName, o.pattern = actorName, pattern
+	}
+}
+
+// WithStart 设置虚拟时钟的起点，默认为 DefaultStart
+func WithStart(start time.Time) Option {
+	return func(o *options) {
+		o.start = start
+	}
+}
+
+// WithProps 设置被测Actor的Props，例如 actor.WithStashUntilInit()、actor.WithAliveTimeout
+func WithProps(ops ...actor.PropsOption) Option {
+	return func(o *options) {
+		o.props = append(o.props, ops...)
+	}
+}
+
+// WithFactory 在Kit的系统中注册协作Actor的工厂，例如 Probe.Factory()
+func WithFactory(pattern string, factory actor.BehaivorFactory) Option {
+	return func(o *options) {
+		o.factories = append(o.factories, factoryEntry{pattern: pattern, factory: factory})
+	}
+}
+
+type envelope struct {
+	msg    any
+	sender *protoactor.PID
+}
+
+// stopRequest Actor请求父Actor停止自己
+type stopRequest struct{}
+
+// Kit 单个Actor的测试工具，所有方法只允许在测试goroutine中调用
+type Kit struct {
+	t      testing.TB
+	system *protoactor.ActorSystem
+	af     *actor.ActorSystem
+	clock  *actor.MockClock
+	child  *actor.ChildActor
+	ctx    *kitContext
+
+	mu     sync.Mutex
+	queue  []envelope
+	notify chan struct{}
+
+	seq      atomic.Int64
+	replies  map[string]any
+	received []any
+	sent     []Sent
+	events   []Event
+	stopped  bool
+}
+
+// Sent Actor通过 actor.Context 发给其他PID的消息，发给其他Actor的业务消息使用 Probe 捕获
+type Sent struct {
+	Target *protoactor.PID
+	Msg    any
+}
+
+// New 创建被测Actor并执行 HandleInit
+func New(t testing.TB, behavior actor.Behavior, ops ...Option) *Kit {
+	o := &options{actorName: "actortest", pattern: "actortest", start: DefaultStart}
+	for _, op := range ops {
+		op(o)
+	}
+
+	k := &Kit{
+		t:       t,
+		system:  protoactor.NewActorSystem(),
+		clock:   actor.NewMockClock(o.start),
+		notify:  make(chan struct{}, 1),
+		replies: make(map[string]any),
+	}
+
+	factories := actor.NewFactoryRegistry()
+	for _, f := range o.factories {
+		factories.Reg(f.pattern, f.factory)
+	}
+	k.af = actor.NewActorFacade(k.system, actor.WithFactories(factories), actor.WithLevels(actor.NewLevelRegistry()))
+	t.Cleanup(func() {
+		k.Stop()
+		k.system.Shutdown()
+	})
+
+	props := actor.NewProps()
+	for _, op := range append(o.props, actor.WithClock(k.clock)) {
+		op(props)
+	}
+
+	// 发给Actor自身PID的消息(例如 AwaitInit 的加载结果)进入Kit的队列
+	self, err := k.system.Root.SpawnNamed(protoactor.PropsFromFunc(func(c protoactor.Context) {
+		switch c.Message().(type) {
+		case protoactor.SystemMessage, protoactor.AutoReceiveMessage:
+		default:
+			k.enqueue(envelope{msg: c.Message(), sender: c.Sender()})
+		}
+	}), "actortest/"+o.actorName)
+	if err != nil {
+		t.Fatalf("actortest: spawn %s failed: %v", o.actorName, err)
+	}
+	k.ctx = &kitContext{kit: k, self: self, parent: protoactor.NewPID("actortest", "parent")}
+
+	k.child = actor.NewChildActor(behavior, o.actorName, o.pattern, props.GetMeta(), props.GetAliveTimeout(), func(err error) error {
+		k.events = append(k.events, Event{Kind: EventInit, Err: err})
+		return nil
+	})
+	k.child.SetProps(props)
+	k.child.SetSystem(k.af)
+
+	k.deliver(envelope{msg: &protoactor.Started{}})
+	k.drain()
+	return k
+}
+
+// Context 返回被测Actor的 IContext，用于检查定时器、行为状态等
+func (k *Kit) Context() actor.IContext {
+	return k.child
+}
+
+// System 返回Kit的ActorSystem，协作Actor运行在此实例中
+func (k *Kit) System() *actor.ActorSystem {
+	return k.af
+}
+
+// Clock 返回驱动定时器的虚拟时钟
+func (k *Kit) Clock() *actor.MockClock {
+	return k.clock
+}
+
+// Send 同步处理一条Send消息
+func (k *Kit) Send(msg any) error {
+	return k.post(actor.MessageTypeSend, msg, nil)
+}
+
+// Forward 同步处理一条Forward消息
+func (k *Kit) Forward(msg any) error {
+	return k.post(actor.MessageTypeForward, msg, nil)
+}
+
+// Request 同步处理一条Request消息并返回回复
+// 消息被暂存且在返回前没有重新处理时返回 ErrNoReply
+func (k *Kit) Request(msg any) (any, error) {
+	sender := protoactor.NewPID("actortest", "request-"+strconv.FormatInt(k.seq.Add(1), 10))
+	if err := k.post(actor.MessageTypeRequest, msg, sender); err != nil {
+		return nil, err
+	}
+	re, ok := k.replies[sender.Id]
+	if !ok {
+		return nil, ErrNoReply
+	}
+	delete(k.replies, sender.Id)
+	if err, ok := re.(error); ok {
+		return nil, err
+	}
+	return re, nil
+}
+
+func (k *Kit) post(msgType int8, msg any, sender *protoactor.PID) error {
+	if k.stopped {
+		return ErrStopped
+	}
+	k.received = append(k.received, msg)
+	k.deliver(envelope{
+		msg: &actor.RequestMessage{
+			ActorName: k.child.GetActorName(),
+			Pattern:   k.child.GetPattern(),
+			MsgType:   msgType,
+			Message:   msg,
+		},
+		sender: sender,
+	})
+	k.drain()
+	return nil
+}
+
+// Advance 推进虚拟时钟并同步处理到期的定时器
+func (k *Kit) Advance(d time.Duration) {
+	k.clock.Advance(d)
+	k.drain()
+}
+
+// Await 等待异步送达的消息(例如 AwaitInit 的加载结果)，直到cond返回true或超时
+func (k *Kit) Await(cond func() bool, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		k.drain()
+		if cond() {
+			return nil
+		}
+		select {
+		case <-k.notify:
+		case <-deadline.C:
+			return ErrAwaitTime
+		}
+	}
+}
+
+// Stop 停止被测Actor，执行 HandleStopping 和 HandleStopped
+func (k *Kit) Stop() {
+	if k.stopped {
+		return
+	}
+	k.stopped = true
+	k.deliver(envelope{msg: &protoactor.Stopping{}})
+	k.events = append(k.events, Event{Kind: EventStopping})
+	k.deliver(envelope{msg: &protoactor.Stopped{}})
+	k.events = append(k.events, Event{Kind: EventStopped})
+	if k.child.TimerMgr != nil {
+		k.child.TimerMgr.Stop()
+	}
+	k.system.Root.Stop(k.ctx.self)
+}
+
+// Stopped 被测Actor是否已经停止
+func (k *Kit) Stopped() bool {
+	return k.stopped
+}
+
+// Events 返回按发生顺序记录的生命周期事件
+func (k *Kit) Events() []Event {
+	return append([]Event(nil), k.events...)
+}
+
+// EventKinds 返回按发生顺序记录的生命周期事件类型
+func (k *Kit) EventKinds() []EventKind {
+	kinds := make([]EventKind, 0, len(k.events))
+	for _, e := range k.events {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+// ExpectEvents 断言生命周期事件依次为kinds
+func (k *Kit) ExpectEvents(kinds ...EventKind) {
+	k.t.Helper()
+	got := k.EventKinds()
+	if fmt.Sprint(got) != fmt.Sprint(kinds) {
+		k.t.Errorf("actortest: expected events %v, got %v", kinds, got)
+	}
+}
+
+// Received 返回Actor收到的所有业务消息
+func (k *Kit) Received() []any {
+	return append([]any(nil), k.received...)
+}
+
+// Sent 返回Actor通过 actor.Context 发给其他PID的消息
+func (k *Kit) Sent() []Sent {
+	return append([]Sent(nil), k.sent...)
+}
+
+func (k *Kit) enqueue(env envelope) {
+	k.mu.Lock()
+	k.queue = append(k.queue, env)
+	k.mu.Unlock()
+	select {
+	case k.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (k *Kit) prepend(envs []envelope) {
+	k.mu.Lock()
+	k.queue = append(envs, k.queue...)
+	k.mu.Unlock()
+}
+
+// drain 按顺序处理队列中的消息，直到队列为空
+func (k *Kit) drain() {
+	for {
+		k.mu.Lock()
+		if len(k.queue) == 0 {
+			k.mu.Unlock()
+			return
+		}
+		env := k.queue[0]
+		k.queue = k.queue[1:]
+		k.mu.Unlock()
+
+		if _, ok := env.msg.(*stopRequest); ok {
+			k.events = append(k.events, Event{Kind: EventPassivated})
+			k.Stop()
+			continue
+		}
+		if !k.stopped {
+			k.deliver(env)
+		}
+	}
+}
+
+// deliver 处理一条消息，UnstashAll 放回的消息排在队列头部
+func (k *Kit) deliver(env envelope) {
+	ctx := k.ctx
+	ctx.msg, ctx.sender, ctx.unstashed = env.msg, env.sender, nil
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Errorf("%v", r)
+			}
+			k.events = append(k.events, Event{Kind: EventCrashed, Err: err})
+		}
+		if len(ctx.unstashed) > 0 {
+			k.prepend(ctx.unstashed)
+		}
+		ctx.msg, ctx.sender, ctx.unstashed = nil, nil, nil
+	}()
+	k.child.Receive(ctx)
+}
+
+// kitContext 被测Actor使用的 protoactor Context，只实现了 ChildActor 用到的方法
+type kitContext struct {
+	protoactor.Context
+	kit          *Kit
+	self, parent *protoactor.PID
+	msg          any
+	sender       *protoactor.PID
+	unstashed    []envelope
+}
+
+func (c *kitContext) Message() any {
+	return c.msg
+}
+
+func (c *kitContext) Sender() *protoactor.PID {
+	return c.sender
+}
+
+func (c *kitContext) Self() *protoactor.PID {
+	return c.self
+}
+
+func (c *kitContext) Parent() *protoactor.PID {
+	return c.parent
+}
+
+func (c *kitContext) ActorSystem() *protoactor.ActorSystem {
+	return c.kit.system
+}
+
+func (c *kitContext) Respond(response any) {
+	if c.sender == nil {
+		return
+	}
+	c.kit.replies[c.sender.Id] = response
+}
+
+func (c *kitContext) Send(pid *protoactor.PID, message any) {
+	switch {
+	case pid.Equal(c.self):
+		c.kit.enqueue(envelope{msg: message})
+	case pid.Equal(c.parent):
+		if _, ok := message.(*actor.PoisonActorMessage); ok {
+			c.kit.enqueue(envelope{msg: &stopRequest{}})
+		}
+	default:
+		c.kit.sent = append(c.kit.sent, Sent{Target: pid, Msg: message})
+	}
+}
+
+func (c *kitContext) RequestWithCustomSender(pid *protoactor.PID, message any, sender *protoactor.PID) {
+	if pid.Equal(c.self) {
+		c.unstashed = append(c.unstashed, envelope{msg: message, sender: sender})
+		return
+	}
+	c.kit.sent = append(c.kit.sent, Sent{Target: pid, Msg: message})
+}
@@ -0,0 +1,158 @@
+package actortest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/core/actors/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+const probePattern = "actortest-probe-pattern"
+
+type timerTick struct{}
+
+// CounterBehavior 计数，tick定时器到期时通知协作Actor
+type CounterBehavior struct {
+	count  int
+	ticks  []string
+	inited bool
+	load   chan int
+}
+
+func (b *CounterBehavior) HandleRequest(ctx actor.IContext, msg any) (any, error) {
+	switch m := msg.(type) {
+	case string:
+		switch m {
+		case "get":
+			return b.count, nil
+		case "tick":
+			ctx.AddTimerOnce("tick", time.Minute, &timerTick{})
+			return nil, nil
+		case "crash":
+			panic("crash")
+		}
+	case int:
+		b.count += m
+		return b.count, nil
+	}
+	return nil, errors.New("unknown")
+}
+
+func (b *CounterBehavior) HandleSend(ctx actor.IContext, msg any) {
+	if n, ok := msg.(int); ok {
+		b.count += n
+	}
+}
+
+func (b *CounterBehavior) HandleTimer(ctx actor.IContext, key string, msg any) {
+	b.ticks = append(b.ticks, key)
+	_ = ctx.GetSystem().NewActorRef(actor.NewProps(), "probe-1", probePattern).Send(key)
+}
+
+func (b *CounterBehavior) HandleForward(ctx actor.IContext, _ any) {}
+
+func (b *CounterBehavior) HandleInit(ctx actor.IContext) error {
+	if b.load != nil {
+		ctx.AwaitInit(func() (any, error) {
+			return <-b.load, nil
+		}, func(result any, err error) error {
+			b.count = result.(int)
+			return err
+		})
+	}
+	b.inited = true
+	return nil
+}
+
+func (b *CounterBehavior) HandleStopping(ctx actor.IContext) error { return nil }
+
+func (b *CounterBehavior) HandleStopped(ctx actor.IContext) error { return nil }
+
+func TestKit_RequestAndSend(t *testing.T) {
+	t.Parallel()
+	b := &CounterBehavior{}
+	k := New(t, b)
+
+	re, err := k.Request(2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, re)
+
+	assert.NoError(t, k.Send(3))
+	re, err = k.Request("get")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, re)
+
+	_, err = k.Request(struct{}{})
+	assert.EqualError(t, err, "unknown")
+	assert.Equal(t, []any{2, 3, "get", struct{}{}}, k.Received())
+
+	k.Stop()
+	k.ExpectEvents(EventInit, EventStopping, EventStopped)
+	assert.ErrorIs(t, k.Send(1), ErrStopped)
+}
+
+func TestKit_TimersAndProbe(t *testing.T) {
+	t.Parallel()
+	probe := NewProbe()
+	b := &CounterBehavior{}
+	k := New(t, b, WithFactory(probePattern, probe.Factory()))
+
+	_, err := k.Request("tick")
+	assert.NoError(t, err)
+	k.Advance(59 * time.Second)
+	assert.Empty(t, b.ticks)
+	k.Advance(time.Second)
+	assert.Equal(t, []string{"tick"}, b.ticks)
+
+	r := probe.ExpectMsg(t, time.Second)
+	assert.Equal(t, Received{ActorName: "probe-1", MsgType: actor.MessageTypeSend, Msg: "tick"}, r)
+	probe.ExpectNoMsg(t, 50*time.Millisecond)
+	assert.Equal(t, []EventKind{EventInit}, probe.Events("probe-1"))
+}
+
+func TestKit_Passivation(t *testing.T) {
+	t.Parallel()
+	k := New(t, &CounterBehavior{}, WithProps(actor.WithAliveTimeout(10*time.Minute)))
+
+	k.Advance(5 * time.Minute)
+	_, err := k.Request("get")
+	assert.NoError(t, err)
+	k.Advance(9 * time.Minute)
+	assert.False(t, k.Stopped(), "activity resets the alive timeout")
+
+	k.Advance(10 * time.Minute)
+	assert.True(t, k.Stopped())
+	k.ExpectEvents(EventInit, EventPassivated, EventStopping, EventStopped)
+}
+
+func TestKit_StashUntilInit(t *testing.T) {
+	t.Parallel()
+	b := &CounterBehavior{load: make(chan int, 1)}
+	k := New(t, b, WithProps(actor.WithStashUntilInit()))
+
+	// 初始化完成前的Request被暂存，没有回复
+	_, err := k.Request(1)
+	assert.ErrorIs(t, err, ErrNoReply)
+	assert.Equal(t, 1, k.Context().StashSize())
+
+	b.load <- 10
+	assert.NoError(t, k.Await(func() bool { return k.Context().StashSize() == 0 }, time.Second))
+	re, err := k.Request("get")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, re)
+}
+
+func TestKit_Crash(t *testing.T) {
+	t.Parallel()
+	k := New(t, &CounterBehavior{})
+
+	// 崩溃时Request收到 ErrActorCrashed 回复
+	_, err := k.Request("crash")
+	assert.ErrorIs(t, err, actor.ErrActorCrashed)
+	events := k.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, EventCrashed, events[1].Kind)
+	assert.Error(t, events[1].Err)
+}
@@ -0,0 +1,140 @@
+package actortest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/core/actors/actor"
+)
+
+/*
+	Probe 捕获消息的协作Actor
+
+	1. Probe 实现了 actor.Behavior，通过 WithFactory(pattern, probe.Factory()) 注册为协作Actor的工厂后，被测Actor发给协作Actor的消息都被记录
+	2. 协作Actor在Kit的独立系统中由真实的运行时调度，使用 ExpectMsg 等待消息送达，不需要sleep
+	3. Request 的回复默认为nil，使用 OnRequest 自定义
+*/
+
+// Received Probe 收到的一条消息
+type Received struct {
+	ActorName string
+	MsgType   int8 // actor.MessageTypeSend / MessageTypeRequest / MessageTypeForward
+	Msg       any
+}
+
+type Probe struct {
+	mu       sync.Mutex
+	received []Received
+	events   map[string][]EventKind
+	ch       chan Received
+	reply    func(actorName string, msg any) (any, error)
+}
+
+func NewProbe() *Probe {
+	return &Probe{
+		events: make(map[string][]EventKind),
+		ch:     make(chan Received, 1024),
+	}
+}
+
+// Factory 返回使用此Probe的工厂，通过 WithFactory 注册到Kit的系统中
+func (p *Probe) Factory() actor.BehaivorFactory {
+	return func(actorName string) actor.Behavior {
+		return p
+	}
+}
+
+// OnRequest 设置Request消息的回复
+func (p *Probe) OnRequest(reply func(actorName string, msg any) (any, error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reply = reply
+}
+
+// ExpectMsg 等待下一条消息，超时时测试失败
+func (p *Probe) ExpectMsg(t testing.TB, timeout time.Duration) Received {
+	t.Helper()
+	select {
+	case r := <-p.ch:
+		return r
+	case <-time.After(timeout):
+		t.Fatalf("actortest: probe received no message within %v", timeout)
+		return Received{}
+	}
+}
+
+// ExpectNoMsg 断言d时间内没有收到消息
+func (p *Probe) ExpectNoMsg(t testing.TB, d time.Duration) {
+	t.Helper()
+	select {
+	case r := <-p.ch:
+		t.Errorf("actortest: probe expected no message, got %#v", r)
+	case <-time.After(d):
+	}
+}
+
+// Messages 返回收到的所有消息
+func (p *Probe) Messages() []Received {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Received(nil), p.received...)
+}
+
+// Events 返回协作Actor的生命周期事件
+func (p *Probe) Events(actorName string) []EventKind {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]EventKind(nil), p.events[actorName]...)
+}
+
+func (p *Probe) record(ctx actor.IContext, msgType int8, msg any) {
+	r := Received{ActorName: ctx.GetActorName(), MsgType: msgType, Msg: msg}
+	p.mu.Lock()
+	p.received = append(p.received, r)
+	p.mu.Unlock()
+	select {
+	case p.ch <- r:
+	default:
+	}
+}
+
+func (p *Probe) event(ctx actor.IContext, kind EventKind) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events[ctx.GetActorName()] = append(p.events[ctx.GetActorName()], kind)
+}
+
+func (p *Probe) HandleRequest(ctx actor.IContext, msg any) (any, error) {
+	p.record(ctx, actor.MessageTypeRequest, msg)
+	p.mu.Lock()
+	reply := p.reply
+	p.mu.Unlock()
+	if reply == nil {
+		return nil, nil
+	}
+	return reply(ctx.GetActorName(), msg)
+}
+
+func (p *Probe) HandleSend(ctx actor.IContext, msg any) {
+	p.record(ctx, actor.MessageTypeSend, msg)
+}
+
+func (p *Probe) HandleForward(ctx actor.IContext, msg any) {
+	p.record(ctx, actor.MessageTypeForward, msg)
+}
+
+func (p *Probe) HandleInit(ctx actor.IContext) error {
+	p.event(ctx, EventInit)
+	return nil
+}
+
+func (p *Probe) HandleStopping(ctx actor.IContext) error {
+	p.event(ctx, EventStopping)
+	return nil
+}
+
+func (p *Probe) HandleStopped(ctx actor.IContext) error {
+	p.event(ctx, EventStopped)
+	return nil
+}
@@ -0,0 +1,184 @@
+package actor
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+	管理端点，只读，返回JSON
+
+	GET /actors?pattern=&level=&detail=&timeout=   已激活的Actor，level可以重复，detail=true时逐个检视
+	GET /actors/{actorName}?timeout=               单个Actor的快照
+	GET /supervisors?timeout=                      每个等级的supervisor
+	GET /shutdown                                  最近一次关闭的进度
+
+	挂载在已有的HTTP服务上，例如:
+	mux.Handle("/debug/actor/", http.StripPrefix("/debug/actor", actor.System.AdminHandler()))
+*/
+
+// AdminHandler 返回管理端点的 http.Handler
+func (af *ActorSystem) AdminHandler() http.Handler {
+	return &adminHandler{af: af}
+}
+
+type adminHandler struct {
+	af *ActorSystem
+}
+
+// levelProgressView LevelProgress 的JSON形式
+type levelProgressView struct {
+	Level     Level     `json:"level"`
+	State     string    `json:"state"`
+	Remaining []string  `json:"remaining"`
+	Attempts  int       `json:"attempts"`
+	StartedAt time.Time `json:"startedAt"`
+	Elapsed   string    `json:"elapsed"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type shutdownView struct {
+	Started bool                `json:"started"`
+	Done    bool                `json:"done"`
+	Levels  []levelProgressView `json:"levels"`
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAdminError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "actors":
+		h.listActors(w, r)
+	case strings.HasPrefix(path, "actors/"):
+		h.inspectActor(w, r, strings.TrimPrefix(path, "actors/"))
+	case path == "supervisors":
+		h.supervisors(w, r)
+	case path == "shutdown":
+		h.shutdown(w)
+	default:
+		writeAdminError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+func (h *adminHandler) listActors(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := ActorFilter{Pattern: query.Get("pattern")}
+	for _, s := range query["level"] {
+		lv, err := strconv.Atoi(s)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, errors.New("invalid level: "+s))
+			return
+		}
+		filter.Levels = append(filter.Levels, Level(lv))
+	}
+	if s := query.Get("detail"); s != "" {
+		detail, err := strconv.ParseBool(s)
+		if err != nil {
+			writeAdminError(w, http.StatusBadRequest, errors.New("invalid detail: "+s))
+			return
+		}
+		filter.Detail = detail
+	}
+	timeout, err := parseAdminTimeout(r)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.Timeout = timeout
+
+	actors := h.af.ListActors(filter)
+	if actors == nil {
+		actors = []ActorStatus{}
+	}
+	writeAdminJSON(w, http.StatusOK, actors)
+}
+
+func (h *adminHandler) inspectActor(w http.ResponseWriter, r *http.Request, actorName string) {
+	timeout, err := parseAdminTimeout(r)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	info, err := h.af.InspectActor(actorName, timeout)
+	switch {
+	case errors.Is(err, ErrActorNotFound):
+		writeAdminError(w, http.StatusNotFound, err)
+	case err != nil:
+		// 检视失败时仍然返回概要，便于排查卡住的Actor
+		info.Error = err.Error()
+		writeAdminJSON(w, http.StatusOK, info)
+	default:
+		writeAdminJSON(w, http.StatusOK, info)
+	}
+}
+
+func (h *adminHandler) supervisors(w http.ResponseWriter, r *http.Request) {
+	timeout, err := parseAdminTimeout(r)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	infos, err := h.af.Supervisors(timeout)
+	if err != nil {
+		writeAdminError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, infos)
+}
+
+func (h *adminHandler) shutdown(w http.ResponseWriter) {
+	progress, started := h.af.ShutdownProgress()
+	view := shutdownView{
+		Started: started,
+		Done:    started && progress.Done(),
+		Levels:  make([]levelProgressView, 0, len(progress.Levels)),
+	}
+	for _, lv := range progress.Levels {
+		v := levelProgressView{
+			Level:     lv.Level,
+			State:     lv.State.String(),
+			Remaining: lv.Remaining,
+			Attempts:  lv.Attempts,
+			StartedAt: lv.StartedAt,
+			Elapsed:   lv.Elapsed.String(),
+		}
+		if v.Remaining == nil {
+			v.Remaining = []string{}
+		}
+		if lv.Err != nil {
+			v.Error = lv.Err.Error()
+		}
+		view.Levels = append(view.Levels, v)
+	}
+	writeAdminJSON(w, http.StatusOK, view)
+}
+
+func parseAdminTimeout(r *http.Request) (time.Duration, error) {
+	s := r.URL.Query().Get("timeout")
+	if s == "" {
+		return DefaultInspectTimeout, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, errors.New("invalid timeout: " + s)
+	}
+	return d, nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,175 @@
+package actor
+
+import (
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"go.uber.org/zap"
+)
+
+const behaviorStateTimerKey = "system_behavior_state_timer"
+
+// BehaviorState 子Actor的行为状态，用于实现阶段式的状态机(例如房间的 等待->倒计时->游戏中->结算)
+//
+//  1. 处理函数为nil时，消息交给 Behavior 对应的方法处理
+//  2. OnEnter/OnExit 在状态切换时于Actor的goroutine中执行
+//  3. Timeout > 0 时，进入状态后经过 Timeout 仍未离开，执行 OnTimeout，通常在 OnTimeout 中调用 Become 切换到下一个状态
+type BehaviorState struct {
+	Name          string
+	HandleRequest func(ctx IContext, msg any) (any, error)
+	HandleSend    func(ctx IContext, msg any)
+	HandleForward func(ctx IContext, msg any)
+	HandleTimer   func(ctx IContext, key string, msg any)
+	OnEnter       func(ctx IContext)
+	OnExit        func(ctx IContext)
+	Timeout       time.Duration
+	OnTimeout     func(ctx IContext)
+}
+
+func (s *BehaviorState) GetName() string {
+	if s == nil {
+		return ""
+	}
+	return s.Name
+}
+
+// behaviorStack 子Actor的行为状态栈，栈顶为当前状态
+type behaviorStack struct {
+	states []*BehaviorState
+	seq    uint64 // 每次进入状态时递增，用于识别过期的超时消息
+}
+
+// behaviorStateTimeout 状态超时消息，seq 用于忽略已经离开的状态的超时
+type behaviorStateTimeout struct {
+	seq uint64
+}
+
+// Become 切换到新的行为状态，替换当前状态
+// 先执行当前状态的 OnExit，再执行新状态的 OnEnter
+func (state *ChildActor) Become(next *BehaviorState) {
+	if next == nil {
+		return
+	}
+	state.exitState()
+	if n := len(state.behaviors.states); n > 0 {
+		state.behaviors.states[n-1] = next
+	} else {
+		state.behaviors.states = append(state.behaviors.states, next)
+	}
+	state.enterState()
+}
+
+// BecomeStacked 切换到新的行为状态，保留当前状态，调用 Unbecome 后回到当前状态
+func (state *ChildActor) BecomeStacked(next *BehaviorState) {
+	if next == nil {
+		return
+	}
+	state.exitState()
+	state.behaviors.states = append(state.behaviors.states, next)
+	state.enterState()
+}
+
+// Unbecome 离开当前行为状态，回到上一个状态(重新执行其 OnEnter)，没有上一个状态时回到 Behavior
+func (state *ChildActor) Unbecome() {
+	if len(state.behaviors.states) == 0 {
+		return
+	}
+	state.exitState()
+	state.behaviors.states[len(state.behaviors.states)-1] = nil
+	state.behaviors.states = state.behaviors.states[:len(state.behaviors.states)-1]
+	state.enterState()
+}
+
+// CurrentState 返回当前的行为状态，nil表示使用 Behavior 处理消息
+func (state *ChildActor) CurrentState() *BehaviorState {
+	if len(state.behaviors.states) == 0 {
+		return nil
+	}
+	return state.behaviors.states[len(state.behaviors.states)-1]
+}
+
+func (state *ChildActor) enterState() {
+	cur := state.CurrentState()
+	state.behaviors.seq++
+	if cur == nil {
+		return
+	}
+
+	logger.GetLogger().Debug("Child actor become",
+		zap.String("ActorName", state.actorName),
+		zap.String("State", cur.Name))
+
+	if cur.OnEnter != nil {
+		cur.OnEnter(state)
+	}
+	// OnEnter 中可能已经切换到其他状态
+	if cur != state.CurrentState() {
+		return
+	}
+	if cur.Timeout > 0 && state.TimerMgr != nil {
+		state.TimerMgr.AddTimerOnce(behaviorStateTimerKey, cur.Timeout, &behaviorStateTimeout{seq: state.behaviors.seq})
+	}
+}
+
+func (state *ChildActor) exitState() {
+	cur := state.CurrentState()
+	if cur == nil {
+		return
+	}
+	if cur.Timeout > 0 && state.TimerMgr != nil {
+		state.TimerMgr.RemoveTimer(behaviorStateTimerKey)
+	}
+	if cur.OnExit != nil {
+		cur.OnExit(state)
+	}
+}
+
+func (state *ChildActor) handleStateTimeout(msg *behaviorStateTimeout) {
+	cur := state.CurrentState()
+	if cur == nil || msg.seq != state.behaviors.seq || cur.OnTimeout == nil {
+		return
+	}
+	cur.OnTimeout(state)
+}
+
+func (state *ChildActor) dispatchRequest(msg any) (any, error) {
+	if cur := state.CurrentState(); cur != nil && cur.HandleRequest != nil {
+		return cur.HandleRequest(state, msg)
+	}
+	return state.Behavior.HandleRequest(state, msg)
+}
+
+func (state *ChildActor) dispatchSend(msg any) {
+	if cur := state.CurrentState(); cur != nil && cur.HandleSend != nil {
+		cur.HandleSend(state, msg)
+		return
+	}
+	state.Behavior.HandleSend(state, msg)
+}
+
+// dispatchTimer 回调定时器在Actor内执行，其他定时器消息优先交给 HandleTimer，都未实现时交给 HandleSend
+func (state *ChildActor) dispatchTimer(key string, msg any) {
+	defer state.observeHandled(MetricMsgTimer, msg, time.Now())
+	defer state.handling.watch.end(state.handling.watch.begin(MetricMsgTimer, msg))
+	if f, ok := msg.(timerFunc); ok {
+		f(state)
+		return
+	}
+	if cur := state.CurrentState(); cur != nil && cur.HandleTimer != nil {
+		cur.HandleTimer(state, key, msg)
+		return
+	}
+	if h, ok := state.Behavior.(TimerHandler); ok {
+		h.HandleTimer(state, key, msg)
+		return
+	}
+	state.dispatchSend(msg)
+}
+
+func (state *ChildActor) dispatchForward(msg any) {
+	if cur := state.CurrentState(); cur != nil && cur.HandleForward != nil {
+		cur.HandleForward(state, msg)
+		return
+	}
+	state.Behavior.HandleForward(state, msg)
+}
@@ -0,0 +1,158 @@
+package actor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RoomBehavior 房间按 waiting -> countdown -> playing 切换阶段，countdown 超时后自动进入 playing
+type RoomBehavior struct {
+	events []string
+}
+
+func (b *RoomBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	return "base", nil
+}
+
+func (b *RoomBehavior) HandleSend(ctx IContext, msg any) {
+}
+
+func (b *RoomBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *RoomBehavior) HandleInit(ctx IContext) error {
+	ctx.Become(b.waiting())
+	return nil
+}
+
+func (b *RoomBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *RoomBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func (b *RoomBehavior) state(name string) *BehaviorState {
+	return &BehaviorState{
+		Name: name,
+		HandleRequest: func(ctx IContext, msg any) (any, error) {
+			return ctx.CurrentState().GetName(), nil
+		},
+		OnEnter: func(ctx IContext) { b.events = append(b.events, "enter:"+name) },
+		OnExit:  func(ctx IContext) { b.events = append(b.events, "exit:"+name) },
+	}
+}
+
+func (b *RoomBehavior) waiting() *BehaviorState {
+	s := b.state("waiting")
+	s.HandleRequest = func(ctx IContext, msg any) (any, error) {
+		switch msg {
+		case "start":
+			ctx.Become(b.countdown())
+		case "pause":
+			ctx.BecomeStacked(b.paused())
+		}
+		return ctx.CurrentState().GetName(), nil
+	}
+	return s
+}
+
+func (b *RoomBehavior) paused() *BehaviorState {
+	s := b.state("paused")
+	s.HandleRequest = func(ctx IContext, msg any) (any, error) {
+		if msg == "resume" {
+			ctx.Unbecome()
+		}
+		return ctx.CurrentState().GetName(), nil
+	}
+	return s
+}
+
+func (b *RoomBehavior) countdown() *BehaviorState {
+	s := b.state("countdown")
+	s.Timeout = 50 * time.Millisecond
+	s.OnTimeout = func(ctx IContext) {
+		ctx.Become(b.playing())
+	}
+	return s
+}
+
+func (b *RoomBehavior) playing() *BehaviorState {
+	s := b.state("playing")
+	s.HandleRequest = func(ctx IContext, msg any) (any, error) {
+		if msg == "finish" {
+			ctx.Unbecome()
+			return ctx.CurrentState().GetName(), nil
+		}
+		return "playing", nil
+	}
+	return s
+}
+
+func TestBehaviorState_Become(t *testing.T) {
+	const pattern = "behavior-state-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	room := &RoomBehavior{}
+	RegFactory(pattern, func(actorName string) Behavior {
+		return room
+	})
+
+	ref := NewActorRef(NewProps(), "behavior-state-room", pattern)
+	request := func(msg any) any {
+		re, err := ref.RequestFuture(msg)
+		assert.NoError(t, err)
+		return re
+	}
+
+	assert.Equal(t, "waiting", request("query"))
+	assert.Equal(t, "countdown", request("start"))
+	assert.Eventually(t, func() bool {
+		return request("query") == "playing"
+	}, time.Second, 10*time.Millisecond, "countdown should time out into playing")
+
+	// Unbecome 回到 Behavior
+	assert.Equal(t, "", request("finish"))
+	assert.Equal(t, "base", request("query"))
+
+	assert.Equal(t, []string{
+		"enter:waiting",
+		"exit:waiting", "enter:countdown",
+		"exit:countdown", "enter:playing",
+		"exit:playing",
+	}, room.events)
+}
+
+func TestBehaviorState_BecomeStacked(t *testing.T) {
+	const pattern = "behavior-state-stacked-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	room := &RoomBehavior{}
+	RegFactory(pattern, func(actorName string) Behavior {
+		return room
+	})
+
+	ref := NewActorRef(NewProps(), "behavior-state-stacked-room", pattern)
+	for _, step := range []struct{ msg, state string }{
+		{"pause", "paused"},
+		{"query", "paused"},
+		{"resume", "waiting"},
+		{"query", "waiting"},
+	} {
+		re, err := ref.RequestFuture(step.msg)
+		assert.NoError(t, err)
+		assert.Equal(t, step.state, re, step.msg)
+	}
+
+	assert.Equal(t, []string{
+		"enter:waiting",
+		"exit:waiting", "enter:paused",
+		"exit:paused", "enter:waiting",
+	}, room.events)
+}
@@ -4,10 +4,7 @@ import (
 	cmap "github.com/orcaman/concurrent-map/v2"
 )
 
-var (
-	actorsCache *ActorsCache
-)
-
+// ActorsCache 一个 ActorSystem 中已激活的Actor
 type ActorsCache struct {
 	cache cmap.ConcurrentMap[string, *Process]
 }
@@ -37,3 +34,8 @@ func (c *ActorsCache) Set(actorName string, p *Process) {
 func (c *ActorsCache) Delete(actorName string) {
 	c.cache.Remove(actorName)
 }
+
+// Range 遍历所有已激活的Actor，遍历期间持有缓存的读锁，fn 中不能修改缓存
+func (c *ActorsCache) Range(fn func(actorName string, p *Process)) {
+	c.cache.IterCb(fn)
+}
@@ -1,10 +1,11 @@
 package actor
 
 import (
+	"runtime/debug"
 	"time"
 
-	"gitee.com/orbit-w/meteor/bases/misc/utils"
 	"gitee.com/orbit-w/orbit/lib/logger"
+	"gitee.com/orbit-w/orbit/lib/tracing"
 	"github.com/asynkron/protoactor-go/actor"
 	"go.uber.org/zap"
 )
@@ -31,6 +32,25 @@ type ChildActor struct {
 	aliveTimeout       time.Duration
 	lastActivityTime   time.Time
 	aliveCheckInterval time.Duration
+	clock              Clock
+	system             *ActorSystem
+
+	mailbox      *mailbox
+	initState    int8
+	initComplete func(result any, err error) error
+
+	stash     stashState      // 消息暂存
+	behaviors behaviorStack   // 行为状态栈，栈顶为当前状态
+	handling  handlingState   // 当前处理的消息
+	migration migrationStatus // 迁移状态
+}
+
+// handlingState 消息处理相关的状态，span/reqCtx/timer 在每条消息处理结束时恢复
+type handlingState struct {
+	span   *tracing.Span   // 当前处理的消息所在的Span
+	watch  *handlerWatch   // 慢处理检测，Pattern未注册 WatchdogPolicy 时为nil
+	reqCtx *requestContext // 当前处理的消息的调用方ctx
+	timer  *timerFired     // 当前处理的定时器消息
 }
 
 // NewChildActor 创建一个新的子Actor
@@ -45,76 +65,188 @@ func NewChildActor(behavior Behavior, name, pattern string, meta *Meta, aliveTim
 		pattern:            pattern,
 		Behavior:           behavior,
 		initCallback:       initCB,
+		aliveTimeout:       aliveTimeout,
 		aliveCheckInterval: AliveCheckInterval,
 	}
 }
 
+// SetProps 应用Props中与实例相关的配置，需要在Actor启动前调用
+func (state *ChildActor) SetProps(props *Props) {
+	state.stash.untilInit = props.GetStashUntilInit()
+	state.clock = props.GetClock()
+}
+
 func (state *ChildActor) GetContext() IContext {
 	return state
 }
 
 // Receive 处理接收到的消息
 func (state *ChildActor) Receive(context actor.Context) {
-	defer utils.RecoverPanic()
+	defer state.escalateCrash(context)
 	switch msg := context.Message().(type) {
 	case *actor.Started:
 		state.SetActorContext(context)
+		state.startWatch()
 		// 执行初始化逻辑
 		state.HandleInit(context)
 
 	case *actor.Stopping:
+		if state.migration.to != "" {
+			state.forwardStash()
+		} else {
+			state.dropStash(ErrActorStopped)
+		}
+		state.GetSystem().Topics().UnsubscribeAll(state.actorName)
 		_ = state.HandleStopping(context)
 
 	case *actor.Stopped:
+		state.stopWatch()
 		state.HandleStopped(context)
 
 	case *actor.Restarting:
 		logger.GetLogger().Info("Child actor restarting", zap.String("ActorName", state.GetContext().GetActorName()))
-		// 重启时重新执行初始化逻辑
-		//state.HandleInit(context)
+		// 旧实例的定时器随实例一起废弃，重启后由新实例在 HandleInit 中重新创建
+		if state.TimerMgr != nil {
+			state.TimerMgr.Stop()
+		}
+		state.dropStash(ErrActorCrashed)
+		// 订阅随实例一起废弃，重启后由新实例重新订阅
+		state.GetSystem().Topics().UnsubscribeAll(state.actorName)
 
 	case *RequestMessage:
+		if state.stashing() {
+			state.Stash()
+			return
+		}
 		state.handleMessage(context, msg)
 
 	case *TimerMessage:
-		state.Process(func(msg any) {
-			switch msg.(type) {
+		state.ProcessTimers(func(timer *Timer) {
+			switch msg := timer.GetMsg().(type) {
 			case *CheckAliveMessage:
 				state.handleAliveCheck(context)
+			case *behaviorStateTimeout:
+				state.handleStateTimeout(msg)
 			default:
-				state.HandleSend(state, msg)
+				state.handling.timer = &timerFired{key: timer.GetKey(), msg: msg}
+				if state.stashing() {
+					state.Stash()
+				} else {
+					state.dispatchTimer(timer.GetKey(), msg)
+				}
+				state.handling.timer = nil
 			}
 		})
 
+	case *timerFired:
+		if state.stashing() {
+			state.Stash()
+			return
+		}
+		state.dispatchTimer(msg.key, msg.msg)
+
+	case *asyncInitResult:
+		state.handleAsyncInitResult(context, msg)
+
+	case *migrateFreeze:
+		state.handleMigrateFreeze(context)
+
+	case *migrateRollback:
+		state.unfreeze()
+
+	case *migrateCommit:
+		state.handleMigrateCommit(context, msg)
+
+	case *inspectActor:
+		context.Respond(state.snapshot())
+
 	default:
 		logger.GetLogger().Info("Child actor received invalid message", zap.String("ActorName", state.GetContext().GetActorName()), zap.Any("Message", msg))
 	}
 }
 
+// escalateCrash 捕获处理消息时发生的panic，附带调用栈后重新抛出，
+// 交由Supervisor按照Pattern的 SupervisionPolicy 处理
+// 如果崩溃发生在Request消息中，会先向调用者回复 ErrActorCrashed，避免调用者等待超时
+func (state *ChildActor) escalateCrash(context actor.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if msg, ok := context.Message().(*RequestMessage); ok && msg.MsgType == MessageTypeRequest {
+		context.Respond(ErrActorCrashed)
+	}
+
+	panic(&ActorCrash{
+		ActorName: state.actorName,
+		Pattern:   state.pattern,
+		Reason:    r,
+		Stack:     debug.Stack(),
+	})
+}
+
 // handleMessage 处理常规消息
 func (state *ChildActor) handleMessage(context actor.Context, msg *RequestMessage) {
 	state.updateActivityTime()
+	state.stash.current = false
+	defer state.observeHandled(msgTypeLabel(msg.MsgType), msg.Message, time.Now())
+	defer state.endSpan(state.startSpan(msg))
+	defer state.handling.watch.end(state.handling.watch.begin(msgTypeLabel(msg.MsgType), msg.Message))
+	defer state.endRequest(state.beginRequest(msg))
 
 	switch msg.MsgType {
 	case MessageTypeRequest:
-		result, err := state.HandleRequest(state, msg.Message)
+		if err := msg.canceled(); err != nil {
+			// 调用方已经放弃等待，不再处理
+			state.handling.span.SetError(err)
+			context.Respond(err)
+			return
+		}
+		result, err := state.dispatchRequest(msg.Message)
+		if state.stash.current {
+			// 消息已被暂存，重新处理后再回复调用者
+			state.handling.span.SetAttrs(tracing.KV("stashed", true))
+			return
+		}
+		state.handling.span.SetError(err)
 		if err != nil {
 			context.Respond(err)
 		} else {
 			context.Respond(result)
 		}
 	case MessageTypeSend:
-		state.HandleSend(state, msg.Message)
+		state.dispatchSend(msg.Message)
 	case MessageTypeForward:
-		state.HandleForward(state, msg.Message)
+		state.dispatchForward(msg.Message)
 	}
 }
 
 // HandleInit 在Actor启动时执行的初始化逻辑
 // 返回nil表示成功，否则返回错误
 func (state *ChildActor) HandleInit(context actor.Context) {
-	// 执行初始化逻辑
-	err := state.Behavior.HandleInit(state)
+	// 初始化定时器，HandleInit 中允许添加定时器和切换行为状态
+	state.TimerMgr = NewTimerMgr(func() {
+		context.Send(context.Self(), &TimerMessage{})
+	}, WithTimerClock(state.clock))
+
+	// 执行初始化逻辑，迁入的Actor先恢复迁移的状态
+	state.initState = initRunning
+	var err error
+	if state.migration.state != nil {
+		if m, ok := state.Behavior.(Migratable); ok {
+			err = m.MigrateIn(state, state.migration.state)
+		} else {
+			err = ErrNotMigratable
+		}
+		state.migration.state = nil
+	}
+	if err == nil {
+		err = state.Behavior.HandleInit(state)
+	}
+	if err != nil || state.initState != initAwaiting {
+		state.initState = initDone
+	}
 	if err != nil {
 		logger.GetLogger().Error("Child actor initialization failed", zap.String("ActorName", state.GetActorName()), zap.Error(err))
 	}
@@ -128,11 +260,6 @@ func (state *ChildActor) HandleInit(context actor.Context) {
 		logger.GetLogger().Info("Child actor started", zap.String("ActorName", state.GetActorName()))
 	}
 
-	// 初始化定时器
-	state.TimerMgr = NewTimerMgr(func() {
-		context.Send(context.Self(), &TimerMessage{})
-	})
-
 	// 更新最后活动时间
 	state.updateActivityTime()
 
@@ -163,7 +290,8 @@ func (state *ChildActor) HandleStopped(context actor.Context) {
 
 // 处理活跃检测
 func (state *ChildActor) handleAliveCheck(context actor.Context) {
-	if time.Since(state.lastActivityTime) > state.aliveTimeout {
+	if state.now().Sub(state.lastActivityTime) > state.aliveTimeout {
+		state.GetSystem().Metrics().ActorPassivated(state.pattern)
 		context.Send(context.Parent(), &PoisonActorMessage{
 			ActorName: state.GetActorName(),
 			Pattern:   state.GetPattern(),
@@ -171,7 +299,7 @@ func (state *ChildActor) handleAliveCheck(context actor.Context) {
 		logger.GetLogger().Info("Actor is not active, stopping",
 			zap.String("ActorName", state.GetActorName()),
 			zap.Duration("AliveTimeout", state.aliveTimeout),
-			zap.Duration("LastActivityTime", time.Since(state.lastActivityTime)))
+			zap.Duration("LastActivityTime", state.now().Sub(state.lastActivityTime)))
 	} else {
 		logger.GetLogger().Debug("Alive check",
 			zap.String("ActorName", state.GetActorName()))
@@ -188,7 +316,7 @@ func (state *ChildActor) schedule() {
 
 // IsActive 检查Actor是否活跃
 func (state *ChildActor) IsActive() bool {
-	return time.Since(state.lastActivityTime) < state.aliveTimeout
+	return state.now().Sub(state.lastActivityTime) < state.aliveTimeout
 }
 
 func (state *ChildActor) SetMetaData(meta *Meta) {
@@ -222,7 +350,52 @@ func (state *ChildActor) GetServerId() string {
 	return state.metaData.ServerId
 }
 
+// SetSystem 设置Actor所属的ActorSystem，需要在Actor启动前调用
+func (state *ChildActor) SetSystem(system *ActorSystem) {
+	state.system = system
+}
+
+// GetSystem 返回Actor所属的ActorSystem，用于创建绑定到同一系统的 ActorRef
+func (state *ChildActor) GetSystem() *ActorSystem {
+	if state.system != nil {
+		return state.system
+	}
+	return System
+}
+
 // 更新Actor最后活动时间
 func (state *ChildActor) updateActivityTime() {
-	state.lastActivityTime = time.Now()
+	state.lastActivityTime = state.now()
+}
+
+// now 返回Actor时钟的当前时间，保活检测与定时器使用同一个时钟
+func (state *ChildActor) now() time.Time {
+	if state.TimerMgr != nil {
+		return state.TimerMgr.Now()
+	}
+	if state.clock != nil {
+		return state.clock.Now()
+	}
+	return time.Now()
+}
+
+// timerFunc 回调定时器，到期时在Actor的goroutine中执行
+type timerFunc func(ctx IContext)
+
+// AfterFunc 添加一个一次性回调定时器，到期时在Actor内执行f，与 AddTimerOnce 共用key
+func (state *ChildActor) AfterFunc(key string, duration time.Duration, f func(ctx IContext)) *Timer {
+	if f == nil {
+		return nil
+	}
+	return state.AddTimerOnce(key, duration, timerFunc(f))
+}
+
+// PauseTimers 暂停Actor的所有定时器，保活检测继续运行
+func (state *ChildActor) PauseTimers() {
+	state.TimerMgr.Pause(aliveCheckTimerKey)
+}
+
+// ResumeTimers 恢复暂停的定时器
+func (state *ChildActor) ResumeTimers() {
+	state.TimerMgr.Resume()
 }
@@ -0,0 +1,128 @@
+package actor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock TimerMgr 使用的时钟，测试中可以替换为 MockClock，不需要真实等待即可验证定时逻辑
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer Clock.AfterFunc 返回的定时器，*time.Timer 实现了此接口
+type ClockTimer interface {
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// SystemClock 使用系统时间的时钟
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
+
+// MockClock 手动推进的时钟
+//
+//	1: Advance/Set 推进时间后，在调用者的goroutine中按到期时间顺序执行到期的定时器回调
+//	2: 回调中可以重新 Reset 定时器，新的到期时间不晚于推进后的时间时在本次推进中继续执行
+type MockClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*mockTimer
+}
+
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+func (c *MockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *MockClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &mockTimer{clock: c, f: f, at: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 将时间推进d
+func (c *MockClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set 将时间设置为now，不允许回拨
+func (c *MockClock) Set(now time.Time) {
+	for {
+		c.mu.Lock()
+		if now.After(c.now) {
+			c.now = now
+		}
+		var due *mockTimer
+		for _, t := range c.timers {
+			if t.active && !t.at.After(c.now) && (due == nil || t.at.Before(due.at)) {
+				due = t
+			}
+		}
+		if due != nil {
+			due.active = false
+		}
+		c.mu.Unlock()
+
+		if due == nil {
+			return
+		}
+		due.f()
+	}
+}
+
+// Pending 返回尚未到期的定时器的到期时间，按时间排序
+func (c *MockClock) Pending() []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var pending []time.Time
+	for _, t := range c.timers {
+		if t.active {
+			pending = append(pending, t.at)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Before(pending[j]) })
+	return pending
+}
+
+type mockTimer struct {
+	clock  *MockClock
+	f      func()
+	at     time.Time
+	active bool
+}
+
+func (t *mockTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.at = t.clock.now.Add(d)
+	t.active = true
+	return active
+}
+
+func (t *mockTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
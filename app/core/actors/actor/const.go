@@ -10,4 +10,8 @@ const (
 	aliveCheckTimerKey  = "system_alive_check_timer"
 	AliveCheckInterval  = 30 * time.Second
 	DefaultAliveTimeout = 30 * time.Minute
+
+	DefaultMailboxBlockTimeout = time.Second
+
+	MigrationRecordTTL = DefaultAliveTimeout
 )
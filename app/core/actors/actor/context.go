@@ -1,14 +1,22 @@
 package actor
 
 import (
+	"context"
 	"time"
 
+	"gitee.com/orbit-w/orbit/lib/tracing"
 	actor "github.com/asynkron/protoactor-go/actor"
 )
 
 type IContext interface {
 	IBaseContext
 	ITimerContext
+	IStashContext
+	IBehaviorStateContext
+	ITopicContext
+	IReminderContext
+	ITraceContext
+	IRequestContext
 }
 
 type IBaseContext interface {
@@ -19,10 +27,58 @@ type IBaseContext interface {
 	GetActorContext() actor.Context
 	SetActorContext(context actor.Context)
 	GetServerId() string
+	GetSystem() *ActorSystem
 }
 
 type ITimerContext interface {
 	AddSystemTimer(key string, duration time.Duration, msg any) *Timer
 	AddTimerOnce(key string, duration time.Duration, msg any) *Timer
+	AddTimerAt(key string, at time.Time, msg any) *Timer
+	AddScheduleTimer(key string, schedule TimerSchedule, msg any) *Timer
+	AddCronTimer(key, spec string, loc *time.Location, msg any) (*Timer, error)
+	AddDailyTimer(key string, hour, minute int, loc *time.Location, msg any) *Timer
 	RemoveTimer(key string)
+	AfterFunc(key string, duration time.Duration, f func(ctx IContext)) *Timer
+	Timers() []*Timer
+	Remaining(key string) (time.Duration, bool)
+	PauseTimers()
+	ResumeTimers()
+	Now() time.Time
+}
+
+// TimerHandler Behavior 实现此接口后，定时器消息交给 HandleTimer 处理，不再与业务消息一起进入 HandleSend
+type TimerHandler interface {
+	HandleTimer(ctx IContext, key string, msg any)
+}
+
+type IStashContext interface {
+	Stash()
+	UnstashAll()
+	StashSize() int
+	AwaitInit(load func() (any, error), complete func(result any, err error) error)
+}
+
+type IBehaviorStateContext interface {
+	Become(state *BehaviorState)
+	BecomeStacked(state *BehaviorState)
+	Unbecome()
+	CurrentState() *BehaviorState
+}
+
+type IReminderContext interface {
+	RegisterReminder(name string, schedule ReminderSchedule, payload []byte, ops ...RegisterReminderOption) error
+	UnregisterReminder(name string) error
+}
+
+type ITopicContext interface {
+	Subscribe(topic string, filter ...TopicFilter)
+	Unsubscribe(topic string)
+}
+
+type ITraceContext interface {
+	SpanContext() tracing.SpanContext
+}
+
+type IRequestContext interface {
+	Context() context.Context
 }
@@ -0,0 +1,208 @@
+package actor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimerSchedule 周期定时器的触发计划
+type TimerSchedule interface {
+	// Next 返回晚于after的下一次触发时间，没有下一次时返回零值
+	Next(after time.Time) time.Time
+}
+
+// CronSchedule 标准的5段cron表达式: 分 时 日 月 周
+//
+//	1: 每段支持 *、数字、范围 a-b、列表 a,b 和步长 */n、a-b/n
+//	2: 周的取值为0-6，0表示周日，也可以使用7表示周日
+//	3: 日和周都不是 * 时，满足其中之一即触发，与标准cron一致
+//	4: 支持 @hourly、@daily、@weekly、@monthly
+//	5: 在指定的时区计算，夏令时切换时跳过不存在的时间
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	loc                           *time.Location
+	spec                          string
+}
+
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+type cronBounds struct {
+	min, max int
+}
+
+var (
+	cronMinute = cronBounds{0, 59}
+	cronHour   = cronBounds{0, 23}
+	cronDom    = cronBounds{1, 31}
+	cronMonth  = cronBounds{1, 12}
+	cronDow    = cronBounds{0, 7}
+)
+
+// cronStar 字段为 * 时额外设置的标记位，用于日和周的匹配规则
+const cronStar = uint64(1) << 63
+
+// ParseCron 解析cron表达式，loc为nil时使用本地时区
+func ParseCron(spec string, loc *time.Location) (*CronSchedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	expr := strings.TrimSpace(spec)
+	if d, ok := cronDescriptors[expr]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d in %q", ErrInvalidCron, len(fields), spec)
+	}
+
+	s := &CronSchedule{loc: loc, spec: spec}
+	var err error
+	for i, f := range []struct {
+		bits   *uint64
+		bounds cronBounds
+	}{
+		{&s.minute, cronMinute},
+		{&s.hour, cronHour},
+		{&s.dom, cronDom},
+		{&s.month, cronMonth},
+		{&s.dow, cronDow},
+	} {
+		if *f.bits, err = parseCronField(fields[i], f.bounds); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidCron, spec, err)
+		}
+	}
+	// 7 与 0 都表示周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, bounds cronBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step, part = n, part[:i]
+		}
+
+		lo, hi := bounds.min, bounds.max
+		switch {
+		case part == "*":
+			if step == 1 {
+				bits |= cronStar
+			}
+		case strings.Contains(part, "-"):
+			r := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(r[0])
+			hi, err2 = strconv.Atoi(r[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = bounds.max
+			}
+		}
+		if lo < bounds.min || hi > bounds.max || lo > hi {
+			return 0, fmt.Errorf("value out of range %q", part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// String 返回原始的cron表达式
+func (s *CronSchedule) String() string {
+	return s.spec
+}
+
+// Next 返回晚于after的下一次触发时间，五年内没有匹配的时间时返回零值
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.dom&cronStar != 0 || s.dow&cronStar != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// DailySchedule 每天在指定时区的 hour:minute 触发，用于每日重置
+func DailySchedule(hour, minute int, loc *time.Location) TimerSchedule {
+	s, _ := ParseCron(fmt.Sprintf("%d %d * * *", minute, hour), loc)
+	return s
+}
+
+// WeeklySchedule 每周在指定时区的 weekday hour:minute 触发，用于每周重置
+func WeeklySchedule(weekday time.Weekday, hour, minute int, loc *time.Location) TimerSchedule {
+	s, _ := ParseCron(fmt.Sprintf("%d %d * * %d", minute, hour, weekday), loc)
+	return s
+}
+
+// LastDailyReset 返回不晚于now的最近一次每日重置时间，重置时间为指定时区的 hour:minute，loc为nil时使用本地时区
+func LastDailyReset(now time.Time, hour, minute int, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if reset.After(now) {
+		reset = time.Date(local.Year(), local.Month(), local.Day()-1, hour, minute, 0, 0, loc)
+	}
+	return reset
+}
+
+// NextDailyReset 返回晚于now的下一次每日重置时间
+func NextDailyReset(now time.Time, hour, minute int, loc *time.Location) time.Time {
+	last := LastDailyReset(now, hour, minute, loc)
+	return time.Date(last.Year(), last.Month(), last.Day()+1, hour, minute, 0, 0, last.Location())
+}
+
+// SameResetDay 判断a和b是否位于同一个重置周期内，例如玩家上次领取奖励的时间与当前时间比较
+func SameResetDay(a, b time.Time, hour, minute int, loc *time.Location) bool {
+	return LastDailyReset(a, hour, minute, loc).Equal(LastDailyReset(b, hour, minute, loc))
+}
@@ -0,0 +1,64 @@
+package actor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCron_Next(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	now := time.Date(2024, 5, 1, 10, 30, 15, 0, loc) // 星期三
+
+	cases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 31, 0, 0, loc)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, loc)},
+		{"0 5 * * *", time.Date(2024, 5, 2, 5, 0, 0, 0, loc)},
+		{"@daily", time.Date(2024, 5, 2, 0, 0, 0, 0, loc)},
+		{"0 5 * * 1", time.Date(2024, 5, 6, 5, 0, 0, 0, loc)},
+		{"0 0 * * 7", time.Date(2024, 5, 5, 0, 0, 0, 0, loc)},
+		{"30 9-11 * * 1-5", time.Date(2024, 5, 1, 11, 30, 0, 0, loc)},
+		{"0 0 1 * *", time.Date(2024, 6, 1, 0, 0, 0, 0, loc)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, loc)},
+		// 日和周都指定时满足其一即可
+		{"0 0 15 * 5", time.Date(2024, 5, 3, 0, 0, 0, 0, loc)},
+	}
+	for _, c := range cases {
+		s, err := ParseCron(c.spec, loc)
+		assert.NoError(t, err, c.spec)
+		assert.Equal(t, c.next, s.Next(now), c.spec)
+	}
+
+	// 按表达式的时区计算
+	s, err := ParseCron("0 0 * * *", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), s.Next(now))
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err = ParseCron(spec, loc)
+		assert.True(t, errors.Is(err, ErrInvalidCron), spec)
+	}
+}
+
+func TestCron_DailyReset(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+
+	before := time.Date(2024, 5, 1, 4, 59, 0, 0, loc)
+	after := time.Date(2024, 5, 1, 5, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 4, 30, 5, 0, 0, 0, loc), LastDailyReset(before, 5, 0, loc))
+	assert.Equal(t, after, LastDailyReset(after, 5, 0, loc))
+	assert.Equal(t, after, NextDailyReset(before, 5, 0, loc))
+	assert.Equal(t, time.Date(2024, 5, 2, 5, 0, 0, 0, loc), NextDailyReset(after, 5, 0, loc))
+
+	assert.False(t, SameResetDay(before, after, 5, 0, loc))
+	assert.True(t, SameResetDay(after, time.Date(2024, 5, 2, 4, 59, 0, 0, loc), 5, 0, loc))
+	// 同一时刻在不同时区的重置周期不同
+	assert.True(t, SameResetDay(before, after, 5, 0, time.UTC))
+}
@@ -0,0 +1,174 @@
+package actor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitee.com/orbit-w/meteor/bases/misc/utils"
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/asynkron/protoactor-go/actor"
+	"go.uber.org/zap"
+)
+
+const (
+	// 默认死信日志限流：每个周期最多输出的日志条数
+	deadLetterLogThrottleCount    = 10
+	deadLetterLogThrottleInterval = time.Second
+)
+
+// DeadLetter 无法投递到目标Actor的消息
+type DeadLetter struct {
+	ActorName string
+	Pattern   string
+	Message   *RequestMessage
+	Sender    *actor.PID // 发送者，Send消息通常为空
+	Reason    error
+	Time      time.Time
+}
+
+// DeadLetterHandler 死信订阅回调
+// 回调在投递失败的发送方goroutine中同步执行，不允许阻塞
+type DeadLetterHandler func(dl *DeadLetter)
+
+// DeadLetterSink 死信汇聚点
+//
+//  1. 汇聚两类死信：
+//     a. ActorRef.Send 重试后仍因Actor停止而失败的消息
+//     b. 在缓存查找与投递之间目标Actor已经终止，由protoactor转入deadletter的消息
+//  2. 默认对死信输出限流日志
+//  3. 消息携带重投次数(WithRedelivery)时，重新激活目标Actor后再次投递
+type DeadLetterSink struct {
+	mu          sync.RWMutex
+	id          atomic.Int64
+	subscribers map[int64]DeadLetterHandler
+	throttle    actor.ShouldThrottle
+	system      *ActorSystem // 重投时激活Actor的系统，为空时不重投
+}
+
+func NewDeadLetterSink() *DeadLetterSink {
+	return &DeadLetterSink{
+		subscribers: make(map[int64]DeadLetterHandler),
+		throttle: actor.NewThrottle(deadLetterLogThrottleCount, deadLetterLogThrottleInterval, func(throttled int32) {
+			logger.GetLogger().Warn("[DeadLetter] throttled", zap.Int32("Count", throttled))
+		}),
+	}
+}
+
+// Subscribe 订阅死信，返回订阅ID，用于 Unsubscribe
+func (s *DeadLetterSink) Subscribe(handler DeadLetterHandler) int64 {
+	id := s.id.Add(1)
+	s.mu.Lock()
+	s.subscribers[id] = handler
+	s.mu.Unlock()
+	return id
+}
+
+func (s *DeadLetterSink) Unsubscribe(id int64) {
+	s.mu.Lock()
+	delete(s.subscribers, id)
+	s.mu.Unlock()
+}
+
+// Publish 发布一条死信：输出限流日志，通知所有订阅者，按需重投
+func (s *DeadLetterSink) Publish(dl *DeadLetter) {
+	if dl == nil || dl.Message == nil {
+		return
+	}
+	if dl.Time.IsZero() {
+		dl.Time = time.Now()
+	}
+
+	if s.throttle() == actor.Open {
+		logger.GetLogger().Warn("[DeadLetter]",
+			zap.String("ActorName", dl.ActorName),
+			zap.String("Pattern", dl.Pattern),
+			zap.Int8("MsgType", dl.Message.MsgType),
+			zap.Any("Message", dl.Message.Message),
+			zap.Any("Sender", dl.Sender),
+			zap.Error(dl.Reason))
+	}
+
+	s.mu.RLock()
+	handlers := make([]DeadLetterHandler, 0, len(s.subscribers))
+	for _, handler := range s.subscribers {
+		handlers = append(handlers, handler)
+	}
+	s.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(dl)
+	}
+
+	s.redeliver(dl)
+}
+
+// redeliver 重新激活目标Actor后再次投递Send消息，每次重投消耗一次重投次数
+func (s *DeadLetterSink) redeliver(dl *DeadLetter) {
+	if dl.Message.MsgType != MessageTypeSend || dl.Message.Redelivery <= 0 || dl.Pattern == "" {
+		return
+	}
+	system := s.system
+	if system == nil || !system.isRunning() {
+		return
+	}
+
+	// 订阅者持有同一个死信，重投使用消息副本
+	msg := dl.Message.clone()
+	msg.Redelivery--
+	utils.GoRecoverPanic(func() {
+		p, err := system.GetOrStartActor(dl.ActorName, dl.Pattern, msg.props)
+		if err == nil {
+			err = p.deliver(msg)
+		}
+		if err != nil {
+			s.Publish(&DeadLetter{
+				ActorName: dl.ActorName,
+				Pattern:   dl.Pattern,
+				Message:   msg,
+				Reason:    err,
+			})
+		}
+	})
+}
+
+// onProtoDeadLetter 将protoactor的DeadLetterEvent转换为DeadLetter
+func (s *DeadLetterSink) onProtoDeadLetter(evt any) {
+	ev, ok := evt.(*actor.DeadLetterEvent)
+	if !ok {
+		return
+	}
+	msg, ok := ev.Message.(*RequestMessage)
+	if !ok {
+		return
+	}
+
+	actorName := msg.ActorName
+	if actorName == "" {
+		actorName = ExtractActorName(ev.PID)
+	}
+	s.Publish(&DeadLetter{
+		ActorName: actorName,
+		Pattern:   msg.Pattern,
+		Message:   msg,
+		Sender:    ev.Sender,
+		Reason:    ErrDeadLetter,
+	})
+}
+
+// SendOption 发送消息时的可选项
+type SendOption func(msg *RequestMessage)
+
+// WithPriority 指定消息优先级，见 PriorityHigh
+func WithPriority(priority int8) SendOption {
+	return func(msg *RequestMessage) {
+		msg.Priority = priority
+	}
+}
+
+// WithRedelivery 消息成为死信后，重新激活目标Actor并最多重投 attempts 次
+func WithRedelivery(attempts int) SendOption {
+	return func(msg *RequestMessage) {
+		msg.Redelivery = attempts
+	}
+}
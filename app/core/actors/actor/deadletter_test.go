@@ -0,0 +1,118 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// ReceiveBehavior 将收到的Send消息转发到通道
+type ReceiveBehavior struct {
+	received chan any
+}
+
+func (b *ReceiveBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	return msg, nil
+}
+
+func (b *ReceiveBehavior) HandleSend(ctx IContext, msg any) {
+	b.received <- msg
+}
+
+func (b *ReceiveBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *ReceiveBehavior) HandleInit(ctx IContext) error {
+	return nil
+}
+
+func (b *ReceiveBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *ReceiveBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+// deadProcess 构造一个指向已经不存在的PID的Process，模拟缓存查找后Actor终止的场景
+func deadProcess(actorName, pattern string) *Process {
+	pid := actor.NewPID(System.ActorSystem().Address(), "dead-"+actorName)
+	return NewActorProcess(actorName, pattern, pid, NewProps())
+}
+
+func TestDeadLetterSink_Subscribe(t *testing.T) {
+	const pattern = "dead-letter-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	letters := make(chan *DeadLetter, 4)
+	id := System.DeadLetters().Subscribe(func(dl *DeadLetter) {
+		letters <- dl
+	})
+
+	p := deadProcess("dead-letter-actor", pattern)
+	assert.NoError(t, p.Send("lost-message"))
+
+	select {
+	case dl := <-letters:
+		assert.Equal(t, "dead-letter-actor", dl.ActorName)
+		assert.Equal(t, pattern, dl.Pattern)
+		assert.Equal(t, "lost-message", dl.Message.Message)
+		assert.True(t, errors.Is(dl.Reason, ErrDeadLetter))
+	case <-time.After(time.Second):
+		t.Fatal("dead letter not published")
+	}
+
+	_, err := p.RequestFuture("lost-request", time.Second)
+	assert.True(t, errors.Is(err, ErrDeadLetter))
+	select {
+	case dl := <-letters:
+		assert.Equal(t, MessageTypeRequest, dl.Message.MsgType)
+		assert.NotNil(t, dl.Sender)
+	case <-time.After(time.Second):
+		t.Fatal("dead letter not published")
+	}
+
+	System.DeadLetters().Unsubscribe(id)
+	assert.NoError(t, p.Send("unobserved-message"))
+	select {
+	case <-letters:
+		t.Fatal("unsubscribed handler should not be called")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestDeadLetterSink_Redelivery(t *testing.T) {
+	const pattern = "dead-letter-redelivery-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	received := make(chan any, 1)
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &ReceiveBehavior{received: received}
+	})
+
+	letters := make(chan *DeadLetter, 1)
+	System.DeadLetters().Subscribe(func(dl *DeadLetter) {
+		letters <- dl
+	})
+
+	p := deadProcess("dead-letter-redelivery-actor", pattern)
+	assert.NoError(t, p.Send("redelivered-message", WithRedelivery(1)))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "redelivered-message", msg)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+	assert.True(t, System.actors.Exist("dead-letter-redelivery-actor"), "actor should be reactivated")
+
+	// 重投不修改订阅者收到的死信
+	dl := <-letters
+	assert.Equal(t, 1, dl.Message.Redelivery)
+}
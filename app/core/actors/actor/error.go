@@ -6,4 +6,38 @@ var (
 	ErrActorNotFound      = errors.New("actor not found")
 	ErrActorStopped       = errors.New("actor is stopped")
 	ErrSupervisionStopped = errors.New("supervision stopped")
+	ErrActorCrashed       = errors.New("actor crashed while handling message")
+	ErrDeadLetter         = errors.New("message delivered to dead letter")
+	ErrMailboxFull        = errors.New("actor mailbox is full")
+
+	ErrNodeNotFound               = errors.New("remote node not found")
+	ErrRemoteUnavailable          = errors.New("remote node unavailable")
+	ErrRemoteMessageNotRegistered = errors.New("remote message not registered")
+	ErrRemoteUnauthorized         = errors.New("remote node unauthorized")
+
+	ErrLeaseLost        = errors.New("actor lease lost")
+	ErrLeaseNotHeld     = errors.New("actor lease not held by current node")
+	ErrRemoteNotStarted = errors.New("remote not started")
+	ErrNoAvailableNode  = errors.New("no available node for placement")
+	ErrMetaConflict     = errors.New("actor meta modified by another node")
+
+	ErrNotMigratable      = errors.New("actor behavior is not migratable")
+	ErrActorAlreadyActive = errors.New("actor is already active on target node")
+
+	ErrRouterNoHashKey        = errors.New("router message has no hash key")
+	ErrRouterBroadcastRequest = errors.New("broadcast router does not support request")
+
+	ErrRemindersNotStarted = errors.New("reminders not started")
+	ErrReminderName        = errors.New("invalid reminder name")
+	ErrReminderSchedule    = errors.New("reminder schedule has no next fire time")
+
+	ErrInvalidCron = errors.New("invalid cron expression")
+
+	ErrShutdownIncomplete = errors.New("actor level did not stop before deadline")
+
+	ErrActorUnresponsive = errors.New("actor did not respond to inspection in time")
+
+	ErrActorOverloaded = errors.New("actor circuit is open after a slow handler")
+
+	ErrSupervisionRestarting = errors.New("supervision restarting")
 )
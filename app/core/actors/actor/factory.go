@@ -1,16 +1,68 @@
 package actor
 
+import "sync"
+
 // Factory function type that accepts an actor ID
 type BehaivorFactory func(actorName string) Behavior
 
-var factories = make(map[string]BehaivorFactory)
+// FactoryRegistry 按Pattern注册的Behavior工厂，每个 ActorSystem 可以使用独立的注册表(WithFactories)
+type FactoryRegistry struct {
+	mu        sync.RWMutex
+	factories map[string]BehaivorFactory
+}
 
-// RegFactory registers a factory for a specific actor type
-func RegFactory(pattern string, factory BehaivorFactory) {
-	if _, ok := factories[pattern]; ok {
+func NewFactoryRegistry() *FactoryRegistry {
+	return &FactoryRegistry{
+		factories: make(map[string]BehaivorFactory),
+	}
+}
+
+// defaultFactories 未指定注册表的 ActorSystem 以及包级别的 RegFactory 使用的注册表
+var defaultFactories = NewFactoryRegistry()
+
+// DefaultFactories 返回默认的工厂注册表
+func DefaultFactories() *FactoryRegistry {
+	return defaultFactories
+}
+
+// Reg registers a factory for a specific actor type
+func (r *FactoryRegistry) Reg(pattern string, factory BehaivorFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[pattern]; ok {
 		panic("factory already registered: " + pattern)
 	}
-	factories[pattern] = factory
+	r.factories[pattern] = factory
+}
+
+// Exists 是否注册了Pattern的工厂
+func (r *FactoryRegistry) Exists(pattern string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.factories[pattern]
+	return ok
+}
+
+// Dispatch returns a factory function for creating actors with the given ID
+func (r *FactoryRegistry) Dispatch(pattern string) BehaivorFactory {
+	r.mu.RLock()
+	factory, ok := r.factories[pattern]
+	r.mu.RUnlock()
+	if !ok {
+		panic("factory not found: " + pattern)
+	}
+
+	return factory
+}
+
+// Create creates an actor with a specific ID
+func (r *FactoryRegistry) Create(pattern string, actorName string) Behavior {
+	return r.Dispatch(pattern)(actorName)
+}
+
+// RegFactory registers a factory for a specific actor type
+func RegFactory(pattern string, factory BehaivorFactory) {
+	defaultFactories.Reg(pattern, factory)
 }
 
 // RegFactories registers multiple factories at once
@@ -25,16 +77,10 @@ func RegFactories(factories ...struct {
 
 // Dispatch returns a factory function for creating actors with the given ID
 func Dispatch(pattern string) BehaivorFactory {
-	factory, ok := factories[pattern]
-	if !ok {
-		panic("factory not found: " + pattern)
-	}
-
-	return factory
+	return defaultFactories.Dispatch(pattern)
 }
 
 // CreateBehaviorWithID creates an actor with a specific ID
 func CreateBehaviorWithID(pattern string, actorName string) Behavior {
-	factory := Dispatch(pattern)
-	return factory(actorName)
+	return defaultFactories.Create(pattern, actorName)
 }
@@ -0,0 +1,280 @@
+package actor
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+/*
+	运行时检视
+
+	1. ListActors 列出已激活的Actor，按Pattern和等级过滤，只读取缓存和邮箱的统计，不打扰Actor
+	2. InspectActor 向Actor发送内部消息，由Actor在自己的协程中生成快照: 最后活跃时间、定时器、暂存的消息、行为状态
+	   内部消息优先于业务消息处理，超时说明Actor正卡在某个消息中，返回 ErrActorUnresponsive
+	3. Supervisors 返回每个等级的supervisor中正在启动、停止、重启的Actor以及等待中的Future数量
+	4. AdminHandler 通过HTTP以JSON暴露以上信息
+*/
+
+const (
+	DefaultInspectTimeout = time.Second
+	inspectConcurrency    = 32 // ListActors 同时检视的Actor数量上限
+)
+
+// ActorFilter ListActors 的过滤条件，零值表示全部
+type ActorFilter struct {
+	Pattern string        // 为空时不限制Pattern
+	Levels  []Level       // 为空时不限制等级
+	Detail  bool          // 是否逐个检视Actor，填充 ActorStatus.Detail
+	Timeout time.Duration // 单个Actor的检视超时，默认为 DefaultInspectTimeout
+}
+
+func (f ActorFilter) match(pattern string, level Level) bool {
+	if f.Pattern != "" && f.Pattern != pattern {
+		return false
+	}
+	if len(f.Levels) == 0 {
+		return true
+	}
+	for _, lv := range f.Levels {
+		if lv == level {
+			return true
+		}
+	}
+	return false
+}
+
+// ActorStatus 已激活Actor的概要，由 ListActors/InspectActor 返回
+type ActorStatus struct {
+	ActorName string        `json:"actorName"`
+	Pattern   string        `json:"pattern"`
+	Level     Level         `json:"level"`
+	State     string        `json:"state"` // running / stopped
+	Mailbox   *MailboxStats `json:"mailbox,omitempty"`
+	Detail    *ActorDetail  `json:"detail,omitempty"`
+	Error     string        `json:"error,omitempty"` // 检视失败的原因
+}
+
+// ActorDetail Actor在自己的协程中生成的快照
+type ActorDetail struct {
+	LastActivityTime time.Time   `json:"lastActivityTime"`
+	Initialized      bool        `json:"initialized"`
+	Frozen           bool        `json:"frozen"`        // 迁移中
+	BehaviorState    string      `json:"behaviorState"` // 当前行为状态，没有时为空
+	StashSize        int         `json:"stashSize"`
+	TimersPaused     bool        `json:"timersPaused"`
+	Timers           []TimerInfo `json:"timers"`
+}
+
+// TimerInfo 定时器的概要
+type TimerInfo struct {
+	Key        string        `json:"key"`
+	Expiration time.Time     `json:"expiration"`
+	Interval   time.Duration `json:"interval"`
+	Scheduled  bool          `json:"scheduled"` // 按计划续约，例如cron定时器
+	System     bool          `json:"system"`
+}
+
+// PendingActor supervisor中尚未完成启动、停止或重启的Actor
+type PendingActor struct {
+	ActorName string `json:"actorName"`
+	Pattern   string `json:"pattern"`
+	Futures   int    `json:"futures"` // 等待结果的调用者数量
+}
+
+// SupervisorInfo 一个等级的supervisor的快照
+type SupervisorInfo struct {
+	Level      Level          `json:"level"`
+	State      string         `json:"state"` // normal / stopping / stopped
+	Children   int            `json:"children"`
+	Starting   []PendingActor `json:"starting"`
+	Stopping   []PendingActor `json:"stopping"`
+	Restarting []PendingActor `json:"restarting"`
+}
+
+type (
+	inspectActor      struct{}
+	inspectSupervisor struct{}
+)
+
+func (*inspectActor) internalMessage() {}
+
+// ListActors 按过滤条件列出已激活的Actor，按ActorName排序
+func (af *ActorSystem) ListActors(filter ActorFilter) []ActorStatus {
+	var infos []ActorStatus
+	af.actors.Range(func(actorName string, p *Process) {
+		level := af.levels.Get(p.Pattern)
+		if !filter.match(p.Pattern, level) {
+			return
+		}
+		infos = append(infos, processInfo(p, level))
+	})
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ActorName < infos[j].ActorName
+	})
+
+	if filter.Detail {
+		af.inspectAll(infos, filter.Timeout)
+	}
+	return infos
+}
+
+// InspectActor 返回已激活Actor的概要和快照，Actor未激活时返回 ErrActorNotFound，
+// 超时未回复时返回 ErrActorUnresponsive
+func (af *ActorSystem) InspectActor(actorName string, timeout ...time.Duration) (ActorStatus, error) {
+	p, exists := af.actors.Get(actorName)
+	if !exists {
+		return ActorStatus{}, ErrActorNotFound
+	}
+	info := processInfo(p, af.levels.Get(p.Pattern))
+	detail, err := af.inspect(p, parseInspectTimeout(timeout...))
+	if err != nil {
+		return info, err
+	}
+	info.Detail = detail
+	return info, nil
+}
+
+// Supervisors 返回每个等级的supervisor的快照，按等级排序
+func (af *ActorSystem) Supervisors(timeout ...time.Duration) ([]SupervisorInfo, error) {
+	d := parseInspectTimeout(timeout...)
+	infos := make([]SupervisorInfo, 0, len(af.supervisors))
+	for _, pid := range af.supervisors {
+		result, err := waitFuture(af.actorSystem.Root.RequestFuture(pid, &inspectSupervisor{}, d))
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, *result.(*SupervisorInfo))
+	}
+	return infos, nil
+}
+
+func (af *ActorSystem) inspectAll(infos []ActorStatus, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultInspectTimeout
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, inspectConcurrency)
+	for i := range infos {
+		p, exists := af.actors.Get(infos[i].ActorName)
+		if !exists {
+			infos[i].Error = ErrActorNotFound.Error()
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(info *ActorStatus, p *Process) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			detail, err := af.inspect(p, timeout)
+			if err != nil {
+				info.Error = err.Error()
+				return
+			}
+			info.Detail = detail
+		}(&infos[i], p)
+	}
+	wg.Wait()
+}
+
+func (af *ActorSystem) inspect(p *Process, timeout time.Duration) (*ActorDetail, error) {
+	if p.IsStopped() {
+		return nil, ErrActorStopped
+	}
+	result, err := waitFuture(af.actorSystem.Root.RequestFuture(p.PID, &inspectActor{}, timeout))
+	if err != nil {
+		if errors.Is(err, actor.ErrTimeout) {
+			return nil, ErrActorUnresponsive
+		}
+		return nil, err
+	}
+	return result.(*ActorDetail), nil
+}
+
+func processInfo(p *Process, level Level) ActorStatus {
+	info := ActorStatus{
+		ActorName: p.ActorName,
+		Pattern:   p.Pattern,
+		Level:     level,
+		State:     "running",
+	}
+	if p.IsStopped() {
+		info.State = "stopped"
+	}
+	if stats, ok := p.MailboxStats(); ok {
+		info.Mailbox = &stats
+	}
+	return info
+}
+
+func parseInspectTimeout(timeout ...time.Duration) time.Duration {
+	if len(timeout) > 0 && timeout[0] > 0 {
+		return timeout[0]
+	}
+	return DefaultInspectTimeout
+}
+
+// snapshot 在Actor的协程中生成快照
+func (state *ChildActor) snapshot() *ActorDetail {
+	detail := &ActorDetail{
+		LastActivityTime: state.lastActivityTime,
+		Initialized:      state.initState == initDone,
+		Frozen:           state.migration.frozen,
+		StashSize:        state.StashSize(),
+		Timers:           []TimerInfo{},
+	}
+	if s := state.CurrentState(); s != nil {
+		detail.BehaviorState = s.GetName()
+	}
+	if state.TimerMgr != nil {
+		detail.TimersPaused = state.IsPaused()
+		for _, timer := range state.Timers() {
+			detail.Timers = append(detail.Timers, TimerInfo{
+				Key:        timer.GetKey(),
+				Expiration: timer.GetExpiration(),
+				Interval:   timer.GetDuration(),
+				Scheduled:  timer.GetSchedule() != nil,
+				System:     timer.IsSystem(),
+			})
+		}
+	}
+	return detail
+}
+
+// snapshot 在supervisor的协程中生成快照
+func (m *ActorSupervision) snapshot(context actor.Context) *SupervisorInfo {
+	info := &SupervisorInfo{
+		Level:      m.level,
+		Children:   len(context.Children()),
+		Starting:   pendingActors(m.starting),
+		Stopping:   pendingActors(m.stopping),
+		Restarting: pendingActors(m.restarting),
+	}
+	switch m.state.Load() {
+	case StateActorSupervisionNormal:
+		info.State = "normal"
+	case StateActorSupervisionStopping:
+		info.State = "stopping"
+	default:
+		info.State = "stopped"
+	}
+	return info
+}
+
+func pendingActors(q *Queue) []PendingActor {
+	items := q.Items()
+	pending := make([]PendingActor, 0, len(items))
+	for _, item := range items {
+		pending = append(pending, PendingActor{
+			ActorName: item.ActorName,
+			Pattern:   item.Pattern,
+			Futures:   item.FuturesNum(),
+		})
+	}
+	return pending
+}
@@ -0,0 +1,153 @@
+package actor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// inspectBehavior 初始化时添加定时器并进入行为状态，收到 "block" 时阻塞直到release关闭
+type inspectBehavior struct {
+	MockBehavior
+	release chan struct{}
+}
+
+func (b *inspectBehavior) HandleInit(ctx IContext) error {
+	ctx.AddTimerOnce("inspect-tick", time.Minute, "tick")
+	ctx.Become(&BehaviorState{Name: "idle"})
+	return nil
+}
+
+func (b *inspectBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if msg == "block" {
+		<-b.release
+	}
+	return nil, nil
+}
+
+func newInspectSystem(t *testing.T) (*ActorSystem, chan struct{}) {
+	release := make(chan struct{})
+	factories := NewFactoryRegistry()
+	for _, pattern := range []string{"inspect-normal", "inspect-high"} {
+		factories.Reg(pattern, func(actorName string) Behavior {
+			return &inspectBehavior{release: release}
+		})
+	}
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories),
+		WithLevels(NewLevelRegistry(PatternLevel{Pattern: "inspect-high", Level: LevelHigh})))
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+		_ = af.Stop(context.Background())
+	})
+	return af, release
+}
+
+func TestIntrospection_ListAndInspect(t *testing.T) {
+	af, release := newInspectSystem(t)
+	for _, name := range []string{"inspect-b", "inspect-a"} {
+		_, err := af.NewActorRef(NewProps(), name, "inspect-normal").RequestFuture("ping")
+		assert.NoError(t, err)
+	}
+	_, err := af.NewActorRef(NewProps(), "inspect-c", "inspect-high").RequestFuture("ping")
+	assert.NoError(t, err)
+
+	actors := af.ListActors(ActorFilter{})
+	assert.Len(t, actors, 3)
+	assert.Equal(t, "inspect-a", actors[0].ActorName)
+	assert.Equal(t, "running", actors[0].State)
+	assert.NotNil(t, actors[0].Mailbox)
+	assert.Nil(t, actors[0].Detail)
+
+	high := af.ListActors(ActorFilter{Levels: []Level{LevelHigh}, Detail: true})
+	assert.Len(t, high, 1)
+	assert.Equal(t, "inspect-c", high[0].ActorName)
+	assert.Equal(t, LevelHigh, high[0].Level)
+	if assert.NotNil(t, high[0].Detail) {
+		assert.True(t, high[0].Detail.Initialized)
+		assert.Equal(t, "idle", high[0].Detail.BehaviorState)
+		assert.False(t, high[0].Detail.LastActivityTime.IsZero())
+		var keys []string
+		for _, timer := range high[0].Detail.Timers {
+			if !timer.System {
+				keys = append(keys, timer.Key)
+			}
+		}
+		assert.Equal(t, []string{"inspect-tick"}, keys)
+	}
+	assert.Len(t, af.ListActors(ActorFilter{Pattern: "inspect-normal"}), 2)
+
+	_, err = af.InspectActor("inspect-missing")
+	assert.True(t, errors.Is(err, ErrActorNotFound))
+
+	// 卡在业务消息中的Actor无法回复检视
+	go func() {
+		_, _ = af.NewActorRef(NewProps(), "inspect-a", "inspect-normal").RequestFuture("block", 5*time.Second)
+	}()
+	assert.Eventually(t, func() bool {
+		_, err := af.InspectActor("inspect-a", 50*time.Millisecond)
+		return errors.Is(err, ErrActorUnresponsive)
+	}, 2*time.Second, 10*time.Millisecond)
+	close(release)
+	info, err := af.InspectActor("inspect-a")
+	assert.NoError(t, err)
+	assert.NotNil(t, info.Detail)
+
+	supervisors, err := af.Supervisors()
+	assert.NoError(t, err)
+	assert.Len(t, supervisors, af.LevelCount())
+	assert.Equal(t, "normal", supervisors[0].State)
+	assert.Equal(t, 2, supervisors[0].Children)
+	assert.Equal(t, 1, supervisors[1].Children)
+}
+
+func TestIntrospection_AdminHandler(t *testing.T) {
+	af, _ := newInspectSystem(t)
+	_, err := af.NewActorRef(NewProps(), "inspect-admin", "inspect-high").RequestFuture("ping")
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.StripPrefix("/debug/actor", af.AdminHandler()))
+	defer server.Close()
+
+	get := func(path string, v any) int {
+		resp, err := http.Get(server.URL + "/debug/actor" + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+		return resp.StatusCode
+	}
+
+	var actors []ActorStatus
+	assert.Equal(t, http.StatusOK, get("/actors?level=1&detail=true", &actors))
+	if assert.Len(t, actors, 1) {
+		assert.Equal(t, "inspect-admin", actors[0].ActorName)
+		assert.NotNil(t, actors[0].Detail)
+	}
+
+	var info ActorStatus
+	assert.Equal(t, http.StatusOK, get("/actors/inspect-admin", &info))
+	assert.Equal(t, "inspect-high", info.Pattern)
+
+	var e map[string]string
+	assert.Equal(t, http.StatusNotFound, get("/actors/inspect-missing", &e))
+	assert.Equal(t, ErrActorNotFound.Error(), e["error"])
+	assert.Equal(t, http.StatusBadRequest, get("/actors?level=x", &e))
+
+	var supervisors []SupervisorInfo
+	assert.Equal(t, http.StatusOK, get("/supervisors", &supervisors))
+	assert.Len(t, supervisors, af.LevelCount())
+
+	var shutdown shutdownView
+	assert.Equal(t, http.StatusOK, get("/shutdown", &shutdown))
+	assert.False(t, shutdown.Started)
+}
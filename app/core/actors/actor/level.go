@@ -2,7 +2,6 @@ package actor
 
 import (
 	"sync/atomic"
-	"unsafe"
 )
 
 const (
@@ -16,24 +15,68 @@ const (
 
 type Level int
 
-var (
-	// Map to store pattern to level mappings
-	patternLevelMap *map[string]Level
-)
-
-func InitPatternLevelMap(list []struct {
+// PatternLevel Pattern对应的停止等级
+type PatternLevel = struct {
 	Pattern string
 	Level   Level
-}) {
-	m := make(map[string]Level)
+}
+
+// LevelRegistry Pattern到停止等级的映射，每个 ActorSystem 可以使用独立的映射(WithLevels)
+// 未登记的Pattern属于 LevelNormal
+type LevelRegistry struct {
+	m atomic.Pointer[map[string]Level]
+}
+
+func NewLevelRegistry(list ...PatternLevel) *LevelRegistry {
+	r := &LevelRegistry{}
+	r.Init(list)
+	return r
+}
+
+// defaultLevels 未指定映射的 ActorSystem 以及包级别的 InitPatternLevelMap 使用的映射
+var defaultLevels = NewLevelRegistry()
+
+// DefaultLevels 返回默认的等级映射
+func DefaultLevels() *LevelRegistry {
+	return defaultLevels
+}
+
+// Init 替换全部映射
+func (r *LevelRegistry) Init(list []PatternLevel) {
+	m := make(map[string]Level, len(list))
 	for _, item := range list {
 		m[item.Pattern] = item.Level
 	}
+	r.m.Store(&m)
+}
+
+// Set 登记单个Pattern的等级，写时复制，适用于启动阶段
+func (r *LevelRegistry) Set(pattern string, level Level) {
+	for {
+		old := r.m.Load()
+		m := make(map[string]Level, len(*old)+1)
+		for k, v := range *old {
+			m[k] = v
+		}
+		m[pattern] = level
+		if r.m.CompareAndSwap(old, &m) {
+			return
+		}
+	}
+}
+
+// Get 返回Pattern的等级，未登记时返回 LevelNormal
+func (r *LevelRegistry) Get(pattern string) Level {
+	if m := r.m.Load(); m != nil {
+		return (*m)[pattern]
+	}
+	return LevelNormal
+}
 
-	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&patternLevelMap)), unsafe.Pointer(&m))
+func InitPatternLevelMap(list []PatternLevel) {
+	defaultLevels.Init(list)
 }
 
 func GetLevelByPattern(pattern string) Level {
-	m := *patternLevelMap
-	return m[pattern]
+	return defaultLevels.Get(pattern)
 }
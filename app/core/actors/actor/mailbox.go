@@ -0,0 +1,584 @@
+package actor
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+)
+
+// OverflowPolicy 有界邮箱写满后对新消息的处理策略
+type OverflowPolicy int8
+
+const (
+	// OverflowDropNewest 丢弃新到达的消息，丢弃的消息发布到死信
+	OverflowDropNewest OverflowPolicy = iota
+	// OverflowDropOldest 丢弃邮箱中最早的消息，为新消息腾出空间，丢弃的消息发布到死信
+	OverflowDropOldest
+	// OverflowReject 拒绝新消息：Send 直接返回 ErrMailboxFull，Request 的调用者收到 ErrMailboxFull
+	OverflowReject
+	// OverflowBlock 阻塞发送方直到邮箱有空位，超过 MailboxConfig.BlockTimeout 后按 OverflowReject 处理
+	// 注意：Actor向自己发送消息时不要使用此策略
+	OverflowBlock
+)
+
+// MailboxConfig Pattern的有界邮箱配置
+type MailboxConfig struct {
+	Capacity     int            // 邮箱容量，只有业务消息计入容量，<=0表示无界
+	Overflow     OverflowPolicy // 邮箱写满后的处理策略，被丢弃或拒绝的消息都会发布到死信
+	BlockTimeout time.Duration  // OverflowBlock 策略下发送方的最长阻塞时间，默认为 DefaultMailboxBlockTimeout
+}
+
+// MailboxRegistry 按Pattern注册的邮箱配置，每个 ActorSystem 可以使用独立的注册表(WithMailboxes)
+// 未注册的Pattern使用无界邮箱
+type MailboxRegistry struct {
+	mu      sync.RWMutex
+	configs map[string]*MailboxConfig
+}
+
+func NewMailboxRegistry() *MailboxRegistry {
+	return &MailboxRegistry{
+		configs: make(map[string]*MailboxConfig),
+	}
+}
+
+// defaultMailboxes 未指定注册表的 ActorSystem 以及包级别的 RegMailbox 使用的注册表
+var defaultMailboxes = NewMailboxRegistry()
+
+// DefaultMailboxes 返回默认的邮箱配置注册表
+func DefaultMailboxes() *MailboxRegistry {
+	return defaultMailboxes
+}
+
+// Reg 为Pattern注册邮箱配置，重复注册时panic
+func (r *MailboxRegistry) Reg(pattern string, config *MailboxConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.configs[pattern]; ok {
+		panic("mailbox config already registered: " + pattern)
+	}
+	r.configs[pattern] = config
+}
+
+// Get 返回Pattern的邮箱配置，未注册时返回nil
+func (r *MailboxRegistry) Get(pattern string) *MailboxConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configs[pattern]
+}
+
+// RegMailbox 为Pattern注册有界邮箱，同一Pattern的所有Actor使用相同的配置
+//
+// 示例:
+//
+//	RegMailbox("boss-pattern", &MailboxConfig{
+//	  Capacity: 10000,
+//	  Overflow: OverflowReject,
+//	})
+func RegMailbox(pattern string, config *MailboxConfig) {
+	defaultMailboxes.Reg(pattern, config)
+}
+
+// MailboxStats 邮箱统计信息
+type MailboxStats struct {
+	Capacity  int   `json:"capacity"`  // 容量，0表示无界
+	Depth     int   `json:"depth"`     // 当前积压的消息数
+	MaxDepth  int   `json:"maxDepth"`  // 积压消息数的历史峰值
+	Posted    int64 `json:"posted"`    // 进入邮箱的消息总数
+	Processed int64 `json:"processed"` // 已处理的消息总数
+	Dropped   int64 `json:"dropped"`   // 因邮箱写满被丢弃的消息数
+	Rejected  int64 `json:"rejected"`  // 因邮箱写满被拒绝的消息数
+}
+
+// internalMessage 框架内部消息，进入邮箱的内部通道，优先于所有业务消息处理
+type internalMessage interface {
+	internalMessage()
+}
+
+// 用户消息通道，按顺序依次处理，同一通道内保持先进先出
+const (
+	laneInternal = iota // 框架内部消息：定时器、保活检测
+	laneHigh            // 通过 SendPriority 发送的业务消息
+	laneNormal          // 普通业务消息以及其他用户消息(例如PoisonPill)
+	laneCount
+)
+
+// mailbox 子Actor使用的邮箱，实现protoactor的Mailbox接口
+//
+//  1. 系统消息(Started/Stop/Failure等)优先于所有用户消息处理
+//  2. 用户消息按 内部消息 > 高优先级业务消息 > 普通消息 的顺序处理，同一优先级内保持到达顺序
+//  3. 只有业务消息(RequestMessage)计入容量，框架内部消息不受容量限制，保证定时器和保活检测不会被丢弃
+type mailbox struct {
+	actorSystem  *actor.ActorSystem
+	actorName    string
+	pattern      string
+	capacity     int
+	overflow     OverflowPolicy
+	blockTimeout time.Duration
+	deadLetters  *DeadLetterSink
+
+	mu      sync.Mutex
+	system  *ringQueue
+	lanes   [laneCount]*ringQueue
+	bounded int             // 邮箱中计入容量的业务消息数
+	waiters []chan struct{} // OverflowBlock 策略下等待空位的发送方，按到达顺序排列
+
+	schedulerStatus atomic.Int32
+	suspended       atomic.Int32
+	invoker         actor.MessageInvoker
+	dispatcher      actor.Dispatcher
+
+	// 注册了 WatchdogPolicy 的Actor记录处理消息的协程ID，每次调度时更新一次
+	watched atomic.Bool
+	goid    atomic.Int64
+
+	maxDepth  atomic.Int64
+	posted    atomic.Int64
+	processed atomic.Int64
+	dropped   atomic.Int64
+	rejected  atomic.Int64
+}
+
+const (
+	mailboxIdle int32 = iota
+	mailboxRunning
+)
+
+func newMailbox(actorSystem *actor.ActorSystem, actorName, pattern string, config *MailboxConfig) *mailbox {
+	m := &mailbox{
+		actorSystem: actorSystem,
+		actorName:   actorName,
+		pattern:     pattern,
+		system:      newRingQueue(4),
+	}
+	if config != nil {
+		m.capacity = config.Capacity
+		m.overflow = config.Overflow
+		m.blockTimeout = config.BlockTimeout
+	}
+	for i := range m.lanes {
+		m.lanes[i] = newRingQueue(4)
+	}
+	return m
+}
+
+// producer 返回创建当前邮箱的MailboxProducer，protoactor在Spawn时调用一次
+func (m *mailbox) producer() actor.MailboxProducer {
+	return func() actor.Mailbox {
+		return m
+	}
+}
+
+func (m *mailbox) PostUserMessage(message any) {
+	_, msg, sender := actor.UnwrapEnvelope(message)
+	rm, bounded := msg.(*RequestMessage)
+	if !bounded || m.capacity <= 0 {
+		m.mu.Lock()
+		m.pushUser(message, bounded)
+		m.mu.Unlock()
+		m.schedule()
+		return
+	}
+
+	var evicted any
+	m.mu.Lock()
+	switch {
+	case m.bounded < m.capacity:
+		m.pushUser(message, true)
+	case m.overflow == OverflowDropOldest:
+		if evicted = m.evictOldest(); evicted == nil {
+			// 最早的消息不是业务消息，无法腾出空间，退化为丢弃新消息
+			m.mu.Unlock()
+			m.drop(message)
+			return
+		}
+		m.pushUser(message, true)
+	case m.overflow == OverflowBlock:
+		m.mu.Unlock()
+		if !m.waitNotFull(message) {
+			m.reject(rm, sender)
+		}
+		m.schedule()
+		return
+	default:
+		m.mu.Unlock()
+		if m.overflow == OverflowReject {
+			m.reject(rm, sender)
+		} else {
+			m.drop(message)
+		}
+		return
+	}
+	m.mu.Unlock()
+
+	if evicted != nil {
+		m.drop(evicted)
+	}
+	m.schedule()
+}
+
+// waitNotFull 阻塞等待邮箱出现空位并写入消息，超时返回false
+// 每腾出一个空位唤醒一个等待者，等待者按到达顺序被唤醒
+func (m *mailbox) waitNotFull(message any) bool {
+	timeout := m.blockTimeout
+	if timeout <= 0 {
+		timeout = DefaultMailboxBlockTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		m.mu.Lock()
+		if m.bounded < m.capacity {
+			m.pushUser(message, true)
+			m.mu.Unlock()
+			return true
+		}
+		wake := make(chan struct{})
+		m.waiters = append(m.waiters, wake)
+		m.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			m.mu.Lock()
+			if !m.removeWaiter(wake) {
+				// 超时的同时已被唤醒，把空位让给下一个等待者
+				m.signalNotFull()
+			}
+			m.mu.Unlock()
+			return false
+		}
+	}
+}
+
+// removeWaiter 移除仍在等待的发送方，已被唤醒时返回false，必须在持有锁的情况下调用
+func (m *mailbox) removeWaiter(wake chan struct{}) bool {
+	for i, w := range m.waiters {
+		if w == wake {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// pushUser 必须在持有锁的情况下调用
+func (m *mailbox) pushUser(message any, bounded bool) {
+	m.lanes[laneOf(message)].Push(message)
+	if bounded {
+		m.bounded++
+	}
+	m.posted.Add(1)
+	if depth := int64(m.depth()); depth > m.maxDepth.Load() {
+		m.maxDepth.Store(depth)
+	}
+}
+
+// evictOldest 移除最早的业务消息，优先从普通通道移除，必须在持有锁的情况下调用
+func (m *mailbox) evictOldest() any {
+	for lane := laneNormal; lane >= laneHigh; lane-- {
+		head := m.lanes[lane].Peek()
+		if head == nil {
+			continue
+		}
+		if !isBounded(head) {
+			return nil
+		}
+		m.lanes[lane].Pop()
+		m.bounded--
+		return head
+	}
+	return nil
+}
+
+// prepend 将Actor暂存的消息按原有顺序放回各自通道的头部
+func (m *mailbox) prepend(messages []*actor.MessageEnvelope) {
+	m.mu.Lock()
+	var lanes [laneCount][]any
+	for _, env := range messages {
+		lane := laneOf(env)
+		lanes[lane] = append(lanes[lane], env)
+		if isBounded(env) {
+			m.bounded++
+		}
+	}
+	for i, front := range lanes {
+		if len(front) == 0 {
+			continue
+		}
+		rb := newRingQueue(len(front) + m.lanes[i].Length())
+		for _, msg := range front {
+			rb.Push(msg)
+		}
+		for msg, ok := m.lanes[i].Pop(); ok; msg, ok = m.lanes[i].Pop() {
+			rb.Push(msg)
+		}
+		m.lanes[i] = rb
+	}
+	m.mu.Unlock()
+	m.schedule()
+}
+
+// depth 必须在持有锁的情况下调用
+func (m *mailbox) depth() int {
+	n := 0
+	for _, lane := range m.lanes {
+		n += lane.Length()
+	}
+	return n
+}
+
+func laneOf(message any) int {
+	msg := actor.UnwrapEnvelopeMessage(message)
+	if _, ok := msg.(internalMessage); ok {
+		return laneInternal
+	}
+	if rm, ok := msg.(*RequestMessage); ok && rm.Priority > PriorityNormal {
+		return laneHigh
+	}
+	return laneNormal
+}
+
+func isBounded(message any) bool {
+	_, ok := actor.UnwrapEnvelopeMessage(message).(*RequestMessage)
+	return ok
+}
+
+func (m *mailbox) drop(message any) {
+	m.dropped.Add(1)
+	_, msg, sender := actor.UnwrapEnvelope(message)
+	if rm, ok := msg.(*RequestMessage); ok {
+		m.deadLetter(rm, sender)
+	}
+}
+
+func (m *mailbox) reject(rm *RequestMessage, sender *actor.PID) {
+	m.rejected.Add(1)
+	if sender != nil {
+		m.actorSystem.Root.Send(sender, ErrMailboxFull)
+	}
+	m.deadLetter(rm, sender)
+}
+
+func (m *mailbox) deadLetter(rm *RequestMessage, sender *actor.PID) {
+	if m.deadLetters == nil {
+		return
+	}
+	m.deadLetters.Publish(&DeadLetter{
+		ActorName: m.actorName,
+		Pattern:   m.pattern,
+		Message:   rm,
+		Sender:    sender,
+		Reason:    ErrMailboxFull,
+	})
+}
+
+// rejectsNow 邮箱写满且策略为 OverflowReject 时返回true，用于 Send 同步返回错误
+func (m *mailbox) rejectsNow() bool {
+	if m.capacity <= 0 || m.overflow != OverflowReject {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bounded >= m.capacity
+}
+
+func (m *mailbox) PostSystemMessage(message any) {
+	m.mu.Lock()
+	m.system.Push(message)
+	m.mu.Unlock()
+	m.schedule()
+}
+
+func (m *mailbox) RegisterHandlers(invoker actor.MessageInvoker, dispatcher actor.Dispatcher) {
+	m.invoker = invoker
+	m.dispatcher = dispatcher
+}
+
+func (m *mailbox) Start() {}
+
+func (m *mailbox) UserMessageCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.depth()
+}
+
+// Stats 返回邮箱统计信息的快照
+func (m *mailbox) Stats() MailboxStats {
+	return MailboxStats{
+		Capacity:  m.capacity,
+		Depth:     m.UserMessageCount(),
+		MaxDepth:  int(m.maxDepth.Load()),
+		Posted:    m.posted.Load(),
+		Processed: m.processed.Load(),
+		Dropped:   m.dropped.Load(),
+		Rejected:  m.rejected.Load(),
+	}
+}
+
+func (m *mailbox) schedule() {
+	if m.schedulerStatus.CompareAndSwap(mailboxIdle, mailboxRunning) {
+		m.dispatcher.Schedule(m.processMessages)
+	}
+}
+
+func (m *mailbox) processMessages() {
+	if m.watched.Load() {
+		m.goid.Store(currentGoroutineID())
+	}
+	for {
+		m.run()
+		m.schedulerStatus.Store(mailboxIdle)
+
+		// 检查在消息循环结束后到达的消息
+		if !m.hasPending() || !m.schedulerStatus.CompareAndSwap(mailboxIdle, mailboxRunning) {
+			return
+		}
+	}
+}
+
+func (m *mailbox) hasPending() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.system.IsEmpty() {
+		return true
+	}
+	return m.suspended.Load() == 0 && m.depth() > 0
+}
+
+func (m *mailbox) run() {
+	var msg any
+
+	defer func() {
+		if r := recover(); r != nil {
+			m.invoker.EscalateFailure(r, msg)
+		}
+	}()
+
+	i, t := 0, m.dispatcher.Throughput()
+	for {
+		if i > t {
+			i = 0
+			runtime.Gosched()
+		}
+		i++
+
+		// 优先处理所有系统消息
+		if msg = m.popSystem(); msg != nil {
+			switch msg.(type) {
+			case *actor.SuspendMailbox:
+				m.suspended.Store(1)
+			case *actor.ResumeMailbox:
+				m.suspended.Store(0)
+			default:
+				m.invoker.InvokeSystemMessage(msg)
+			}
+			continue
+		}
+
+		// 邮箱被挂起(例如Actor崩溃等待Supervisor处理)，暂停处理用户消息
+		if m.suspended.Load() == 1 {
+			return
+		}
+
+		if msg = m.popUser(); msg == nil {
+			return
+		}
+		m.processed.Add(1)
+		m.invoker.InvokeUserMessage(msg)
+	}
+}
+
+func (m *mailbox) popSystem() any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	msg, ok := m.system.Pop()
+	if !ok {
+		return nil
+	}
+	return msg
+}
+
+// popUser 按通道优先级取出下一条用户消息
+func (m *mailbox) popUser() any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, lane := range m.lanes {
+		msg, ok := lane.Pop()
+		if !ok {
+			continue
+		}
+		if isBounded(msg) {
+			m.bounded--
+			m.signalNotFull()
+		}
+		return msg
+	}
+	return nil
+}
+
+// signalNotFull 唤醒最早等待空位的发送方，必须在持有锁的情况下调用
+func (m *mailbox) signalNotFull() {
+	if len(m.waiters) == 0 {
+		return
+	}
+	wake := m.waiters[0]
+	m.waiters[0] = nil
+	m.waiters = m.waiters[1:]
+	close(wake)
+}
+
+// ringQueue 邮箱通道使用的先进先出环形队列，写满时容量翻倍，必须在持有邮箱锁的情况下访问
+type ringQueue struct {
+	buf  []any
+	head int
+	len  int
+}
+
+func newRingQueue(size int) *ringQueue {
+	if size < 4 {
+		size = 4
+	}
+	return &ringQueue{buf: make([]any, size)}
+}
+
+func (q *ringQueue) Push(item any) {
+	if q.len == len(q.buf) {
+		buf := make([]any, len(q.buf)<<1)
+		for i := 0; i < q.len; i++ {
+			buf[i] = q.buf[(q.head+i)%len(q.buf)]
+		}
+		q.buf, q.head = buf, 0
+	}
+	q.buf[(q.head+q.len)%len(q.buf)] = item
+	q.len++
+}
+
+func (q *ringQueue) Pop() (any, bool) {
+	if q.len == 0 {
+		return nil, false
+	}
+	item := q.buf[q.head]
+	q.buf[q.head] = nil
+	q.head = (q.head + 1) % len(q.buf)
+	q.len--
+	return item, true
+}
+
+func (q *ringQueue) Peek() any {
+	if q.len == 0 {
+		return nil
+	}
+	return q.buf[q.head]
+}
+
+func (q *ringQueue) Length() int {
+	return q.len
+}
+
+func (q *ringQueue) IsEmpty() bool {
+	return q.len == 0
+}
@@ -0,0 +1,231 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// manualDispatcher 不自动调度，由测试手动驱动邮箱处理消息
+type manualDispatcher struct{}
+
+func (manualDispatcher) Schedule(fn func()) {}
+
+func (manualDispatcher) Throughput() int { return 300 }
+
+type recordInvoker struct {
+	user []any
+}
+
+func (r *recordInvoker) InvokeSystemMessage(any) {}
+
+func (r *recordInvoker) InvokeUserMessage(msg any) {
+	r.user = append(r.user, actor.UnwrapEnvelopeMessage(msg))
+}
+
+func (r *recordInvoker) EscalateFailure(reason any, message any) {}
+
+func newTestMailbox(config *MailboxConfig) (*mailbox, *recordInvoker) {
+	invoker := &recordInvoker{}
+	m := newMailbox(System.ActorSystem(), "mailbox-actor", "mailbox-pattern", config)
+	m.RegisterHandlers(invoker, manualDispatcher{})
+	return m, invoker
+}
+
+func sendMessage(v any) *RequestMessage {
+	return &RequestMessage{MsgType: MessageTypeSend, Message: v}
+}
+
+func TestMailbox_Overflow(t *testing.T) {
+	service := setup("mailbox-pattern")
+	defer service.Stop(context.Background())
+
+	t.Run("DropNewest", func(t *testing.T) {
+		m, invoker := newTestMailbox(&MailboxConfig{Capacity: 2, Overflow: OverflowDropNewest})
+		for i := 1; i <= 4; i++ {
+			m.PostUserMessage(sendMessage(i))
+		}
+		m.run()
+		assert.Equal(t, []any{sendMessage(1), sendMessage(2)}, invoker.user)
+		assert.Equal(t, int64(2), m.Stats().Dropped)
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		m, invoker := newTestMailbox(&MailboxConfig{Capacity: 2, Overflow: OverflowDropOldest})
+		for i := 1; i <= 4; i++ {
+			m.PostUserMessage(sendMessage(i))
+		}
+		m.run()
+		assert.Equal(t, []any{sendMessage(3), sendMessage(4)}, invoker.user)
+		assert.Equal(t, int64(2), m.Stats().Dropped)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		m, _ := newTestMailbox(&MailboxConfig{Capacity: 1, Overflow: OverflowReject})
+		m.PostUserMessage(sendMessage(1))
+		assert.True(t, m.rejectsNow())
+
+		future := actor.NewFuture(System.ActorSystem(), time.Second)
+		m.PostUserMessage(&actor.MessageEnvelope{
+			Message: &RequestMessage{MsgType: MessageTypeRequest, Message: 2},
+			Sender:  future.PID(),
+		})
+		result, err := future.Result()
+		assert.NoError(t, err)
+		assert.True(t, errors.Is(result.(error), ErrMailboxFull))
+		assert.Equal(t, int64(1), m.Stats().Rejected)
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		m, invoker := newTestMailbox(&MailboxConfig{
+			Capacity:     1,
+			Overflow:     OverflowBlock,
+			BlockTimeout: time.Second,
+		})
+		m.PostUserMessage(sendMessage(1))
+
+		done := make(chan struct{})
+		go func() {
+			m.PostUserMessage(sendMessage(2))
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("sender should block while mailbox is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		assert.Equal(t, sendMessage(1), actor.UnwrapEnvelopeMessage(m.popUser()))
+		<-done
+		m.run()
+		assert.Equal(t, []any{sendMessage(2)}, invoker.user)
+
+		m.blockTimeout = 20 * time.Millisecond
+		m.PostUserMessage(sendMessage(3))
+		m.PostUserMessage(sendMessage(4))
+		assert.Equal(t, int64(1), m.Stats().Rejected)
+	})
+
+	// 连续腾出多个空位时，每个空位唤醒一个等待的发送方
+	t.Run("BlockWakesEachWaiter", func(t *testing.T) {
+		m, _ := newTestMailbox(&MailboxConfig{
+			Capacity:     2,
+			Overflow:     OverflowBlock,
+			BlockTimeout: 5 * time.Second,
+		})
+		m.PostUserMessage(sendMessage(1))
+		m.PostUserMessage(sendMessage(2))
+
+		done := make(chan struct{}, 2)
+		for i := 3; i <= 4; i++ {
+			go func(i int) {
+				m.PostUserMessage(sendMessage(i))
+				done <- struct{}{}
+			}(i)
+		}
+		assert.Eventually(t, func() bool {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return len(m.waiters) == 2
+		}, time.Second, 5*time.Millisecond)
+
+		m.popUser()
+		m.popUser()
+		for i := 0; i < 2; i++ {
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("blocked sender was not woken after a slot was freed")
+			}
+		}
+		assert.Equal(t, int64(0), m.Stats().Rejected)
+	})
+}
+
+// 内部消息不计入容量，并且优先于业务消息处理
+func TestMailbox_InternalMessages(t *testing.T) {
+	service := setup("mailbox-pattern")
+	defer service.Stop(context.Background())
+
+	m, invoker := newTestMailbox(&MailboxConfig{Capacity: 1, Overflow: OverflowDropNewest})
+	timer := &TimerMessage{}
+	m.PostUserMessage(sendMessage(1))
+	m.PostUserMessage(timer)
+	m.PostUserMessage(timer)
+	m.PostUserMessage(sendMessage(2))
+
+	stats := m.Stats()
+	assert.Equal(t, 3, stats.Depth)
+	assert.Equal(t, 3, stats.MaxDepth)
+	assert.Equal(t, int64(1), stats.Dropped)
+
+	m.run()
+	assert.Equal(t, []any{timer, timer, sendMessage(1)}, invoker.user)
+	assert.Equal(t, 0, m.Stats().Depth)
+	assert.Equal(t, int64(3), m.Stats().Processed)
+}
+
+// 高优先级消息排在普通消息之前，同一优先级内保持到达顺序
+func TestMailbox_Priority(t *testing.T) {
+	service := setup("mailbox-pattern")
+	defer service.Stop(context.Background())
+
+	m, invoker := newTestMailbox(&MailboxConfig{Capacity: 3, Overflow: OverflowDropOldest})
+	high := func(v any) *RequestMessage {
+		msg := sendMessage(v)
+		msg.Priority = PriorityHigh
+		return msg
+	}
+	timer := &TimerMessage{}
+	m.PostUserMessage(sendMessage(1))
+	m.PostUserMessage(high(2))
+	m.PostUserMessage(sendMessage(3))
+	m.PostUserMessage(timer)
+	m.PostUserMessage(high(4)) // 邮箱已满，丢弃最早的普通消息1
+
+	m.run()
+	assert.Equal(t, []any{timer, high(2), high(4), sendMessage(3)}, invoker.user)
+	assert.Equal(t, int64(1), m.Stats().Dropped)
+}
+
+// 同一Pattern的Actor使用注册的邮箱配置，未注册的Pattern使用无界邮箱
+func TestMailbox_ActorRef(t *testing.T) {
+	const pattern = "mailbox-ref-pattern"
+	factories, mailboxes := NewFactoryRegistry(), NewMailboxRegistry()
+	for _, p := range []string{pattern, "mailbox-unbounded-pattern"} {
+		factories.Reg(p, func(actorName string) Behavior {
+			return &MockBehavior{actorID: actorName}
+		})
+	}
+	mailboxes.Reg(pattern, &MailboxConfig{Capacity: 8, Overflow: OverflowReject})
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories), WithMailboxes(mailboxes))
+	defer af.Stop(context.Background())
+
+	ref := af.NewActorRef(NewProps(), "mailbox-ref-actor", pattern)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, ref.Send(i))
+	}
+
+	assert.Eventually(t, func() bool {
+		stats, ok := af.MailboxStats("mailbox-ref-actor")
+		return ok && stats.Processed >= 4 && stats.Depth == 0
+	}, time.Second, 10*time.Millisecond)
+
+	stats, _ := af.MailboxStats("mailbox-ref-actor")
+	assert.Equal(t, 8, stats.Capacity)
+
+	other := af.NewActorRef(NewProps(), "mailbox-ref-other", pattern)
+	assert.NoError(t, other.Send(1))
+	unbounded := af.NewActorRef(NewProps(), "mailbox-unbounded-actor", "mailbox-unbounded-pattern")
+	assert.NoError(t, unbounded.Send(1))
+	assert.Eventually(t, func() bool {
+		s1, ok1 := af.MailboxStats("mailbox-ref-other")
+		s2, ok2 := af.MailboxStats("mailbox-unbounded-actor")
+		return ok1 && ok2 && s1.Capacity == 8 && s2.Capacity == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -2,11 +2,19 @@ package actor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"gitee.com/orbit-w/orbit/lib/logger"
 	"gitee.com/orbit-w/orbit/lib/unipue_task_exec"
 	cmap "github.com/orcaman/concurrent-map"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -23,8 +31,33 @@ import (
 		Dispatcher    *Dispatcher            `protobuf:"bytes,4,opt,name=Dispatcher,proto3" json:"Dispatcher,omitempty"`
 		...
 	}
+
+	本地缓存:
+	1. 每一项在 WithMetaLocalTTL 设置的时间后过期，过期后下一次 Load 重新从Redis读取
+	2. Store/CompareAndSwap/Del 写入Redis后在 actor_meta_invalidate 频道发布失效通知，
+	   调用 Start 订阅后，其他节点收到通知时删除本地缓存
+	3. 订阅断开期间的通知会丢失，此时本地缓存最多在TTL后失效
 */
 
+const (
+	metaInvalidateChannel = "actor_meta_invalidate"
+	metaCASMaxRetries     = 3
+
+	DefaultMetaLocalTTL = 5 * time.Minute
+)
+
+// MetaCacheOption ActorMetaCache 选项
+type MetaCacheOption func(c *ActorMetaCache)
+
+// WithMetaLocalTTL 设置本地缓存的有效期
+func WithMetaLocalTTL(ttl time.Duration) MetaCacheOption {
+	return func(c *ActorMetaCache) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
 func NewMeta(name, pattern, serverId string, dispatcher *Dispatcher) *Meta {
 	return &Meta{
 		ActorName:  name,
@@ -34,23 +67,84 @@ func NewMeta(name, pattern, serverId string, dispatcher *Dispatcher) *Meta {
 	}
 }
 
+type metaEntry struct {
+	meta     *Meta
+	expireAt time.Time
+}
+
 type ActorMetaCache struct {
 	cli   *redis.Client
+	id    string // 实例ID，忽略自己发布的失效通知
+	ttl   time.Duration
 	cache cmap.ConcurrentMap
 	exec  *unipue_task_exec.UniqueTaskExecutor
+
+	mu     sync.Mutex
+	pubsub *redis.PubSub
+	done   chan struct{}
 }
 
-func NewMetaCache(cli *redis.Client) *ActorMetaCache {
-	return &ActorMetaCache{
+func NewMetaCache(cli *redis.Client, ops ...MetaCacheOption) *ActorMetaCache {
+	c := &ActorMetaCache{
 		cli:   cli,
+		id:    strconv.FormatUint(rand.Uint64(), 16),
+		ttl:   DefaultMetaLocalTTL,
 		cache: cmap.New(),
 		exec:  unipue_task_exec.NewUniqueTaskExecutor(),
 	}
+	for _, op := range ops {
+		op(c)
+	}
+	return c
+}
+
+// Start 订阅其他节点发布的失效通知，重复调用无副作用
+func (c *ActorMetaCache) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pubsub != nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	pubsub := c.cli.Subscribe(ctx, metaInvalidateChannel)
+	// 等待订阅确认，保证 Start 返回后不会错过通知
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return err
+	}
+	c.pubsub = pubsub
+	c.done = make(chan struct{})
+	go c.listen(pubsub.Channel(), c.done)
+	return nil
+}
+
+// Stop 取消订阅
+func (c *ActorMetaCache) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pubsub == nil {
+		return
+	}
+	_ = c.pubsub.Close()
+	<-c.done
+	c.pubsub = nil
+}
+
+func (c *ActorMetaCache) listen(ch <-chan *redis.Message, done chan struct{}) {
+	defer close(done)
+	for msg := range ch {
+		id, actorName, ok := strings.Cut(msg.Payload, "|")
+		if !ok || id == c.id {
+			continue
+		}
+		c.cache.Remove(actorName)
+	}
 }
 
 func (c *ActorMetaCache) Load(actorName string) (*Meta, error) {
-	if v, exists := c.cache.Get(actorName); exists {
-		return v.(*Meta), nil
+	if v, exists := c.Get(actorName); exists {
+		return v, nil
 	}
 
 	re := c.exec.ExecuteOnce(actorName, func() any {
@@ -66,7 +160,7 @@ func (c *ActorMetaCache) Load(actorName string) (*Meta, error) {
 			return err
 		}
 
-		c.cache.Set(actorName, ar)
+		c.Set(actorName, ar)
 		return ar
 	})
 
@@ -80,31 +174,170 @@ func (c *ActorMetaCache) Load(actorName string) (*Meta, error) {
 	}
 }
 
+// LoadMany 批量加载Meta，本地缓存未命中的部分通过一次 MGET 读取
+// Redis中不存在的Actor不出现在返回结果中
+func (c *ActorMetaCache) LoadMany(actorNames []string) (map[string]*Meta, error) {
+	metas := make(map[string]*Meta, len(actorNames))
+	var missing, keys []string
+	for _, actorName := range actorNames {
+		if meta, ok := c.Get(actorName); ok {
+			metas[actorName] = meta
+			continue
+		}
+		if _, ok := metas[actorName]; ok {
+			continue
+		}
+		missing = append(missing, actorName)
+		keys = append(keys, genRedisKey(actorName))
+	}
+	if len(keys) == 0 {
+		return metas, nil
+	}
+
+	values, err := c.cli.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		content, ok := v.(string)
+		if !ok {
+			continue
+		}
+		meta := &Meta{}
+		if err = proto.Unmarshal([]byte(content), meta); err != nil {
+			return nil, err
+		}
+		c.Set(missing[i], meta)
+		metas[missing[i]] = meta
+	}
+	return metas, nil
+}
+
+// Store 写入Redis成功后更新本地缓存，并通知其他节点失效
 func (c *ActorMetaCache) Store(actorName string, value *Meta) (*Meta, error) {
 	content, err := proto.Marshal(value)
 	if err != nil {
 		return nil, err
 	}
 
-	c.cli.Set(context.Background(), genRedisKey(actorName), content, 0)
-	c.cache.Set(actorName, value)
+	if err = c.cli.Set(context.Background(), genRedisKey(actorName), content, 0).Err(); err != nil {
+		return nil, err
+	}
+	c.Set(actorName, value)
+	c.publish(actorName)
+	return value, nil
+}
+
+// CompareAndSwap Redis中记录的 Dispatcher 与 expect 相同时写入 value，不存在的记录视为 Dispatcher 为nil
+// 记录已被其他节点修改时返回当前记录和 ErrMetaConflict
+func (c *ActorMetaCache) CompareAndSwap(actorName string, expect *Dispatcher, value *Meta) (*Meta, error) {
+	content, err := proto.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	key := genRedisKey(actorName)
+	var current *Meta
+	for i := 0; i < metaCASMaxRetries; i++ {
+		err = c.cli.Watch(ctx, func(tx *redis.Tx) error {
+			current = nil
+			stored, err := tx.Get(ctx, key).Bytes()
+			switch {
+			case errors.Is(err, redis.Nil):
+			case err != nil:
+				return err
+			default:
+				current = &Meta{}
+				if err = proto.Unmarshal(stored, current); err != nil {
+					return err
+				}
+			}
+			if !proto.Equal(current.GetDispatcher(), expect) {
+				return ErrMetaConflict
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, content, 0)
+				return nil
+			})
+			return err
+		}, key)
+		// WATCH的key在事务执行前被修改，重新比较
+		if !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+
+	switch {
+	case errors.Is(err, ErrMetaConflict):
+		if current != nil {
+			c.Set(actorName, current)
+		} else {
+			c.cache.Remove(actorName)
+		}
+		return current, err
+	case err != nil:
+		return nil, err
+	}
+	c.Set(actorName, value)
+	c.publish(actorName)
 	return value, nil
 }
 
+// Set 只写入本地缓存
 func (c *ActorMetaCache) Set(key string, value *Meta) {
-	c.cache.Set(key, value)
+	c.cache.Set(key, &metaEntry{meta: value, expireAt: time.Now().Add(c.ttl)})
 }
 
+// Get 只读取本地缓存，已过期的项视为不存在
 func (c *ActorMetaCache) Get(key string) (*Meta, bool) {
-	if v, ok := c.cache.Get(key); ok {
-		return v.(*Meta), true
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	entry := v.(*metaEntry)
+	if !time.Now().Before(entry.expireAt) {
+		c.cache.RemoveCb(key, func(_ string, v any, exists bool) bool {
+			return exists && v == entry
+		})
+		return nil, false
+	}
+	return entry.meta, true
 }
 
-func (c *ActorMetaCache) Del(key string) {
-	c.cli.Del(context.Background(), key)
+// Del 删除Redis中的记录和本地缓存，并通知其他节点失效
+func (c *ActorMetaCache) Del(key string) error {
 	c.cache.Remove(key)
+	if err := c.cli.Del(context.Background(), genRedisKey(key)).Err(); err != nil {
+		return err
+	}
+	c.publish(key)
+	return nil
+}
+
+// Items 返回本地缓存的所有未过期的Meta
+func (c *ActorMetaCache) Items() map[string]*Meta {
+	now := time.Now()
+	items := make(map[string]*Meta, c.cache.Count())
+	for key, v := range c.cache.Items() {
+		if entry := v.(*metaEntry); now.Before(entry.expireAt) {
+			items[key] = entry.meta
+		}
+	}
+	return items
+}
+
+// Evict 只删除本地缓存，下一次 Load 时重新从Redis读取
+func (c *ActorMetaCache) Evict(key string) {
+	c.cache.Remove(key)
+}
+
+// publish 通知其他节点删除本地缓存，失败时其他节点的缓存在TTL后失效
+func (c *ActorMetaCache) publish(actorName string) {
+	if err := c.cli.Publish(context.Background(), metaInvalidateChannel, c.id+"|"+actorName).Err(); err != nil {
+		logger.GetLogger().Error("[ActorMetaCache] publish invalidation failed", zap.String("ActorName", actorName), zap.Error(err))
+	}
 }
 
 func genRedisKey(actorName string) string {
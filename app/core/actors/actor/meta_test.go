@@ -0,0 +1,136 @@
+package actor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMetaCache_StoreAndDel(t *testing.T) {
+	stub, cli := newRedisStub(t)
+	c := NewMetaCache(cli)
+
+	meta := NewMeta("guild-1", "guild", "1", &Dispatcher{NodeId: "node-a"})
+	_, err := c.Store("guild-1", meta)
+	assert.NoError(t, err)
+	content, ok := stub.Get(genRedisKey("guild-1"))
+	assert.True(t, ok)
+	stored := &Meta{}
+	assert.NoError(t, proto.Unmarshal([]byte(content), stored))
+	assert.True(t, proto.Equal(meta, stored))
+
+	assert.NoError(t, c.Del("guild-1"))
+	_, ok = stub.Get(genRedisKey("guild-1"))
+	assert.False(t, ok, "del should remove the actor_meta key")
+	_, ok = c.Get("guild-1")
+	assert.False(t, ok)
+	_, err = c.Load("guild-1")
+	assert.True(t, errors.Is(err, redis.Nil))
+
+	// 写入Redis失败时不更新本地缓存
+	broken := NewMetaCache(redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1}))
+	_, err = broken.Store("guild-2", meta)
+	assert.Error(t, err)
+	_, ok = broken.Get("guild-2")
+	assert.False(t, ok)
+}
+
+func TestMetaCache_LocalTTL(t *testing.T) {
+	stub, cli := newRedisStub(t)
+	ttl := 100 * time.Millisecond
+	c := NewMetaCache(cli, WithMetaLocalTTL(ttl))
+
+	_, err := c.Store("guild-ttl", NewMeta("guild-ttl", "guild", "1", &Dispatcher{NodeId: "node-a"}))
+	assert.NoError(t, err)
+	content, _ := proto.Marshal(NewMeta("guild-ttl", "guild", "1", &Dispatcher{NodeId: "node-b"}))
+	stub.Set(genRedisKey("guild-ttl"), string(content), 0)
+
+	meta, err := c.Load("guild-ttl")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", meta.GetDispatcher().GetNodeId(), "local cache should be used before expiry")
+
+	time.Sleep(ttl + 20*time.Millisecond)
+	assert.Empty(t, c.Items())
+	meta, err = c.Load("guild-ttl")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-b", meta.GetDispatcher().GetNodeId())
+}
+
+func TestMetaCache_Invalidate(t *testing.T) {
+	_, cli := newRedisStub(t)
+	a, b := NewMetaCache(cli), NewMetaCache(cli)
+	assert.NoError(t, a.Start())
+	assert.NoError(t, b.Start())
+	assert.NoError(t, b.Start())
+	defer a.Stop()
+	defer b.Stop()
+
+	_, err := a.Store("guild-1", NewMeta("guild-1", "guild", "1", &Dispatcher{NodeId: "node-a"}))
+	assert.NoError(t, err)
+	meta, err := b.Load("guild-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", meta.GetDispatcher().GetNodeId())
+
+	_, err = a.Store("guild-1", NewMeta("guild-1", "guild", "1", &Dispatcher{NodeId: "node-c"}))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		_, ok := b.Get("guild-1")
+		return !ok
+	}, time.Second, 10*time.Millisecond, "other nodes should drop their local copy")
+	meta, err = b.Load("guild-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-c", meta.GetDispatcher().GetNodeId())
+	_, ok := a.Get("guild-1")
+	assert.True(t, ok, "the writer keeps its own copy")
+
+	assert.NoError(t, a.Del("guild-1"))
+	assert.Eventually(t, func() bool {
+		_, ok := b.Get("guild-1")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMetaCache_CompareAndSwap(t *testing.T) {
+	_, cli := newRedisStub(t)
+	a, b := NewMetaCache(cli), NewMetaCache(cli)
+	nodeA := &Dispatcher{NodeId: "node-a"}
+	nodeB := &Dispatcher{NodeId: "node-b"}
+
+	// 不存在的记录视为 Dispatcher 为nil
+	_, err := a.CompareAndSwap("guild-1", nil, NewMeta("guild-1", "guild", "1", nodeA))
+	assert.NoError(t, err)
+	current, err := b.CompareAndSwap("guild-1", nil, NewMeta("guild-1", "guild", "1", nodeB))
+	assert.True(t, errors.Is(err, ErrMetaConflict))
+	assert.Equal(t, "node-a", current.GetDispatcher().GetNodeId())
+
+	_, err = b.CompareAndSwap("guild-1", nodeA, NewMeta("guild-1", "guild", "1", nodeB))
+	assert.NoError(t, err)
+	// a 的本地缓存已过时，冲突后更新为当前记录
+	current, err = a.CompareAndSwap("guild-1", nodeA, NewMeta("guild-1", "guild", "1", &Dispatcher{NodeId: "node-c"}))
+	assert.True(t, errors.Is(err, ErrMetaConflict))
+	assert.Equal(t, "node-b", current.GetDispatcher().GetNodeId())
+	meta, _ := a.Get("guild-1")
+	assert.Equal(t, "node-b", meta.GetDispatcher().GetNodeId())
+}
+
+func TestMetaCache_LoadMany(t *testing.T) {
+	stub, cli := newRedisStub(t)
+	c := NewMetaCache(cli)
+
+	_, err := c.Store("guild-1", NewMeta("guild-1", "guild", "1", nil))
+	assert.NoError(t, err)
+	content, _ := proto.Marshal(NewMeta("guild-2", "guild", "2", nil))
+	stub.Set(genRedisKey("guild-2"), string(content), 0)
+
+	metas, err := c.LoadMany([]string{"guild-1", "guild-2", "guild-3", "guild-2"})
+	assert.NoError(t, err)
+	assert.Len(t, metas, 2)
+	assert.Equal(t, "1", metas["guild-1"].GetServerId())
+	assert.Equal(t, "2", metas["guild-2"].GetServerId())
+	_, ok := c.Get("guild-2")
+	assert.True(t, ok, "loaded metas should be cached")
+}
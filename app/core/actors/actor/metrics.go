@@ -0,0 +1,84 @@
+package actor
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// 消息类型标签
+const (
+	MetricMsgRequest = "request"
+	MetricMsgSend    = "send"
+	MetricMsgForward = "forward"
+	MetricMsgTimer   = "timer"
+)
+
+// Metrics Actor运行时指标，通过 WithMetrics 为 ActorSystem 设置，默认不记录
+// 在Actor和supervisor的协程中同步调用，实现需要并发安全且不能阻塞
+type Metrics interface {
+	// MessageHandled 处理完一条消息，message 为消息名称(见 MessageLabel)，latency 为业务处理函数的耗时
+	MessageHandled(pattern, msgType, message string, latency time.Duration)
+	// ActorStarted Actor初始化完成，latency 为supervisor收到 StartActorRequest 到 ChildStartedNotification 的耗时
+	ActorStarted(pattern string, latency time.Duration)
+	// ActorInitFailed Actor初始化(包括重启后的重新初始化)失败
+	ActorInitFailed(pattern string)
+	// ActorStopped Actor停止，包括主动停止、闲置停止和崩溃后被停止
+	ActorStopped(pattern string)
+	// ActorPassivated Actor超过保活时间没有消息，被闲置停止
+	ActorPassivated(pattern string)
+	// SlowHandler 消息处理时间超过 WatchdogPolicy.Threshold，每条消息记录一次
+	SlowHandler(pattern, msgType string)
+	// CircuitTripped 慢处理触发熔断，期间拒绝新的Request
+	CircuitTripped(pattern string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) MessageHandled(string, string, string, time.Duration) {}
+func (nopMetrics) ActorStarted(string, time.Duration)                   {}
+func (nopMetrics) ActorInitFailed(string)                               {}
+func (nopMetrics) ActorStopped(string)                                  {}
+func (nopMetrics) ActorPassivated(string)                               {}
+func (nopMetrics) SlowHandler(string, string)                           {}
+func (nopMetrics) CircuitTripped(string)                                {}
+
+// WithMetrics 设置ActorSystem的指标实现，例如 NewPrometheusMetrics
+func WithMetrics(metrics Metrics) SystemOption {
+	return func(af *ActorSystem) {
+		af.metrics = metrics
+	}
+}
+
+// Metrics 返回ActorSystem的指标实现，未设置时返回不记录的实现
+func (af *ActorSystem) Metrics() Metrics {
+	if af == nil || af.metrics == nil {
+		return nopMetrics{}
+	}
+	return af.metrics
+}
+
+func msgTypeLabel(msgType int8) string {
+	switch msgType {
+	case MessageTypeRequest:
+		return MetricMsgRequest
+	case MessageTypeForward:
+		return MetricMsgForward
+	}
+	return MetricMsgSend
+}
+
+// MessageLabel 返回消息名称标签，protobuf消息使用 FullName，其他消息使用Go类型名
+// 只取决于消息类型，不包含消息内容，标签的取值数量不超过消息类型的数量
+func MessageLabel(msg any) string {
+	if pm, ok := msg.(proto.Message); ok {
+		return string(pm.ProtoReflect().Descriptor().FullName())
+	}
+	return fmt.Sprintf("%T", msg)
+}
+
+// observeHandled 记录消息的处理耗时，以 defer state.observeHandled(msgType, msg, time.Now()) 的形式调用
+func (state *ChildActor) observeHandled(msgType string, msg any, start time.Time) {
+	state.GetSystem().Metrics().MessageHandled(state.pattern, msgType, MessageLabel(msg), time.Since(start))
+}
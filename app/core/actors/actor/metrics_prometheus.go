@@ -0,0 +1,137 @@
+package actor
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusMetrics 以Prometheus文本格式导出的 Metrics 实现
+// 指标注册在独立的 Registry 中，多个ActorSystem各自导出，也可以通过 Registry 合并到已有的采集
+type PrometheusMetrics struct {
+	registry       *prometheus.Registry
+	messages       *prometheus.CounterVec
+	handlerLatency *prometheus.HistogramVec
+	starts         *prometheus.CounterVec
+	startLatency   *prometheus.HistogramVec
+	initFailures   *prometheus.CounterVec
+	stops          *prometheus.CounterVec
+	passivations   *prometheus.CounterVec
+	slowHandlers   *prometheus.CounterVec
+	circuitTrips   *prometheus.CounterVec
+}
+
+type PrometheusOption func(o *prometheusOptions)
+
+type prometheusOptions struct {
+	namespace      string
+	constLabels    prometheus.Labels
+	handlerBuckets []float64
+	startBuckets   []float64
+}
+
+// WithPrometheusNamespace 设置指标名称的前缀，默认为 orbit
+func WithPrometheusNamespace(namespace string) PrometheusOption {
+	return func(o *prometheusOptions) {
+		o.namespace = namespace
+	}
+}
+
+// WithPrometheusConstLabels 为所有指标附加固定的标签，例如节点ID
+func WithPrometheusConstLabels(labels map[string]string) PrometheusOption {
+	return func(o *prometheusOptions) {
+		o.constLabels = labels
+	}
+}
+
+// WithPrometheusBuckets 设置处理耗时和启动耗时直方图的分桶(秒)
+func WithPrometheusBuckets(handler, start []float64) PrometheusOption {
+	return func(o *prometheusOptions) {
+		if len(handler) > 0 {
+			o.handlerBuckets = handler
+		}
+		if len(start) > 0 {
+			o.startBuckets = start
+		}
+	}
+}
+
+func NewPrometheusMetrics(ops ...PrometheusOption) *PrometheusMetrics {
+	o := &prometheusOptions{
+		namespace:      "orbit",
+		handlerBuckets: []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1},
+		startBuckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10},
+	}
+	for _, op := range ops {
+		op(o)
+	}
+
+	counter := func(name, help string, labels ...string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace, Subsystem: "actor", Name: name, Help: help, ConstLabels: o.constLabels,
+		}, labels)
+	}
+	histogram := func(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace, Subsystem: "actor", Name: name, Help: help, ConstLabels: o.constLabels, Buckets: buckets,
+		}, labels)
+	}
+
+	m := &PrometheusMetrics{
+		registry:       prometheus.NewRegistry(),
+		messages:       counter("messages_handled_total", "Messages handled by actors.", "pattern", "type"),
+		handlerLatency: histogram("handler_duration_seconds", "Actor message handler latency.", o.handlerBuckets, "pattern", "type", "message"),
+		starts:         counter("starts_total", "Actors started successfully.", "pattern"),
+		startLatency:   histogram("start_duration_seconds", "Latency from start request to actor initialized.", o.startBuckets, "pattern"),
+		initFailures:   counter("init_failures_total", "Actor initializations that failed.", "pattern"),
+		stops:          counter("stops_total", "Actors stopped.", "pattern"),
+		passivations:   counter("passivations_total", "Actors stopped after being idle.", "pattern"),
+		slowHandlers:   counter("slow_handlers_total", "Messages handled slower than the watchdog threshold.", "pattern", "type"),
+		circuitTrips:   counter("circuit_trips_total", "Actor circuits opened by slow handlers.", "pattern"),
+	}
+	m.registry.MustRegister(m.messages, m.handlerLatency, m.starts, m.startLatency, m.initFailures, m.stops, m.passivations,
+		m.slowHandlers, m.circuitTrips)
+	return m
+}
+
+// Registry 返回指标所在的 Registry
+func (m *PrometheusMetrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler 返回以Prometheus文本格式导出指标的 http.Handler
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *PrometheusMetrics) MessageHandled(pattern, msgType, message string, latency time.Duration) {
+	m.messages.WithLabelValues(pattern, msgType).Inc()
+	m.handlerLatency.WithLabelValues(pattern, msgType, message).Observe(latency.Seconds())
+}
+
+func (m *PrometheusMetrics) ActorStarted(pattern string, latency time.Duration) {
+	m.starts.WithLabelValues(pattern).Inc()
+	m.startLatency.WithLabelValues(pattern).Observe(latency.Seconds())
+}
+
+func (m *PrometheusMetrics) ActorInitFailed(pattern string) {
+	m.initFailures.WithLabelValues(pattern).Inc()
+}
+
+func (m *PrometheusMetrics) ActorStopped(pattern string) {
+	m.stops.WithLabelValues(pattern).Inc()
+}
+
+func (m *PrometheusMetrics) ActorPassivated(pattern string) {
+	m.passivations.WithLabelValues(pattern).Inc()
+}
+
+func (m *PrometheusMetrics) SlowHandler(pattern, msgType string) {
+	m.slowHandlers.WithLabelValues(pattern, msgType).Inc()
+}
+
+func (m *PrometheusMetrics) CircuitTripped(pattern string) {
+	m.circuitTrips.WithLabelValues(pattern).Inc()
+}
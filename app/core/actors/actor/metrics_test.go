@@ -0,0 +1,84 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// failInitBehavior 初始化总是失败
+type failInitBehavior struct {
+	MockBehavior
+}
+
+var errMetricsInit = errors.New("metrics init failed")
+
+func (b *failInitBehavior) HandleInit(ctx IContext) error {
+	return errMetricsInit
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics(WithPrometheusConstLabels(map[string]string{"node": "test"}))
+	factories := NewFactoryRegistry()
+	factories.Reg("metrics-ok", MockBehaviorFactory)
+	factories.Reg("metrics-fail", func(actorName string) Behavior { return &failInitBehavior{} })
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories), WithMetrics(metrics))
+	defer af.Stop(context.Background())
+
+	clock := NewMockClock(time.Now())
+	ref := af.NewActorRef(NewProps(), "metrics-actor", "metrics-ok", WithClock(clock), WithAliveTimeout(time.Minute))
+	for i := 0; i < 2; i++ {
+		_, err := ref.RequestFuture("ping")
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, ref.Send("ping"))
+
+	_, err := af.GetOrStartActor("metrics-fail-actor", "metrics-fail", NewProps())
+	assert.True(t, errors.Is(err, errMetricsInit))
+
+	// 推进时钟超过保活时间，Actor被闲置停止
+	assert.Eventually(t, func() bool { return len(clock.Pending()) > 0 }, time.Second, 10*time.Millisecond)
+	clock.Advance(2 * time.Minute)
+	assert.Eventually(t, func() bool { return !af.Actors().Exist("metrics-actor") }, 2*time.Second, 10*time.Millisecond)
+
+	server := httptest.NewServer(metrics.Handler())
+	defer server.Close()
+	scrape := func() string {
+		resp, err := server.Client().Get(server.URL)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	assert.Eventually(t, func() bool {
+		return strings.Contains(scrape(), `orbit_actor_stops_total{node="test",pattern="metrics-fail"} 1`)
+	}, 2*time.Second, 10*time.Millisecond)
+	text := scrape()
+	for _, line := range []string{
+		`orbit_actor_messages_handled_total{node="test",pattern="metrics-ok",type="request"} 2`,
+		`orbit_actor_messages_handled_total{node="test",pattern="metrics-ok",type="send"} 1`,
+		`orbit_actor_handler_duration_seconds_count{message="string",node="test",pattern="metrics-ok",type="request"} 2`,
+		`orbit_actor_starts_total{node="test",pattern="metrics-ok"} 1`,
+		`orbit_actor_start_duration_seconds_count{node="test",pattern="metrics-ok"} 1`,
+		`orbit_actor_init_failures_total{node="test",pattern="metrics-fail"} 1`,
+		`orbit_actor_passivations_total{node="test",pattern="metrics-ok"} 1`,
+		`orbit_actor_stops_total{node="test",pattern="metrics-ok"} 1`,
+	} {
+		assert.Contains(t, text, line)
+	}
+	assert.NotContains(t, text, `orbit_actor_starts_total{node="test",pattern="metrics-fail"}`)
+}
+
+func TestMessageLabel(t *testing.T) {
+	assert.Equal(t, "string", MessageLabel("ping"))
+	assert.Equal(t, "*actor.failInitBehavior", MessageLabel(&failInitBehavior{}))
+	assert.Equal(t, "actor.Meta", MessageLabel(&Meta{}))
+}
@@ -0,0 +1,287 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/asynkron/protoactor-go/actor"
+	"go.uber.org/zap"
+)
+
+/*
+	Actor在线迁移，用于节点下线前将Actor平滑地转移到其他节点:
+
+	1. 冻结: 源节点Actor停止处理业务消息，之后收到的消息全部暂存，调用 Migratable.MigrateOut 导出状态
+	2. 激活: 目标节点以导出的状态激活Actor，Migratable.MigrateIn 先于 HandleInit 执行
+	3. 记录: 源节点记录迁移去向，更新 Meta.Dispatcher.NodeId(放置服务)，之后发往该Actor的消息由 ActorRef 转发到目标节点
+	4. 提交: 源节点Actor将暂存的消息及停止前收到的消息按顺序转发到目标节点，然后停止
+
+	启用集群归属登记时，源节点在冻结后将租约移交给目标节点，目标节点激活前以新的token接管租约
+	冻结或激活失败时回滚：源节点收回租约，Actor解冻并按顺序处理暂存的消息，目标节点上可能已经激活的Actor被停止
+*/
+
+// Migratable 支持在线迁移的 Behavior 需要实现此接口
+type Migratable interface {
+	// MigrateOut 在源节点冻结Actor后调用，返回需要迁移到目标节点的状态
+	MigrateOut(ctx IContext) ([]byte, error)
+	// MigrateIn 在目标节点激活Actor时调用，先于 HandleInit 执行，HandleInit 中可以据此跳过从存储加载
+	MigrateIn(ctx IContext, state []byte) error
+}
+
+// 迁移过程中发送给子Actor的内部消息，不会被暂存
+type (
+	migrateFreeze   struct{}
+	migrateSnapshot struct {
+		state []byte
+	}
+	migrateRollback struct{}
+	migrateCommit   struct {
+		nodeId string
+	}
+)
+
+func (*migrateFreeze) internalMessage()   {}
+func (*migrateRollback) internalMessage() {}
+func (*migrateCommit) internalMessage()   {}
+
+// migrationStatus 子Actor的迁移状态
+type migrationStatus struct {
+	frozen bool   // 迁移中，业务消息全部暂存
+	to     string // 已迁移到的节点，停止时将暂存的消息转发到该节点
+	state  []byte // 迁入的状态，在 HandleInit 之前恢复
+}
+
+// Migrate 将本节点上已激活的Actor迁移到目标节点
+// 迁移失败时Actor回滚到本节点继续运行，返回失败原因
+func (af *ActorSystem) Migrate(actorName, pattern, nodeId string, timeout ...time.Duration) error {
+	if af.remote == nil {
+		return ErrRemoteNotStarted
+	}
+	if nodeId == af.remote.NodeId() {
+		return nil
+	}
+	p, exists := af.actors.Get(actorName)
+	if !exists || p.IsStopped() {
+		return ErrActorNotFound
+	}
+	d := parseTimeout(timeout...)
+
+	// 冻结并导出状态，失败时Actor已经自行解冻
+	re, err := waitFuture(af.actorSystem.Root.RequestFuture(p.PID, &migrateFreeze{}, d))
+	if err != nil {
+		return err
+	}
+	snapshot := re.(*migrateSnapshot)
+
+	// 迁移完成前发往该Actor的消息都投递到本地，由冻结的Actor暂存
+	af.migrating.Store(actorName, nodeId)
+	defer af.migrating.Delete(actorName)
+
+	// Actor通过集群归属登记激活时，先将租约移交给目标节点
+	leased := false
+	if af.ownership != nil {
+		if _, leased = af.ownership.Lease(actorName); leased {
+			err = af.ownership.migrated(actorName, nodeId)
+		}
+	}
+	if err == nil {
+		err = af.remote.Migrate(nodeId, actorName, pattern, snapshot.state, d)
+		// 等待超时时目标节点可能已经激活Actor
+		if errors.Is(err, actor.ErrTimeout) {
+			_ = af.remote.StopActor(nodeId, actorName, pattern)
+		}
+	}
+	if err != nil {
+		af.rollbackMigration(p, nodeId, leased)
+		logger.GetLogger().Error("[Migrate] migrate actor failed, rollback",
+			zap.String("ActorName", actorName),
+			zap.String("NodeId", nodeId),
+			zap.Error(err))
+		return err
+	}
+
+	af.recordMigration(actorName, pattern, nodeId)
+	af.actorSystem.Root.Send(p.PID, &migrateCommit{nodeId: nodeId})
+	logger.GetLogger().Info("[Migrate] actor migrated",
+		zap.String("ActorName", actorName),
+		zap.String("NodeId", nodeId))
+	return nil
+}
+
+// migrationRecord 迁出记录，超过 MigrationRecordTTL 后失效
+// 此时目标节点上的Actor通常已经因空闲停止，之后按放置服务或归属登记重新确定所在节点
+type migrationRecord struct {
+	nodeId   string
+	expireAt time.Time
+}
+
+// migratedTo 返回Actor从本节点迁出后所在的节点，记录已过期时删除并返回空
+func (af *ActorSystem) migratedTo(actorName string) string {
+	v, ok := af.migrations.Load(actorName)
+	if !ok {
+		return ""
+	}
+	record := v.(*migrationRecord)
+	if time.Now().After(record.expireAt) {
+		af.migrations.CompareAndDelete(actorName, v)
+		return ""
+	}
+	return record.nodeId
+}
+
+// recordMigration 记录迁移去向，之后发往该Actor的消息转发到目标节点
+// 同时清理已过期或目标节点已经离开 Discovery 的记录，避免记录无限增长
+func (af *ActorSystem) recordMigration(actorName, pattern, nodeId string) {
+	af.pruneMigrations()
+	af.migrations.Store(actorName, &migrationRecord{
+		nodeId:   nodeId,
+		expireAt: time.Now().Add(MigrationRecordTTL),
+	})
+	if af.placement != nil {
+		if err := af.placement.relocate(actorName, pattern, nodeId); err != nil {
+			logger.GetLogger().Error("[Migrate] record placement failed", zap.String("ActorName", actorName), zap.Error(err))
+		}
+	}
+}
+
+func (af *ActorSystem) pruneMigrations() {
+	now := time.Now()
+	af.migrations.Range(func(key, value any) bool {
+		record := value.(*migrationRecord)
+		if now.After(record.expireAt) {
+			af.migrations.CompareAndDelete(key, value)
+		} else if _, ok := af.remote.Discovery().Lookup(record.nodeId); !ok {
+			af.migrations.CompareAndDelete(key, value)
+		}
+		return true
+	})
+}
+
+// forgetMigration 转发到迁移的目标节点失败时删除迁出记录：目标节点已经离开 Discovery，或Actor已不在目标节点
+// 连接暂时不可用等其他错误保留记录
+func (af *ActorSystem) forgetMigration(actorName, nodeId string, err error) {
+	if !errors.Is(err, ErrNodeNotFound) && !errors.Is(err, ErrActorNotFound) && !errors.Is(err, ErrActorStopped) {
+		return
+	}
+	if v, ok := af.migrations.Load(actorName); ok && v.(*migrationRecord).nodeId == nodeId {
+		af.migrations.CompareAndDelete(actorName, v)
+	}
+}
+
+// rollbackMigration 迁移失败，收回租约后解冻本地Actor
+// 租约已被其他节点取得时停止本地Actor，避免出现两个激活
+func (af *ActorSystem) rollbackMigration(p *Process, nodeId string, leased bool) {
+	if leased {
+		if err := af.ownership.reclaim(context.Background(), p.ActorName, p.Pattern, nodeId); err != nil {
+			logger.GetLogger().Error("[Migrate] reclaim lease failed, stop actor", zap.String("ActorName", p.ActorName), zap.Error(err))
+			af.actorSystem.Root.Send(p.PID, &migrateRollback{})
+			_ = af.StopActor(p.ActorName, p.Pattern)
+			return
+		}
+	}
+	af.actorSystem.Root.Send(p.PID, &migrateRollback{})
+}
+
+// activateMigrated 在目标节点上以迁移的状态激活Actor
+func (af *ActorSystem) activateMigrated(actorName, pattern string, state []byte) error {
+	if af.actors.Exist(actorName) {
+		return ErrActorAlreadyActive
+	}
+	if af.ownership != nil {
+		lease, err := af.ownership.Acquire(context.Background(), actorName, pattern)
+		if err != nil {
+			return err
+		}
+		if lease.NodeId != af.ownership.NodeId() {
+			return ErrLeaseNotHeld
+		}
+	}
+	// Actor迁回本节点
+	af.migrations.Delete(actorName)
+
+	_, err := af.getOrStartActor(&StartActorRequest{
+		ActorName:      actorName,
+		Pattern:        pattern,
+		Props:          NewProps(),
+		MigrationState: state,
+	}, StartActorTimeout, ManagerStartActorFutureTimeout)
+	return err
+}
+
+// handleMigrateFreeze 冻结Actor并导出状态
+func (state *ChildActor) handleMigrateFreeze(context actor.Context) {
+	m, ok := state.Behavior.(Migratable)
+	if !ok {
+		context.Respond(ErrNotMigratable)
+		return
+	}
+
+	state.migration.frozen = true
+	data, err := m.MigrateOut(state)
+	if err != nil {
+		state.unfreeze()
+		context.Respond(err)
+		return
+	}
+	context.Respond(&migrateSnapshot{state: data})
+}
+
+func (state *ChildActor) unfreeze() {
+	state.migration.frozen = false
+	state.UnstashAll()
+}
+
+// handleMigrateCommit 迁移完成，转发暂存的消息后停止
+// 停止前收到的消息继续暂存，在 Stopping 时转发
+func (state *ChildActor) handleMigrateCommit(context actor.Context, msg *migrateCommit) {
+	state.migration.to = msg.nodeId
+	state.forwardStash()
+	context.Send(context.Parent(), &PoisonActorMessage{
+		ActorName: state.actorName,
+		Pattern:   state.pattern,
+	})
+}
+
+// forwardStash 将暂存的消息按顺序转发到迁移的目标节点
+// 暂存的定时器消息被丢弃，目标节点在 HandleInit 中重新创建定时器
+func (state *ChildActor) forwardStash() {
+	stashed := state.stash.messages
+	state.stash.messages = nil
+	nodeId, root, system := state.migration.to, state.context.ActorSystem().Root, state.GetSystem()
+	for _, env := range stashed {
+		msg, ok := env.Message.(*RequestMessage)
+		if !ok || msg.ActorName == "" {
+			continue
+		}
+
+		// 所有消息在当前协程中依次发出，Request只在其他协程中等待回复，目标节点按发送顺序投递
+		var err error
+		switch msg.MsgType {
+		case MessageTypeRequest:
+			sender := env.Sender
+			err = system.remote.forwardRequest(nodeId, msg, func(re any, err error) {
+				if sender == nil {
+					return
+				}
+				if err != nil {
+					root.Send(sender, err)
+				} else {
+					root.Send(sender, re)
+				}
+			})
+		default:
+			err = system.remote.Send(nodeId, msg)
+		}
+		if err != nil {
+			system.DeadLetters().Publish(&DeadLetter{
+				ActorName: state.actorName,
+				Pattern:   state.pattern,
+				Message:   msg,
+				Sender:    env.Sender,
+				Reason:    err,
+			})
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package actor
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/proto/pb/pb_core"
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	migrateTestPattern      = "migrate-counter-pattern"
+	migrateEchoTestPattern  = "migrate-echo-pattern"
+	migrateOrderTestPattern = "migrate-order-pattern"
+)
+
+// CounterBehavior 可迁移的计数器，状态为 "fail" 时迁入失败
+type CounterBehavior struct {
+	count int
+}
+
+func (b *CounterBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if msg.(*pb_core.Request_SearchBook).Query == "inc" {
+		b.count++
+	}
+	return bookRsp(strconv.Itoa(b.count)), nil
+}
+
+func (b *CounterBehavior) HandleSend(ctx IContext, msg any) {
+	if msg.(*pb_core.Request_SearchBook).Query == "inc" {
+		b.count++
+	}
+}
+
+func (b *CounterBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *CounterBehavior) HandleInit(ctx IContext) error {
+	return nil
+}
+
+func (b *CounterBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *CounterBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func (b *CounterBehavior) MigrateOut(ctx IContext) ([]byte, error) {
+	if ctx.GetActorName() == "migrate-rollback-actor" {
+		return []byte("fail"), nil
+	}
+	return []byte(strconv.Itoa(b.count)), nil
+}
+
+func (b *CounterBehavior) MigrateIn(ctx IContext, state []byte) error {
+	if string(state) == "fail" {
+		return errors.New("restore state failed")
+	}
+	count, err := strconv.Atoi(string(state))
+	b.count = count
+	return err
+}
+
+// OrderBehavior 按处理顺序记录收到的Request和Send，MigrateOut 阻塞直到测试放行，期间收到的消息被暂存
+// 迁移的状态为已经记录的消息
+type OrderBehavior struct {
+	received []string
+}
+
+var (
+	migrateOrderFrozen = make(chan struct{}, 1)
+	migrateOrderResume = make(chan struct{})
+)
+
+func (b *OrderBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	query := msg.(*pb_core.Request_SearchBook).Query
+	if query != "order" {
+		b.received = append(b.received, query)
+	}
+	return bookRsp(strings.Join(b.received, ",")), nil
+}
+
+func (b *OrderBehavior) HandleSend(ctx IContext, msg any) {
+	b.received = append(b.received, msg.(*pb_core.Request_SearchBook).Query)
+}
+
+func (b *OrderBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *OrderBehavior) HandleInit(ctx IContext) error {
+	return nil
+}
+
+func (b *OrderBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *OrderBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func (b *OrderBehavior) MigrateOut(ctx IContext) ([]byte, error) {
+	migrateOrderFrozen <- struct{}{}
+	<-migrateOrderResume
+	return []byte(strings.Join(b.received, ",")), nil
+}
+
+func (b *OrderBehavior) MigrateIn(ctx IContext, state []byte) error {
+	b.received = strings.Split(string(state), ",")
+	return nil
+}
+
+func counterValue(t *testing.T, ref *ActorRef) string {
+	re, err := ref.RequestFuture(&pb_core.Request_SearchBook{}, 5*time.Second)
+	assert.NoError(t, err)
+	if err != nil {
+		return ""
+	}
+	return bookContent(re)
+}
+
+// testMigrate 由 TestRemote_TwoProcesses 调用，nodeB 上注册了 CounterBehavior
+func testMigrate(t *testing.T) {
+	RegFactory(migrateTestPattern, func(actorName string) Behavior {
+		return &CounterBehavior{}
+	})
+	RegFactory(migrateEchoTestPattern, func(actorName string) Behavior {
+		return &EchoBehavior{}
+	})
+	RegFactory(migrateOrderTestPattern, func(actorName string) Behavior {
+		return &OrderBehavior{}
+	})
+
+	// 不支持迁移的Actor
+	echo := NewActorRef(NewProps(), "migrate-echo-actor", migrateEchoTestPattern)
+	_, err := echo.RequestFuture(&pb_core.Request_SearchBook{})
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(System.Migrate("migrate-echo-actor", migrateEchoTestPattern, "node-b"), ErrNotMigratable))
+
+	// 迁移期间发送的消息不丢失、不重复
+	ref := NewActorRef(NewProps(), "migrate-counter-actor", migrateTestPattern)
+	for i := 0; i < 3; i++ {
+		_, err = ref.RequestFuture(&pb_core.Request_SearchBook{Query: "inc"})
+		assert.NoError(t, err)
+	}
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for i := 0; i < 50; i++ {
+			assert.NoError(t, ref.Send(&pb_core.Request_SearchBook{Query: "inc"}))
+		}
+	}()
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, System.Migrate("migrate-counter-actor", migrateTestPattern, "node-b", 5*time.Second))
+	<-sent
+	assert.Equal(t, "53", counterValue(t, ref))
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist("migrate-counter-actor")
+	}, time.Second, 10*time.Millisecond, "actor should be stopped on the source node")
+	assert.Equal(t, "node-b", System.migratedTo("migrate-counter-actor"))
+
+	// 目标节点恢复状态失败时回滚到源节点
+	rollback := NewActorRef(NewProps(), "migrate-rollback-actor", migrateTestPattern)
+	_, err = rollback.RequestFuture(&pb_core.Request_SearchBook{Query: "inc"})
+	assert.NoError(t, err)
+	assert.Error(t, System.Migrate("migrate-rollback-actor", migrateTestPattern, "node-b", 5*time.Second))
+	assert.NoError(t, rollback.Send(&pb_core.Request_SearchBook{Query: "inc"}))
+	assert.Equal(t, "2", counterValue(t, rollback))
+	assert.True(t, System.actors.Exist("migrate-rollback-actor"))
+	assert.Equal(t, "", System.migratedTo("migrate-rollback-actor"))
+
+	// 目标节点已经离开 Discovery 的迁出记录在下一次迁移时被清理
+	System.migrations.Store("migrate-gone-actor", &migrationRecord{nodeId: "node-x", expireAt: time.Now().Add(time.Minute)})
+
+	// 冻结期间交替收到的Request和Send按暂存顺序转发，目标节点按同样的顺序处理
+	order := NewActorRef(NewProps(), "migrate-order-actor", migrateOrderTestPattern)
+	_, err = order.RequestFuture(&pb_core.Request_SearchBook{Query: "r0"})
+	assert.NoError(t, err)
+	migrated := make(chan error, 1)
+	go func() {
+		migrated <- System.Migrate("migrate-order-actor", migrateOrderTestPattern, "node-b", 5*time.Second)
+	}()
+	<-migrateOrderFrozen
+	p, _ := System.actors.Get("migrate-order-actor")
+	var futures []*actor.Future
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, order.Send(&pb_core.Request_SearchBook{Query: "s" + strconv.Itoa(i)}))
+		futures = append(futures, System.actorSystem.Root.RequestFuture(p.PID, &RequestMessage{
+			MsgType:   MessageTypeRequest,
+			Message:   &pb_core.Request_SearchBook{Query: "r" + strconv.Itoa(i)},
+			ActorName: "migrate-order-actor",
+			Pattern:   migrateOrderTestPattern,
+		}, 5*time.Second))
+	}
+	close(migrateOrderResume)
+	assert.NoError(t, <-migrated)
+	for i, future := range futures {
+		re, err := requestResult(future.Result())
+		assert.NoError(t, err)
+		if err == nil {
+			assert.True(t, strings.HasSuffix(bookContent(re), "r"+strconv.Itoa(i+1)), "reply should be routed back to the original sender")
+		}
+	}
+	re, err := order.RequestFuture(&pb_core.Request_SearchBook{Query: "order"}, 5*time.Second)
+	assert.NoError(t, err)
+	if err == nil {
+		assert.Equal(t, "r0,s1,r1,s2,r2,s3,r3", bookContent(re))
+	}
+	assert.Equal(t, "", System.migratedTo("migrate-gone-actor"))
+}
+
+func TestActorSystem_MigrationRecord(t *testing.T) {
+	af := &ActorSystem{}
+
+	// 过期的记录不再转发
+	af.migrations.Store("expired", &migrationRecord{nodeId: "node-b", expireAt: time.Now().Add(-time.Second)})
+	assert.Equal(t, "", af.migratedTo("expired"))
+	_, exists := af.migrations.Load("expired")
+	assert.False(t, exists)
+
+	// 只有目标节点离开或Actor已不在目标节点时删除记录
+	af.migrations.Store("moved", &migrationRecord{nodeId: "node-b", expireAt: time.Now().Add(time.Minute)})
+	af.forgetMigration("moved", "node-b", ErrRemoteUnavailable)
+	af.forgetMigration("moved", "node-c", ErrActorNotFound)
+	assert.Equal(t, "node-b", af.migratedTo("moved"))
+	af.forgetMigration("moved", "node-b", ErrNodeNotFound)
+	assert.Equal(t, "", af.migratedTo("moved"))
+
+	af.migrations.Store("moved", &migrationRecord{nodeId: "node-b", expireAt: time.Now().Add(time.Minute)})
+	af.forgetMigration("moved", "node-b", ErrActorStopped)
+	assert.Equal(t, "", af.migratedTo("moved"))
+}
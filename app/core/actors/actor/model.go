@@ -1,8 +1,10 @@
 package actor
 
 import (
+	"context"
 	"time"
 
+	"gitee.com/orbit-w/orbit/lib/tracing"
 	"github.com/asynkron/protoactor-go/actor"
 )
 
@@ -41,11 +43,12 @@ type ChildStartedNotification struct {
 
 // Message types for ActorManager
 type StartActorRequest struct {
-	Pattern   string
-	ActorName string
-	Timeout   time.Duration
-	Future    *actor.PID
-	Props     *Props
+	Pattern        string
+	ActorName      string
+	Timeout        time.Duration
+	Future         *actor.PID
+	Props          *Props
+	MigrationState []byte // 迁入的Actor状态，只用于本次激活创建的Actor实例，崩溃重启时不再恢复
 }
 
 type StartActorWait struct {
@@ -60,11 +63,20 @@ type StopAllRequest struct {
 }
 
 type StopAllResponse struct {
-	Complete bool
+	Complete  bool
+	Remaining []string // 尚未停止的Actor，包括正在启动、停止和等待重启的Actor
 }
 
 type TimerMessage struct{}
 
+func (*TimerMessage) internalMessage() {}
+
+// timerFired 被暂存的到期定时器消息，重新处理时按定时器消息分发
+type timerFired struct {
+	key string
+	msg any
+}
+
 var (
 	startActorWaitMessage = &StartActorWait{}
 	checkAliveMessage     = &CheckAliveMessage{}
@@ -81,9 +93,30 @@ const (
 	MessageTypeForward
 )
 
+// 业务消息优先级，高优先级消息在邮箱中优先于普通消息处理
+const (
+	PriorityNormal int8 = iota
+	PriorityHigh
+)
+
 type RequestMessage struct {
-	MsgType int8
-	Message any
+	MsgType    int8
+	Priority   int8
+	Message    any
+	ActorName  string              // 目标Actor名称
+	Pattern    string              // 目标Actor类型
+	Redelivery int                 // 成为死信后剩余的重投次数
+	Trace      tracing.SpanContext // 发送方所在的Span，接收方以此为父节点记录处理过程
+	props      *Props              // 重投时用于重新激活目标Actor
+	ctx        context.Context     // RequestFutureContext 调用方的ctx，只在本节点内传递
+	deadline   time.Time           // 调用方等待回复的截止时间
+	sender     *actor.PID          // 不经过 RequestFuture 投递的Request的回复接收方，例如跨节点按顺序转发的请求
+}
+
+// clone 复制消息，重投时修改副本，不影响已发布给订阅者的死信
+func (m *RequestMessage) clone() *RequestMessage {
+	c := *m
+	return &c
 }
 
 type CheckAliveMessage struct{}
@@ -0,0 +1,556 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/meteor/bases/misc/utils"
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+	OwnershipRegistry 集群范围的Actor归属登记，保证同一个Actor在整个集群中只有一个激活
+	缓存结构:
+	actor_owner:{actorName}       -> {nodeId}|{token}  租约，过期时间为 LeaseTTL
+	actor_owner_fence:{actorName} -> 单调递增的fencing token
+	获取、接管和移交租约由Lua脚本原子地比较持有者并递增token，续约按批次执行
+
+	1. ActorRef 第一次向未指定 Dispatcher.NodeId 的Actor发送消息时，尝试获取租约:
+	   获取成功则在本节点激活；租约属于其他节点时，经由 Remote 转发到持有者节点
+	2. 持有者节点定期续约，续约失败(租约被其他节点取得)时立即停止本地Actor
+	3. 每次获取租约都会得到一个更大的fencing token，Actor写入外部存储时应携带 token，
+	   存储端拒绝比已写入token更小的写入，避免租约过期后旧持有者的延迟写入覆盖新数据
+	4. Actor停止(被动化)后释放租约，或通过 Handover 将租约移交给指定节点
+	5. 解析得到的Actor位置缓存在 ActorMetaCache 中，本节点持有的租约同时写入 actor_meta
+*/
+
+const (
+	DefaultLeaseTTL = 15 * time.Second
+
+	ownershipAcquireRetry = 3
+	ownershipRenewBatch   = 256
+)
+
+var (
+	// swapScript KEYS: 租约, fencing token; ARGV: 期望的持有者值(为空表示没有持有者), 新的持有者节点, 有效期(ms)
+	// 持有者与期望一致时递增token并写入新的租约，返回 {1, token}；否则返回 {0, 当前持有者的值}
+	swapScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1]) or ''
+if current ~= ARGV[1] then
+	return {0, current}
+end
+local token = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[1], ARGV[2] .. '|' .. token, 'PX', ARGV[3])
+return {1, token}
+`)
+
+	// renewScript KEYS: 租约; ARGV: 有效期(ms), 每个租约期望的持有者值
+	// 持有者与期望一致的租约延长有效期，按KEYS顺序返回是否续约成功
+	renewScript = redis.NewScript(`
+local renewed = {}
+for i, key in ipairs(KEYS) do
+	if redis.call('GET', key) == ARGV[i + 1] then
+		redis.call('PEXPIRE', key, ARGV[1])
+		renewed[i] = 1
+	else
+		renewed[i] = 0
+	end
+end
+return renewed
+`)
+)
+
+// Lease Actor归属租约
+type Lease struct {
+	ActorName string
+	Pattern   string
+	NodeId    string
+	Token     int64 // fencing token
+
+	renewedAt  time.Time
+	handoverTo string
+}
+
+type OwnershipOption func(r *OwnershipRegistry)
+
+// WithLeaseTTL 设置租约有效期，持有者每 TTL/3 续约一次
+func WithLeaseTTL(ttl time.Duration) OwnershipOption {
+	return func(r *OwnershipRegistry) {
+		if ttl > 0 {
+			r.ttl = ttl
+		}
+	}
+}
+
+// OwnershipRegistry 基于 Redis 租约的分布式Actor归属登记
+type OwnershipRegistry struct {
+	cli    *redis.Client
+	metas  *ActorMetaCache
+	nodeId string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*Lease
+	system *ActorSystem
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewOwnershipRegistry(cli *redis.Client, metas *ActorMetaCache, nodeId string, ops ...OwnershipOption) *OwnershipRegistry {
+	r := &OwnershipRegistry{
+		cli:    cli,
+		metas:  metas,
+		nodeId: nodeId,
+		ttl:    DefaultLeaseTTL,
+		leases: make(map[string]*Lease),
+		done:   make(chan struct{}),
+	}
+	for i := range ops {
+		ops[i](r)
+	}
+	return r
+}
+
+// Start 启动续约任务
+func (r *OwnershipRegistry) Start() {
+	utils.GoRecoverPanic(r.renewLoop)
+}
+
+// Stop 停止续约并释放本节点持有的所有租约
+func (r *OwnershipRegistry) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+		r.mu.Lock()
+		leases := r.leases
+		r.leases = make(map[string]*Lease)
+		r.mu.Unlock()
+		for _, lease := range leases {
+			if err := r.release(context.Background(), lease); err != nil {
+				logger.GetLogger().Error("[Ownership] release lease failed", zap.String("ActorName", lease.ActorName), zap.Error(err))
+			}
+		}
+	})
+}
+
+func (r *OwnershipRegistry) NodeId() string {
+	return r.nodeId
+}
+
+// Lease 返回本节点持有的租约，Actor在本节点激活期间可以通过 Token 对外部写入做防护
+func (r *OwnershipRegistry) Lease(actorName string) (*Lease, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, ok := r.leases[actorName]
+	if !ok {
+		return nil, false
+	}
+	cp := *lease
+	return &cp, true
+}
+
+// Resolve 返回Actor所在的节点ID，Actor尚未在任何节点激活时由当前节点获取租约
+func (r *OwnershipRegistry) Resolve(actorName, pattern string) (string, error) {
+	r.mu.Lock()
+	_, held := r.leases[actorName]
+	r.mu.Unlock()
+	if held {
+		return r.nodeId, nil
+	}
+
+	if meta, ok := r.metas.Get(actorName); ok {
+		if nodeId := meta.GetDispatcher().GetNodeId(); nodeId != "" && nodeId != r.nodeId {
+			return nodeId, nil
+		}
+	}
+
+	lease, err := r.Acquire(context.Background(), actorName, pattern)
+	if err != nil {
+		return "", err
+	}
+	return lease.NodeId, nil
+}
+
+// Invalidate 删除缓存的Actor位置，下一次 Resolve 时重新从Redis读取
+// 向缓存的节点转发失败时调用
+func (r *OwnershipRegistry) Invalidate(actorName string) {
+	if meta, ok := r.metas.Get(actorName); ok && meta.GetDispatcher().GetNodeId() != r.nodeId {
+		r.metas.Evict(actorName)
+	}
+}
+
+// Acquire 尝试获取Actor的租约
+// 返回当前的持有者租约，lease.NodeId 不是当前节点时表示Actor已经在其他节点激活
+// 先读取持有者，只有取得租约时才递增fencing token，其他节点持有时不会消耗token
+func (r *OwnershipRegistry) Acquire(ctx context.Context, actorName, pattern string) (*Lease, error) {
+	expect := ""
+	for i := 0; i < ownershipAcquireRetry; i++ {
+		token, current, err := r.swap(ctx, actorName, expect, r.nodeId)
+		if err != nil {
+			return nil, err
+		}
+		if token > 0 {
+			lease := &Lease{ActorName: actorName, Pattern: pattern, NodeId: r.nodeId, Token: token}
+			r.hold(lease)
+			return lease, nil
+		}
+		if current == "" {
+			// 准备接管时租约恰好过期或被释放，重新竞争
+			expect = ""
+			continue
+		}
+
+		owner, err := parseLease(current)
+		if err != nil {
+			return nil, err
+		}
+		owner.ActorName = actorName
+		if owner.NodeId != r.nodeId {
+			r.cacheLocation(actorName, pattern, owner.NodeId)
+			return owner, nil
+		}
+		// 租约登记在当前节点名下但本地没有持有(节点重启或其他节点移交)，以新的token接管
+		expect = current
+	}
+	return nil, fmt.Errorf("acquire lease of %s failed: %w", actorName, ErrLeaseLost)
+}
+
+// Owner 查询Actor当前的持有者，没有任何节点持有时返回 redis.Nil
+func (r *OwnershipRegistry) Owner(ctx context.Context, actorName string) (*Lease, error) {
+	return r.owner(ctx, actorName)
+}
+
+// Release 释放本节点持有的租约
+func (r *OwnershipRegistry) Release(ctx context.Context, actorName string) error {
+	lease, ok := r.drop(actorName)
+	if !ok {
+		return ErrLeaseNotHeld
+	}
+	return r.release(ctx, lease)
+}
+
+// sys 返回登记所属的ActorSystem，单独创建的登记使用默认的 System
+func (r *OwnershipRegistry) sys() *ActorSystem {
+	if r.system != nil {
+		return r.system
+	}
+	return System
+}
+
+// Handover 将Actor移交给指定节点
+// 本地Actor停止后租约转到目标节点名下，目标节点收到第一条消息时以新的token接管并激活Actor
+// Actor停止期间如果有消息等待本地重新激活，则放弃移交
+func (r *OwnershipRegistry) Handover(actorName, nodeId string) error {
+	r.mu.Lock()
+	lease, ok := r.leases[actorName]
+	if ok {
+		lease.handoverTo = nodeId
+	}
+	r.mu.Unlock()
+	if !ok {
+		return ErrLeaseNotHeld
+	}
+
+	if !r.sys().actors.Exist(actorName) {
+		r.passivated(actorName)
+		return nil
+	}
+	return r.sys().StopActor(actorName, lease.Pattern)
+}
+
+// passivated 本地Actor停止后释放或移交租约
+// 本地租约同步删除，之后到达的消息重新竞争租约；Redis操作异步执行，不阻塞Supervisor
+func (r *OwnershipRegistry) passivated(actorName string) {
+	lease, ok := r.drop(actorName)
+	if !ok {
+		return
+	}
+	if lease.handoverTo != "" {
+		r.cacheLocation(actorName, lease.Pattern, lease.handoverTo)
+	}
+
+	utils.GoRecoverPanic(func() {
+		var err error
+		if lease.handoverTo != "" {
+			err = r.handover(context.Background(), lease)
+		} else {
+			err = r.release(context.Background(), lease)
+		}
+		if err != nil && !errors.Is(err, ErrLeaseLost) {
+			logger.GetLogger().Error("[Ownership] passivate lease failed",
+				zap.String("ActorName", actorName),
+				zap.String("HandoverTo", lease.handoverTo),
+				zap.Error(err))
+		}
+	})
+}
+
+// migrated 迁移中的Actor冻结后将租约移交给目标节点，目标节点激活前以新的token接管
+func (r *OwnershipRegistry) migrated(actorName, nodeId string) error {
+	lease, ok := r.drop(actorName)
+	if !ok {
+		return ErrLeaseNotHeld
+	}
+	lease.handoverTo = nodeId
+	if err := r.handover(context.Background(), lease); err != nil {
+		return err
+	}
+	r.cacheLocation(actorName, lease.Pattern, nodeId)
+	return nil
+}
+
+// reclaim 迁移失败后从目标节点收回租约
+func (r *OwnershipRegistry) reclaim(ctx context.Context, actorName, pattern, from string) error {
+	for i := 0; i < ownershipAcquireRetry; i++ {
+		owner, err := r.Acquire(ctx, actorName, pattern)
+		if err != nil {
+			return err
+		}
+		if owner.NodeId == r.nodeId {
+			return nil
+		}
+		if owner.NodeId != from {
+			return ErrLeaseLost
+		}
+
+		token, _, err := r.swap(ctx, actorName, owner.value(), r.nodeId)
+		if err != nil {
+			return err
+		}
+		if token > 0 {
+			r.hold(&Lease{ActorName: actorName, Pattern: pattern, NodeId: r.nodeId, Token: token})
+			return nil
+		}
+	}
+	return ErrLeaseLost
+}
+
+// cancelHandover Actor在本节点重新激活，放弃尚未完成的移交
+func (r *OwnershipRegistry) cancelHandover(actorName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lease, ok := r.leases[actorName]; ok && lease.handoverTo != "" {
+		lease.handoverTo = ""
+		logger.GetLogger().Warn("[Ownership] actor reactivated, handover canceled", zap.String("ActorName", actorName))
+	}
+}
+
+func (r *OwnershipRegistry) release(ctx context.Context, lease *Lease) error {
+	return r.compareAndSwap(ctx, lease.ActorName, lease.value(), func(pipe redis.Pipeliner) {
+		pipe.Del(ctx, genOwnerKey(lease.ActorName))
+	})
+}
+
+func (r *OwnershipRegistry) handover(ctx context.Context, lease *Lease) error {
+	token, _, err := r.swap(ctx, lease.ActorName, lease.value(), lease.handoverTo)
+	if err != nil {
+		return err
+	}
+	if token == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *OwnershipRegistry) renewLoop() {
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			r.renewAll()
+		}
+	}
+}
+
+// renewAll 续约本节点持有的所有租约，每批租约一次往返
+func (r *OwnershipRegistry) renewAll() {
+	r.mu.Lock()
+	leases := make([]*Lease, 0, len(r.leases))
+	for _, lease := range r.leases {
+		leases = append(leases, lease)
+	}
+	r.mu.Unlock()
+
+	for len(leases) > 0 {
+		n := min(len(leases), ownershipRenewBatch)
+		r.renew(leases[:n])
+		leases = leases[n:]
+	}
+}
+
+func (r *OwnershipRegistry) renew(leases []*Lease) {
+	keys := make([]string, 0, len(leases))
+	args := make([]any, 0, len(leases)+1)
+	args = append(args, r.ttl.Milliseconds())
+	for _, lease := range leases {
+		keys = append(keys, genOwnerKey(lease.ActorName))
+		args = append(args, lease.value())
+	}
+	renewed, err := renewScript.Run(context.Background(), r.cli, keys, args...).Int64Slice()
+
+	now := time.Now()
+	for i, lease := range leases {
+		switch {
+		case err == nil && renewed[i] == 1:
+			r.mu.Lock()
+			lease.renewedAt = now
+			r.mu.Unlock()
+		case err == nil:
+			// 租约已被其他节点取得或已经过期，停止本地Actor，避免出现两个激活
+			r.lost(lease, ErrLeaseLost)
+		case time.Since(lease.renewedAt) >= r.ttl:
+			r.lost(lease, err)
+		default:
+			logger.GetLogger().Warn("[Ownership] renew lease failed", zap.String("ActorName", lease.ActorName), zap.Error(err))
+		}
+	}
+}
+
+func (r *OwnershipRegistry) lost(lease *Lease, reason error) {
+	r.mu.Lock()
+	if r.leases[lease.ActorName] == lease {
+		delete(r.leases, lease.ActorName)
+	}
+	r.mu.Unlock()
+	r.metas.Evict(lease.ActorName)
+
+	logger.GetLogger().Error("[Ownership] lease lost, stop local actor",
+		zap.String("ActorName", lease.ActorName),
+		zap.Int64("Token", lease.Token),
+		zap.Error(reason))
+	if err := r.sys().StopActor(lease.ActorName, lease.Pattern); err != nil {
+		logger.GetLogger().Error("[Ownership] stop actor failed", zap.String("ActorName", lease.ActorName), zap.Error(err))
+	}
+}
+
+// compareAndSwap 持有者的值仍为 expect 时执行 fn 中的写操作，否则返回 ErrLeaseLost
+func (r *OwnershipRegistry) compareAndSwap(ctx context.Context, actorName, expect string, fn func(pipe redis.Pipeliner)) error {
+	key := genOwnerKey(actorName)
+	err := r.cli.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return ErrLeaseLost
+		}
+		if err != nil {
+			return err
+		}
+		if current != expect {
+			return ErrLeaseLost
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			fn(pipe)
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrLeaseLost
+	}
+	return err
+}
+
+// swap 持有者的值仍为 expect 时(为空表示没有持有者)递增fencing token并将租约转到 nodeId 名下
+// 成功时返回新的token，否则返回当前持有者的值，没有持有者时为空
+func (r *OwnershipRegistry) swap(ctx context.Context, actorName, expect, nodeId string) (int64, string, error) {
+	keys := []string{genOwnerKey(actorName), genFenceKey(actorName)}
+	re, err := swapScript.Run(ctx, r.cli, keys, expect, nodeId, r.ttl.Milliseconds()).Slice()
+	if err != nil {
+		return 0, "", err
+	}
+	if len(re) != 2 {
+		return 0, "", fmt.Errorf("unexpected swap result: %v", re)
+	}
+	if ok, _ := re[0].(int64); ok == 1 {
+		token, _ := re[1].(int64)
+		return token, "", nil
+	}
+	current, _ := re[1].(string)
+	return 0, current, nil
+}
+
+func (r *OwnershipRegistry) owner(ctx context.Context, actorName string) (*Lease, error) {
+	value, err := r.cli.Get(ctx, genOwnerKey(actorName)).Result()
+	if err != nil {
+		return nil, err
+	}
+	lease, err := parseLease(value)
+	if err != nil {
+		return nil, err
+	}
+	lease.ActorName = actorName
+	return lease, nil
+}
+
+func (r *OwnershipRegistry) hold(lease *Lease) {
+	lease.renewedAt = time.Now()
+	r.mu.Lock()
+	r.leases[lease.ActorName] = lease
+	r.mu.Unlock()
+
+	meta := r.location(lease.ActorName, lease.Pattern, r.nodeId)
+	if _, err := r.metas.Store(lease.ActorName, meta); err != nil {
+		logger.GetLogger().Error("[Ownership] store actor meta failed", zap.String("ActorName", lease.ActorName), zap.Error(err))
+	}
+}
+
+func (r *OwnershipRegistry) drop(actorName string) (*Lease, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lease, ok := r.leases[actorName]
+	if ok {
+		delete(r.leases, actorName)
+	}
+	return lease, ok
+}
+
+func (r *OwnershipRegistry) cacheLocation(actorName, pattern, nodeId string) {
+	r.metas.Set(actorName, r.location(actorName, pattern, nodeId))
+}
+
+// location 复制已缓存的Meta并更新所在节点，缓存中的Meta可能正在被其他goroutine读取
+func (r *OwnershipRegistry) location(actorName, pattern, nodeId string) *Meta {
+	meta := NewMeta(actorName, pattern, "", &Dispatcher{})
+	if v, ok := r.metas.Get(actorName); ok {
+		meta = proto.Clone(v).(*Meta)
+		if meta.Dispatcher == nil {
+			meta.Dispatcher = &Dispatcher{}
+		}
+	}
+	meta.Dispatcher.NodeId = nodeId
+	return meta
+}
+
+func (l *Lease) value() string {
+	return l.NodeId + "|" + strconv.FormatInt(l.Token, 10)
+}
+
+func parseLease(value string) (*Lease, error) {
+	i := strings.LastIndexByte(value, '|')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid lease value: %s", value)
+	}
+	token, err := strconv.ParseInt(value[i+1:], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lease value: %s", value)
+	}
+	return &Lease{NodeId: value[:i], Token: token}, nil
+}
+
+func genOwnerKey(actorName string) string {
+	return fmt.Sprintf("actor_owner:%s", actorName)
+}
+
+func genFenceKey(actorName string) string {
+	return fmt.Sprintf("actor_owner_fence:%s", actorName)
+}
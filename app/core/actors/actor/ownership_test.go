@@ -0,0 +1,158 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwnership_Lease(t *testing.T) {
+	stub, cli := newRedisStub(t)
+	ttl := 200 * time.Millisecond
+	a := NewOwnershipRegistry(cli, NewMetaCache(cli), "node-a", WithLeaseTTL(ttl))
+	b := NewOwnershipRegistry(cli, NewMetaCache(cli), "node-b", WithLeaseTTL(ttl))
+	ctx := context.Background()
+
+	la, err := a.Acquire(ctx, "guild-1", "guild")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", la.NodeId)
+
+	lb, err := b.Acquire(ctx, "guild-1", "guild")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", lb.NodeId, "lease should stay with the first owner")
+	assert.Equal(t, la.Token, lb.Token)
+	fence, _ := stub.Get(genFenceKey("guild-1"))
+	assert.Equal(t, strconv.FormatInt(la.Token, 10), fence, "resolving a held lease should not consume a fencing token")
+	nodeId, err := b.Resolve("guild-1", "guild")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", nodeId)
+	_, held := b.Lease("guild-1")
+	assert.False(t, held)
+
+	// 释放后其他节点可以获取，fencing token 递增
+	assert.NoError(t, a.Release(ctx, "guild-1"))
+	assert.True(t, errors.Is(a.Release(ctx, "guild-1"), ErrLeaseNotHeld))
+	b.Invalidate("guild-1")
+	lb, err = b.Acquire(ctx, "guild-1", "guild")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-b", lb.NodeId)
+	assert.Greater(t, lb.Token, la.Token)
+
+	// 未续约的租约过期后被其他节点取得，旧持有者无法再释放新租约
+	time.Sleep(ttl + 50*time.Millisecond)
+	la, err = a.Acquire(ctx, "guild-1", "guild")
+	assert.NoError(t, err)
+	assert.Equal(t, "node-a", la.NodeId)
+	assert.Greater(t, la.Token, lb.Token)
+	assert.True(t, errors.Is(b.release(ctx, lb), ErrLeaseLost))
+}
+
+func TestOwnership_RenewAndLost(t *testing.T) {
+	const pattern = "ownership-renew-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	stub, cli := newRedisStub(t)
+	ttl := 150 * time.Millisecond
+	r := NewOwnershipRegistry(cli, NewMetaCache(cli), "node-a", WithLeaseTTL(ttl))
+	r.Start()
+	defer r.Stop()
+
+	lease, err := r.Acquire(context.Background(), "guild-renew", pattern)
+	assert.NoError(t, err)
+	others := make([]*Lease, 0, ownershipRenewBatch)
+	for i := 0; i < ownershipRenewBatch; i++ {
+		other, err := r.Acquire(context.Background(), fmt.Sprintf("guild-renew-%d", i), pattern)
+		assert.NoError(t, err)
+		others = append(others, other)
+	}
+	time.Sleep(3 * ttl)
+	value, ok := stub.Get(genOwnerKey("guild-renew"))
+	assert.True(t, ok, "lease should be renewed")
+	assert.Equal(t, lease.value(), value)
+	for _, other := range others {
+		value, _ = stub.Get(genOwnerKey(other.ActorName))
+		assert.Equal(t, other.value(), value, "leases beyond one batch should be renewed")
+	}
+
+	// 租约被其他节点取得后，本节点放弃持有
+	stub.Set(genOwnerKey("guild-renew"), "node-b|100", ttl)
+	assert.Eventually(t, func() bool {
+		_, held := r.Lease("guild-renew")
+		return !held
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestOwnership_ActorRef(t *testing.T) {
+	const pattern = "ownership-actor-ref-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+	RegFactory(pattern, MockBehaviorFactory)
+
+	stub, cli := newRedisStub(t)
+	assert.True(t, errors.Is(System.StartOwnership(cli, NewMetaCache(cli)), ErrRemoteNotStarted))
+	assert.NoError(t, System.StartRemote(&RemoteConfig{NodeId: "node-a", Addr: "127.0.0.1:0"}))
+	assert.NoError(t, System.StartOwnership(cli, NewMetaCache(cli), WithLeaseTTL(time.Second)))
+
+	// 没有节点持有时在本节点激活，被动化后释放租约
+	local := NewActorRef(NewProps(), "guild-local", pattern)
+	_, err := local.RequestFuture("hello")
+	assert.NoError(t, err)
+	assert.True(t, System.actors.Exist("guild-local"))
+	lease, held := System.Ownership().Lease("guild-local")
+	assert.True(t, held)
+	value, _ := stub.Get(genOwnerKey("guild-local"))
+	assert.Equal(t, lease.value(), value)
+
+	local.Stop()
+	assert.Eventually(t, func() bool {
+		_, exists := stub.Get(genOwnerKey("guild-local"))
+		return !exists
+	}, time.Second, 10*time.Millisecond)
+
+	// 其他节点持有时解析为远程引用，不在本节点激活
+	stub.Set(genOwnerKey("guild-remote"), "node-b|7", time.Second)
+	remote := NewActorRef(NewProps(), "guild-remote", pattern)
+	assert.True(t, errors.Is(remote.Send("hello"), ErrNodeNotFound))
+	assert.False(t, System.actors.Exist("guild-remote"))
+
+	// 移交: 本地Actor停止后租约转到目标节点名下
+	handover := NewActorRef(NewProps(), "guild-handover", pattern)
+	_, err = handover.RequestFuture("hello")
+	assert.NoError(t, err)
+	assert.NoError(t, System.Ownership().Handover("guild-handover", "node-b"))
+	assert.Eventually(t, func() bool {
+		value, _ := stub.Get(genOwnerKey("guild-handover"))
+		return strings.HasPrefix(value, "node-b|") && !System.actors.Exist("guild-handover")
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, errors.Is(handover.Send("hello"), ErrNodeNotFound))
+}
+
+// 取得租约后本地激活失败时释放租约，其他节点可以重新竞争
+func TestOwnership_ActivationFailed(t *testing.T) {
+	const pattern = "ownership-init-fail-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &initFailBehavior{}
+	})
+
+	stub, cli := newRedisStub(t)
+	assert.NoError(t, System.StartRemote(&RemoteConfig{NodeId: "node-a", Addr: "127.0.0.1:0"}))
+	assert.NoError(t, System.StartOwnership(cli, NewMetaCache(cli)))
+
+	ref := NewActorRef(NewProps(), "guild-init-fail", pattern)
+	_, err := ref.RequestFuture("hello")
+	assert.EqualError(t, err, "init failed")
+	assert.Eventually(t, func() bool {
+		_, exists := stub.Get(genOwnerKey("guild-init-fail"))
+		_, held := System.Ownership().Lease("guild-init-fail")
+		return !exists && !held
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,353 @@
+package actor
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"gitee.com/orbit-w/orbit/lib/utils"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+/*
+	PlacementService 按Pattern注册的 PlacementPolicy 为首次激活的Actor选择所在节点
+
+	1. DISPATCHER_TYPE_IN_WORLD:  固定放置在 PlacementPolicy.WorldNode
+	2. DISPATCHER_TYPE_IN_REGION: 在Actor所属区域(Meta.ServerId)的健康节点组成的一致性哈希环上按ActorName选择节点，
+	   区域内没有节点时使用所有健康节点
+	3. DISPATCHER_TYPE_RANDOM:    在所有健康节点中随机选择
+
+	选择结果记录在 Meta.Dispatcher 中并通过 ActorMetaCache 写入Redis，之后所有节点都按记录转发。
+	节点加入或离开时重新平衡：
+	  - 记录指向已离开节点的Actor，下一次访问时重新选择节点
+	  - 本节点上按区域放置的Actor，如果哈希环上的目标节点发生变化，更新记录并停止本地Actor，下一条消息在新节点上激活
+*/
+
+const placementVirtualNodes = 64
+
+// PlacementPolicy Actor放置策略，按Pattern注册
+type PlacementPolicy struct {
+	Type      DispatcherType
+	WorldNode string // DISPATCHER_TYPE_IN_WORLD 时使用的节点
+}
+
+// PlacementPolicyRegistry 按Pattern注册的放置策略，每个 ActorSystem 可以使用独立的注册表(WithPlacementPolicies)
+type PlacementPolicyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]*PlacementPolicy
+}
+
+func NewPlacementPolicyRegistry() *PlacementPolicyRegistry {
+	return &PlacementPolicyRegistry{
+		policies: make(map[string]*PlacementPolicy),
+	}
+}
+
+// defaultPlacementPolicies 未指定注册表的 ActorSystem 以及包级别的 RegPlacementPolicy 使用的注册表
+var defaultPlacementPolicies = NewPlacementPolicyRegistry()
+
+// DefaultPlacementPolicies 返回默认的放置策略注册表
+func DefaultPlacementPolicies() *PlacementPolicyRegistry {
+	return defaultPlacementPolicies
+}
+
+// Reg 为Pattern注册放置策略，重复注册时panic
+func (r *PlacementPolicyRegistry) Reg(pattern string, policy *PlacementPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[pattern]; ok {
+		panic("placement policy already registered: " + pattern)
+	}
+	r.policies[pattern] = policy
+}
+
+// Get 返回Pattern的放置策略，未注册时返回nil
+func (r *PlacementPolicyRegistry) Get(pattern string) *PlacementPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.policies[pattern]
+}
+
+// RegPlacementPolicy registers a placement policy for a specific actor pattern
+func RegPlacementPolicy(pattern string, policy *PlacementPolicy) {
+	defaultPlacementPolicies.Reg(pattern, policy)
+}
+
+// GetPlacementPolicy returns the placement policy of the pattern, nil if not registered
+func GetPlacementPolicy(pattern string) *PlacementPolicy {
+	return defaultPlacementPolicies.Get(pattern)
+}
+
+// PlacementService 节点放置服务
+type PlacementService struct {
+	nodeId string
+	metas  *ActorMetaCache
+
+	mu      sync.RWMutex
+	nodes   map[string]NodeConfig // 健康节点
+	all     *hashRing
+	regions map[string]*hashRing
+	system  *ActorSystem
+}
+
+func NewPlacementService(nodeId string, metas *ActorMetaCache, nodes ...NodeConfig) *PlacementService {
+	s := &PlacementService{
+		nodeId: nodeId,
+		metas:  metas,
+		nodes:  make(map[string]NodeConfig, len(nodes)),
+	}
+	for _, node := range nodes {
+		s.nodes[node.NodeId] = node
+	}
+	s.rebuild()
+	return s
+}
+
+// Place 返回Actor所在的节点ID，Pattern未注册放置策略时返回空
+// 已有记录且记录的节点健康时使用记录，否则按策略选择节点并记录到 Meta.Dispatcher
+// 多个节点同时为同一个Actor选择节点时，通过 CompareAndSwap 保证只有一个记录生效
+func (s *PlacementService) Place(actorName, pattern string, meta *Meta) (string, error) {
+	policy := s.sys().PlacementPolicies().Get(pattern)
+	if policy == nil {
+		return "", nil
+	}
+
+	prev, ok := s.recorded(actorName)
+	if ok {
+		return prev.GetDispatcher().GetNodeId(), nil
+	}
+
+	for i := 0; i < metaCASMaxRetries; i++ {
+		nodeId, err := s.choose(policy, actorName, meta.GetServerId())
+		if err != nil {
+			return "", err
+		}
+		current, err := s.metas.CompareAndSwap(actorName, prev.GetDispatcher(), s.newMeta(actorName, pattern, meta.GetServerId(), policy.Type, nodeId))
+		if !errors.Is(err, ErrMetaConflict) {
+			return nodeId, err
+		}
+		// 其他节点先记录了位置
+		if s.usable(current) {
+			return current.GetDispatcher().GetNodeId(), nil
+		}
+		prev = current
+	}
+	return "", ErrMetaConflict
+}
+
+// Join 节点加入或恢复健康
+func (s *PlacementService) Join(node NodeConfig) {
+	s.mu.Lock()
+	s.nodes[node.NodeId] = node
+	s.rebuild()
+	s.mu.Unlock()
+	logger.GetLogger().Info("[Placement] node joined", zap.String("NodeId", node.NodeId), zap.String("Region", node.Region))
+	s.rebalance()
+}
+
+// Leave 节点离开或不健康
+func (s *PlacementService) Leave(nodeId string) {
+	s.mu.Lock()
+	delete(s.nodes, nodeId)
+	s.rebuild()
+	s.mu.Unlock()
+	logger.GetLogger().Info("[Placement] node left", zap.String("NodeId", nodeId))
+	s.rebalance()
+}
+
+// Healthy 判断节点是否健康
+func (s *PlacementService) Healthy(nodeId string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.nodes[nodeId]
+	return ok
+}
+
+// recorded 读取Actor的记录，记录的节点健康时返回true
+// 读取失败或没有记录时返回nil
+func (s *PlacementService) recorded(actorName string) (*Meta, bool) {
+	meta, ok := s.metas.Get(actorName)
+	if !ok {
+		var err error
+		if meta, err = s.metas.Load(actorName); err != nil {
+			if !errors.Is(err, redis.Nil) {
+				logger.GetLogger().Error("[Placement] load actor meta failed", zap.String("ActorName", actorName), zap.Error(err))
+			}
+			return nil, false
+		}
+	}
+	return meta, s.usable(meta)
+}
+
+// usable 记录的节点是否可以继续使用，固定放置的世界节点总是可用
+func (s *PlacementService) usable(meta *Meta) bool {
+	nodeId := meta.GetDispatcher().GetNodeId()
+	if nodeId == "" {
+		return false
+	}
+	if policy := s.sys().PlacementPolicies().Get(meta.GetPattern()); policy != nil &&
+		policy.Type == DispatcherType_DISPATCHER_TYPE_IN_WORLD && nodeId == policy.WorldNode {
+		return true
+	}
+	return s.Healthy(nodeId)
+}
+
+func (s *PlacementService) choose(policy *PlacementPolicy, actorName, region string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch policy.Type {
+	case DispatcherType_DISPATCHER_TYPE_IN_WORLD:
+		if policy.WorldNode == "" {
+			return "", ErrNoAvailableNode
+		}
+		return policy.WorldNode, nil
+	case DispatcherType_DISPATCHER_TYPE_IN_REGION:
+		ring := s.regions[region]
+		if ring == nil {
+			ring = s.all
+		}
+		if nodeId, ok := ring.get(actorName); ok {
+			return nodeId, nil
+		}
+		return "", ErrNoAvailableNode
+	default:
+		if len(s.nodes) == 0 {
+			return "", ErrNoAvailableNode
+		}
+		ids := make([]string, 0, len(s.nodes))
+		for id := range s.nodes {
+			ids = append(ids, id)
+		}
+		return ids[rand.Intn(len(ids))], nil
+	}
+}
+
+func (s *PlacementService) record(actorName, pattern, serverId string, typ DispatcherType, nodeId string) error {
+	_, err := s.metas.Store(actorName, s.newMeta(actorName, pattern, serverId, typ, nodeId))
+	return err
+}
+
+func (s *PlacementService) newMeta(actorName, pattern, serverId string, typ DispatcherType, nodeId string) *Meta {
+	return NewMeta(actorName, pattern, serverId, &Dispatcher{
+		Type:     typ,
+		ServerId: serverId,
+		NodeId:   nodeId,
+	})
+}
+
+// relocate Actor迁移后更新记录的节点
+func (s *PlacementService) relocate(actorName, pattern, nodeId string) error {
+	typ := DispatcherType_DISPATCHER_TYPE_IN_WORLD
+	if policy := s.sys().PlacementPolicies().Get(pattern); policy != nil {
+		typ = policy.Type
+	}
+	meta, _ := s.metas.Get(actorName)
+	return s.record(actorName, pattern, meta.GetServerId(), typ, nodeId)
+}
+
+// rebalance 节点变化后重新平衡
+func (s *PlacementService) rebalance() {
+	// 指向已离开节点的记录失效，下一次访问时重新选择
+	for actorName, meta := range s.metas.Items() {
+		if nodeId := meta.GetDispatcher().GetNodeId(); nodeId != "" && !s.Healthy(nodeId) {
+			s.metas.Evict(actorName)
+		}
+	}
+
+	// 按区域放置的本地Actor迁移到哈希环上新的目标节点
+	// 遍历期间持有缓存的读锁，停止Actor需要在遍历结束后进行
+	var local []*Process
+	system := s.sys()
+	if system == nil {
+		return
+	}
+	policies := system.PlacementPolicies()
+	system.actors.Range(func(_ string, p *Process) {
+		if policy := policies.Get(p.Pattern); policy != nil && policy.Type == DispatcherType_DISPATCHER_TYPE_IN_REGION {
+			local = append(local, p)
+		}
+	})
+	for _, p := range local {
+		policy := policies.Get(p.Pattern)
+		meta, _ := s.metas.Get(p.ActorName)
+		nodeId, err := s.choose(policy, p.ActorName, meta.GetServerId())
+		if err != nil || nodeId == s.nodeId {
+			continue
+		}
+		if err = s.record(p.ActorName, p.Pattern, meta.GetServerId(), policy.Type, nodeId); err != nil {
+			logger.GetLogger().Error("[Placement] record rebalanced actor failed", zap.String("ActorName", p.ActorName), zap.Error(err))
+			continue
+		}
+		logger.GetLogger().Info("[Placement] rebalance actor",
+			zap.String("ActorName", p.ActorName),
+			zap.String("NodeId", nodeId))
+		_ = system.StopActor(p.ActorName, p.Pattern)
+	}
+}
+
+// sys 返回放置服务所属的ActorSystem，单独创建的服务使用默认的 System，System 未初始化时使用默认的策略注册表
+func (s *PlacementService) sys() *ActorSystem {
+	if s.system != nil {
+		return s.system
+	}
+	return System
+}
+
+// rebuild 重建哈希环，调用者持有写锁
+func (s *PlacementService) rebuild() {
+	all := make([]string, 0, len(s.nodes))
+	regions := make(map[string][]string)
+	for id, node := range s.nodes {
+		all = append(all, id)
+		if node.Region != "" {
+			regions[node.Region] = append(regions[node.Region], id)
+		}
+	}
+	s.all = newHashRing(all)
+	s.regions = make(map[string]*hashRing, len(regions))
+	for region, ids := range regions {
+		s.regions[region] = newHashRing(ids)
+	}
+}
+
+// hashRing 一致性哈希环，每个节点对应 placementVirtualNodes 个虚拟节点
+type hashRing struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+func newHashRing(nodeIds []string) *hashRing {
+	r := &hashRing{owners: make(map[uint32]string, len(nodeIds)*placementVirtualNodes)}
+	for _, id := range nodeIds {
+		for i := 0; i < placementVirtualNodes; i++ {
+			point := utils.StringHash32(id+"#"+strconv.Itoa(i), 0)
+			// 哈希冲突时保留节点ID较小的，保证各节点计算结果一致
+			if owner, exists := r.owners[point]; exists && owner < id {
+				continue
+			}
+			if _, exists := r.owners[point]; !exists {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = id
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+func (r *hashRing) get(key string) (string, bool) {
+	if r == nil || len(r.points) == 0 {
+		return "", false
+	}
+	hash := utils.StringHash32(key, 0)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]], true
+}
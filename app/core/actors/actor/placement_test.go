@@ -0,0 +1,146 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPlacement_HashRing(t *testing.T) {
+	ring := newHashRing([]string{"node-a", "node-b", "node-c"})
+	shrunk := newHashRing([]string{"node-a", "node-b"})
+
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("guild-%d", i)
+		nodeId, ok := ring.get(key)
+		assert.True(t, ok)
+		counts[nodeId]++
+
+		// 节点离开后，只有原本在该节点上的key会迁移
+		if nodeId != "node-c" {
+			moved, _ := shrunk.get(key)
+			assert.Equal(t, nodeId, moved)
+		}
+	}
+	for _, n := range counts {
+		assert.Greater(t, n, 500, "keys should be spread over all nodes")
+	}
+
+	_, ok := newHashRing(nil).get("guild-1")
+	assert.False(t, ok)
+}
+
+func TestPlacement_Place(t *testing.T) {
+	const (
+		worldPattern  = "placement-world-pattern"
+		regionPattern = "placement-region-pattern"
+		randomPattern = "placement-random-pattern"
+	)
+	RegPlacementPolicy(worldPattern, &PlacementPolicy{Type: DispatcherType_DISPATCHER_TYPE_IN_WORLD, WorldNode: "node-w"})
+	RegPlacementPolicy(regionPattern, &PlacementPolicy{Type: DispatcherType_DISPATCHER_TYPE_IN_REGION})
+	RegPlacementPolicy(randomPattern, &PlacementPolicy{Type: DispatcherType_DISPATCHER_TYPE_RANDOM})
+
+	stub, cli := newRedisStub(t)
+	s := NewPlacementService("node-a", NewMetaCache(cli),
+		NodeConfig{NodeId: "node-a", Region: "1"},
+		NodeConfig{NodeId: "node-b", Region: "1"},
+		NodeConfig{NodeId: "node-c", Region: "2"},
+	)
+
+	nodeId, err := s.Place("world-1", worldPattern, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "node-w", nodeId)
+	content, ok := stub.Get(genRedisKey("world-1"))
+	assert.True(t, ok, "placement should be recorded in redis")
+	meta := &Meta{}
+	assert.NoError(t, proto.Unmarshal([]byte(content), meta))
+	assert.Equal(t, "node-w", meta.GetDispatcher().GetNodeId())
+	assert.Equal(t, DispatcherType_DISPATCHER_TYPE_IN_WORLD, meta.GetDispatcher().GetType())
+
+	nodeId, err = s.Place("guild-2", regionPattern, NewMeta("guild-2", regionPattern, "2", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "node-c", nodeId, "region actors should stay in their region")
+	nodeId, err = s.Place("guild-1", regionPattern, NewMeta("guild-1", regionPattern, "1", nil))
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"node-a", "node-b"}, nodeId)
+
+	random, err := s.Place("room-1", randomPattern, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		nodeId, err = s.Place("room-1", randomPattern, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, random, nodeId, "recorded placement should be reused")
+	}
+
+	// 新的服务从Redis读取记录
+	other := NewPlacementService("node-b", NewMetaCache(cli), NodeConfig{NodeId: "node-a"}, NodeConfig{NodeId: "node-b"}, NodeConfig{NodeId: "node-c"})
+	nodeId, err = other.Place("room-1", randomPattern, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, random, nodeId)
+
+	// 两个节点同时放置同一个Actor时结果一致
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("room-race-%d", i)
+		results := make(chan string, 2)
+		for _, svc := range []*PlacementService{s, other} {
+			go func(svc *PlacementService) {
+				nodeId, _ := svc.Place(name, randomPattern, nil)
+				results <- nodeId
+			}(svc)
+		}
+		assert.Equal(t, <-results, <-results)
+	}
+
+	// 节点离开后重新选择
+	s.Leave("node-c")
+	nodeId, err = s.Place("guild-2", regionPattern, NewMeta("guild-2", regionPattern, "2", nil))
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"node-a", "node-b"}, nodeId)
+
+	nodeId, err = s.Place("not-placed", "placement-unknown-pattern", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", nodeId)
+}
+
+func TestPlacement_Rebalance(t *testing.T) {
+	const pattern = "placement-rebalance-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+	RegFactory(pattern, MockBehaviorFactory)
+	RegPlacementPolicy(pattern, &PlacementPolicy{Type: DispatcherType_DISPATCHER_TYPE_IN_REGION})
+
+	_, cli := newRedisStub(t)
+	assert.NoError(t, System.StartRemote(&RemoteConfig{
+		NodeId:    "node-a",
+		Addr:      "127.0.0.1:0",
+		Discovery: NewStaticDiscovery(NodeConfig{NodeId: "node-a", Addr: "127.0.0.1:0", Region: "1"}),
+	}))
+	assert.NoError(t, System.StartPlacement(NewMetaCache(cli)))
+
+	// 找到一个在node-b加入后会迁移到node-b的Actor
+	ring := newHashRing([]string{"node-a", "node-b"})
+	var name string
+	for i := 0; name == ""; i++ {
+		if nodeId, _ := ring.get(fmt.Sprintf("guild-%d", i)); nodeId == "node-b" {
+			name = fmt.Sprintf("guild-%d", i)
+		}
+	}
+
+	ref := NewActorRef(NewProps(), name, pattern, WithMeta(NewMeta(name, pattern, "1", nil)))
+	_, err := ref.RequestFuture("hello")
+	assert.NoError(t, err)
+	assert.True(t, System.actors.Exist(name), "only node-a is healthy, actor should be activated locally")
+
+	System.Placement().Join(NodeConfig{NodeId: "node-b", Region: "1"})
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist(name)
+	}, time.Second, 10*time.Millisecond)
+	nodeId, err := System.Placement().Place(name, pattern, ref.Props.GetMeta())
+	assert.NoError(t, err)
+	assert.Equal(t, "node-b", nodeId)
+}
@@ -3,10 +3,13 @@ package actor
 import "time"
 
 type Props struct {
-	InitHandler  func() error
-	Meta         *Meta
-	AliveTimeout time.Duration
-	kvs          map[string]any
+	InitHandler    func() error
+	Meta           *Meta
+	AliveTimeout   time.Duration
+	StashUntilInit bool
+	Clock          Clock
+	kvs            map[string]any
+	system         *ActorSystem // ActorRef绑定的ActorSystem，为空时使用默认的 System
 }
 
 func NewProps() *Props {
@@ -42,6 +45,29 @@ func (pp *Props) GetMeta() *Meta {
 	return pp.Meta
 }
 
+func (pp *Props) GetStashUntilInit() bool {
+	if pp == nil {
+		return false
+	}
+	return pp.StashUntilInit
+}
+
+// GetClock 返回Actor定时器使用的时钟，未设置时返回 SystemClock
+func (pp *Props) GetClock() Clock {
+	if pp == nil || pp.Clock == nil {
+		return SystemClock
+	}
+	return pp.Clock
+}
+
+// getSystem 返回绑定的ActorSystem，未绑定时返回nil
+func (pp *Props) getSystem() *ActorSystem {
+	if pp == nil {
+		return nil
+	}
+	return pp.system
+}
+
 func (pp *Props) GetKvs(iter func(k string, v any)) {
 	if pp == nil {
 		return
@@ -54,14 +80,6 @@ func (pp *Props) GetKvs(iter func(k string, v any)) {
 	}
 }
 
-func (pp *Props) getOrCreateActorPID(name, pattern string) *Process {
-	p, err := GetOrStartActor(name, pattern, pp)
-	if err != nil {
-		panic(err)
-	}
-	return p
-}
-
 type PropsOption func(pp *Props)
 
 func WithInitHandler(handler func() error) PropsOption {
@@ -105,3 +123,34 @@ func WithAliveTimeout(timeout time.Duration) PropsOption {
 		pp.AliveTimeout = timeout
 	}
 }
+
+// WithStashUntilInit 初始化完成前自动暂存收到的业务消息和定时器消息，初始化完成后按到达顺序处理
+//
+// 详细说明:
+//  1. 配合 IContext.AwaitInit 使用：HandleInit 中发起异步加载，Actor启动后即可接收消息，
+//     加载完成前收到的消息不会在未初始化完成的状态上执行
+//  2. 异步加载失败时，暂存的Request消息收到错误回复，Send消息发布到死信，Actor被停止
+//
+// 示例:
+//
+//	actorRef := NewActorRef(NewProps(), "player-1001", "player-pattern",
+//	  WithStashUntilInit(),
+//	)
+func WithStashUntilInit() PropsOption {
+	return func(pp *Props) {
+		pp.StashUntilInit = true
+	}
+}
+
+// WithClock 配置Actor定时器使用的时钟，测试中使用 MockClock 可以不等待真实时间验证定时和重置逻辑
+//
+// 示例:
+//
+//	clock := NewMockClock(time.Now())
+//	actorRef := NewActorRef(NewProps(), "player-1001", "player-pattern", WithClock(clock))
+//	clock.Advance(24 * time.Hour) // 触发每日重置定时器
+func WithClock(clock Clock) PropsOption {
+	return func(pp *Props) {
+		pp.Clock = clock
+	}
+}
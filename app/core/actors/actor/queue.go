@@ -2,6 +2,9 @@ package actor
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"time"
 
 	"gitee.com/orbit-w/meteor/bases/container/priority_queue"
 	"github.com/asynkron/protoactor-go/actor"
@@ -13,6 +16,10 @@ type Item struct {
 	Future    []*actor.PID
 	Child     *actor.PID
 	Props     *Props
+	mailbox   *mailbox
+	createdAt time.Time // 入队时间，用于统计启动耗时
+
+	migrationState []byte // 等待重新激活的Actor迁入的状态
 }
 
 func NewItem(actorName, pattern string, child *actor.PID, props *Props, future ...*actor.PID) *Item {
@@ -22,6 +29,7 @@ func NewItem(actorName, pattern string, child *actor.PID, props *Props, future .
 		Future:    make([]*actor.PID, 0),
 		Child:     child,
 		Props:     props,
+		createdAt: time.Now(),
 	}
 	item.AddFuture(future...)
 	return item
@@ -59,12 +67,14 @@ func (i *Item) Futures() []*actor.PID {
 }
 
 type Queue struct {
-	pq *priority_queue.PriorityQueue[string, *Item, int64]
+	pq   *priority_queue.PriorityQueue[string, *Item, int64]
+	keys map[string]struct{} // PriorityQueue 不支持遍历，单独记录队列中的ActorName
 }
 
 func NewPriorityQueue() *Queue {
 	return &Queue{
-		pq: priority_queue.New[string, *Item, int64](),
+		pq:   priority_queue.New[string, *Item, int64](),
+		keys: make(map[string]struct{}),
 	}
 }
 
@@ -74,6 +84,7 @@ func (q *Queue) Insert(actorName string, item *Item, priority int64) error {
 		return fmt.Errorf("actor %s already exists", actorName)
 	}
 	q.pq.Push(actorName, item, priority)
+	q.keys[actorName] = struct{}{}
 	return nil
 }
 
@@ -87,8 +98,15 @@ func (q *Queue) PushFuture(actorName string, future *actor.PID) {
 	q.pq.UpdateValue(actorName, v)
 }
 
+// Pop 删除并返回指定的项
+// PriorityQueue.PopK 删除非堆尾元素时会死循环，这里先将该项提到堆顶再弹出
 func (q *Queue) Pop(key string) (*Item, bool) {
-	return q.pq.PopK(key)
+	if !q.pq.UpdatePriority(key, math.MinInt64) {
+		return nil, false
+	}
+	_, v, ok := q.pq.Pop()
+	delete(q.keys, key)
+	return v, ok
 }
 
 func (q *Queue) Exists(key string) bool {
@@ -96,7 +114,7 @@ func (q *Queue) Exists(key string) bool {
 }
 
 func (q *Queue) PopAndRangeWithKey(key string, iter func(name, pattern string, child, future *actor.PID) bool) (*Item, bool) {
-	v, ok := q.pq.PopK(key)
+	v, ok := q.Pop(key)
 	if !ok {
 		return nil, false
 	}
@@ -113,6 +131,32 @@ func (q *Queue) Empty() bool {
 	return q.pq.Empty()
 }
 
+// Keys 返回队列中所有的ActorName，按名称排序
+func (q *Queue) Keys() []string {
+	keys := make([]string, 0, len(q.keys))
+	for k := range q.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Items 返回队列中的所有项，按ActorName排序
+func (q *Queue) Items() []*Item {
+	items := make([]*Item, 0, len(q.keys))
+	for _, key := range q.Keys() {
+		if ent, ok := q.pq.Get(key); ok {
+			items = append(items, ent.Value.GetValue())
+		}
+	}
+	return items
+}
+
+func (q *Queue) Len() int {
+	return len(q.keys)
+}
+
 func (q *Queue) Free() {
 	q.pq.Free()
+	q.keys = make(map[string]struct{})
 }
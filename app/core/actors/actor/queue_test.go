@@ -0,0 +1,52 @@
+package actor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// 弹出队列中间的项不会死循环，其余的项仍然可以按ActorName弹出
+func TestQueue_PopMiddle(t *testing.T) {
+	q := NewPriorityQueue()
+	names := []string{"a", "b", "c", "d", "e"}
+	for i, name := range names {
+		assert.NoError(t, q.Insert(name, NewItem(name, "queue-pattern", nil, nil), int64(i)))
+	}
+	q.PushFuture("b", actor.NewPID("nonhost", "future-b"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		item, ok := q.Pop("c")
+		assert.True(t, ok)
+		assert.Equal(t, "c", item.ActorName)
+
+		var futures []string
+		item, ok = q.PopAndRangeWithKey("b", func(name, pattern string, child, future *actor.PID) bool {
+			futures = append(futures, future.Id)
+			return true
+		})
+		assert.True(t, ok)
+		assert.Equal(t, "b", item.ActorName)
+		assert.Equal(t, []string{"future-b"}, futures)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Pop of a middle key did not return")
+	}
+
+	assert.False(t, q.Exists("c"))
+	assert.False(t, q.Exists("b"))
+	_, ok := q.Pop("c")
+	assert.False(t, ok)
+	for _, name := range []string{"e", "a", "d"} {
+		item, ok := q.Pop(name)
+		assert.True(t, ok)
+		assert.Equal(t, name, item.ActorName)
+	}
+	assert.True(t, q.Empty())
+}
@@ -0,0 +1,581 @@
+package actor
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStub 进程内的Redis替身，使用RESP2协议实现测试所需的少量命令
+// 支持: PING GET MGET SET(NX/XX/EX/PX) SETNX DEL EXISTS INCR PEXPIRE PTTL WATCH UNWATCH MULTI EXEC DISCARD
+// PUBLISH SUBSCRIBE UNSUBSCRIBE HSET HGET HDEL HGETALL ZADD ZREM ZSCORE ZRANGEBYSCORE(LIMIT)
+// EVAL/EVALSHA 只支持 redisStubScripts 中用Go实现的脚本
+type redisStub struct {
+	ln net.Listener
+
+	mu          sync.Mutex
+	values      map[string]string
+	hashes      map[string]map[string]string
+	zsets       map[string]map[string]float64
+	expires     map[string]time.Time
+	versions    map[string]uint64
+	scripts     map[string]redisStubScript
+	subscribers map[string]map[*redisStubConn]struct{}
+}
+
+type redisStubConn struct {
+	watched map[string]uint64
+	multi   bool
+	queued  [][]string
+	subs    map[string]struct{}
+
+	wmu sync.Mutex // 发布的消息由其他连接的协程写入
+	wr  *bufio.Writer
+}
+
+// redisStubScript 与Lua脚本语义相同的Go实现，调用时持有锁
+type redisStubScript func(s *redisStub, keys, args []string) any
+
+// redisStubScripts 替身支持的Lua脚本，按SHA1查找
+func redisStubScripts() map[string]redisStubScript {
+	return map[string]redisStubScript{
+		swapScript.Hash(): func(s *redisStub, keys, args []string) any {
+			current, _ := s.exec([]string{"GET", keys[0]}).([]byte)
+			if string(current) != args[0] {
+				return []any{int64(0), current}
+			}
+			token := s.exec([]string{"INCR", keys[1]}).(int64)
+			s.exec([]string{"SET", keys[0], args[1] + "|" + strconv.FormatInt(token, 10), "PX", args[2]})
+			return []any{int64(1), token}
+		},
+		renewScript.Hash(): func(s *redisStub, keys, args []string) any {
+			renewed := make([]any, 0, len(keys))
+			for i, key := range keys {
+				current, _ := s.exec([]string{"GET", key}).([]byte)
+				if current != nil && string(current) == args[i+1] {
+					s.exec([]string{"PEXPIRE", key, args[0]})
+					renewed = append(renewed, int64(1))
+				} else {
+					renewed = append(renewed, int64(0))
+				}
+			}
+			return renewed
+		},
+	}
+}
+
+// redisRawReply 原样写入的回复
+type redisRawReply string
+
+// newRedisStub 启动Redis替身，测试结束时自动关闭
+func newRedisStub(t *testing.T) (*redisStub, *redis.Client) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &redisStub{
+		ln:       ln,
+		values:   make(map[string]string),
+		hashes:   make(map[string]map[string]string),
+		zsets:    make(map[string]map[string]float64),
+		expires:  make(map[string]time.Time),
+		versions: make(map[string]uint64),
+		scripts:  redisStubScripts(),
+
+		subscribers: make(map[string]map[*redisStubConn]struct{}),
+	}
+	go s.accept()
+
+	cli := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2})
+	t.Cleanup(func() {
+		_ = cli.Close()
+		_ = ln.Close()
+	})
+	return s, cli
+}
+
+// Get 直接读取替身中的值，用于测试断言
+func (s *redisStub) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(key)
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set 直接写入替身，用于构造测试数据
+func (s *redisStub) Set(key, value string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.write(key, value, ttl)
+}
+
+func (s *redisStub) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *redisStub) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	state := &redisStubConn{
+		watched: make(map[string]uint64),
+		subs:    make(map[string]struct{}),
+		wr:      bufio.NewWriter(conn),
+	}
+	defer s.unsubscribe(state, nil)
+	for {
+		args, err := readRedisCommand(rd)
+		if err != nil {
+			return
+		}
+		replies := s.handle(state, args)
+		state.wmu.Lock()
+		for _, re := range replies {
+			writeRedisReply(state.wr, re)
+		}
+		if rd.Buffered() == 0 {
+			err = state.wr.Flush()
+		}
+		state.wmu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle 执行命令并返回需要写入的回复，SUBSCRIBE 等命令每个频道回复一次
+func (s *redisStub) handle(state *redisStubConn, args []string) []any {
+	cmd := strings.ToUpper(args[0])
+	switch cmd {
+	case "HELLO":
+		return []any{errors.New("ERR unknown command 'HELLO'")}
+	case "CLIENT", "SELECT":
+		return []any{"OK"}
+	case "SUBSCRIBE":
+		var replies []any
+		s.mu.Lock()
+		for _, channel := range args[1:] {
+			state.subs[channel] = struct{}{}
+			if s.subscribers[channel] == nil {
+				s.subscribers[channel] = make(map[*redisStubConn]struct{})
+			}
+			s.subscribers[channel][state] = struct{}{}
+			replies = append(replies, []any{[]byte("subscribe"), []byte(channel), int64(len(state.subs))})
+		}
+		s.mu.Unlock()
+		return replies
+	case "UNSUBSCRIBE":
+		return s.unsubscribe(state, args[1:])
+	case "PUBLISH":
+		s.mu.Lock()
+		receivers := make([]*redisStubConn, 0, len(s.subscribers[args[1]]))
+		for c := range s.subscribers[args[1]] {
+			receivers = append(receivers, c)
+		}
+		s.mu.Unlock()
+		msg := []any{[]byte("message"), []byte(args[1]), []byte(args[2])}
+		for _, c := range receivers {
+			c.wmu.Lock()
+			writeRedisReply(c.wr, msg)
+			_ = c.wr.Flush()
+			c.wmu.Unlock()
+		}
+		return []any{int64(len(receivers))}
+	case "PING":
+		if len(state.subs) > 0 {
+			return []any{[]any{[]byte("pong"), []byte("")}}
+		}
+		return []any{"PONG"}
+	case "MULTI":
+		state.multi = true
+		state.queued = nil
+		return []any{"OK"}
+	case "DISCARD":
+		state.multi = false
+		state.queued = nil
+		state.watched = make(map[string]uint64)
+		return []any{"OK"}
+	case "WATCH":
+		s.mu.Lock()
+		for _, key := range args[1:] {
+			s.expire(key)
+			state.watched[key] = s.versions[key]
+		}
+		s.mu.Unlock()
+		return []any{"OK"}
+	case "UNWATCH":
+		state.watched = make(map[string]uint64)
+		return []any{"OK"}
+	case "EXEC":
+		s.mu.Lock()
+		aborted := false
+		for key, version := range state.watched {
+			s.expire(key)
+			if s.versions[key] != version {
+				aborted = true
+			}
+		}
+		var replies []any
+		if !aborted {
+			for _, queued := range state.queued {
+				replies = append(replies, s.exec(queued))
+			}
+		}
+		s.mu.Unlock()
+		state.multi = false
+		state.queued = nil
+		state.watched = make(map[string]uint64)
+		if aborted {
+			return []any{redisRawReply("*-1\r\n")}
+		}
+		return []any{replies}
+	default:
+		if state.multi {
+			state.queued = append(state.queued, args)
+			return []any{"QUEUED"}
+		}
+		s.mu.Lock()
+		re := s.exec(args)
+		s.mu.Unlock()
+		return []any{re}
+	}
+}
+
+// unsubscribe 取消订阅，channels为空时取消所有订阅
+func (s *redisStub) unsubscribe(state *redisStubConn, channels []string) []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(channels) == 0 {
+		for channel := range state.subs {
+			channels = append(channels, channel)
+		}
+	}
+	var replies []any
+	for _, channel := range channels {
+		delete(state.subs, channel)
+		delete(s.subscribers[channel], state)
+		replies = append(replies, []any{[]byte("unsubscribe"), []byte(channel), int64(len(state.subs))})
+	}
+	return replies
+}
+
+// exec 执行数据命令，调用者持有锁
+func (s *redisStub) exec(args []string) any {
+	cmd := strings.ToUpper(args[0])
+	if len(args) > 1 {
+		s.expire(args[1])
+	}
+	switch cmd {
+	case "GET":
+		if v, ok := s.values[args[1]]; ok {
+			return []byte(v)
+		}
+		return nil
+	case "MGET":
+		values := make([]any, 0, len(args)-1)
+		for _, key := range args[1:] {
+			s.expire(key)
+			if v, ok := s.values[key]; ok {
+				values = append(values, []byte(v))
+			} else {
+				values = append(values, nil)
+			}
+		}
+		return values
+	case "SETNX":
+		if _, ok := s.values[args[1]]; ok {
+			return int64(0)
+		}
+		s.write(args[1], args[2], 0)
+		return int64(1)
+	case "SET":
+		var (
+			nx, xx bool
+			ttl    time.Duration
+		)
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "XX":
+				xx = true
+			case "PX", "EX":
+				i++
+				n, _ := strconv.ParseInt(args[i], 10, 64)
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToUpper(args[i-1]) == "EX" {
+					ttl = time.Duration(n) * time.Second
+				}
+			}
+		}
+		_, exists := s.values[args[1]]
+		if (nx && exists) || (xx && !exists) {
+			return nil
+		}
+		s.write(args[1], args[2], ttl)
+		return "OK"
+	case "DEL":
+		var n int64
+		for _, key := range args[1:] {
+			s.expire(key)
+			if _, ok := s.values[key]; ok {
+				s.remove(key)
+				n++
+			}
+		}
+		return n
+	case "EXISTS":
+		var n int64
+		for _, key := range args[1:] {
+			s.expire(key)
+			if _, ok := s.values[key]; ok {
+				n++
+			}
+		}
+		return n
+	case "HSET":
+		h := s.hashes[args[1]]
+		if h == nil {
+			h = make(map[string]string)
+			s.hashes[args[1]] = h
+		}
+		var n int64
+		for i := 2; i+1 < len(args); i += 2 {
+			if _, ok := h[args[i]]; !ok {
+				n++
+			}
+			h[args[i]] = args[i+1]
+		}
+		s.versions[args[1]]++
+		return n
+	case "HGET":
+		if v, ok := s.hashes[args[1]][args[2]]; ok {
+			return []byte(v)
+		}
+		return nil
+	case "HDEL":
+		var n int64
+		for _, field := range args[2:] {
+			if _, ok := s.hashes[args[1]][field]; ok {
+				delete(s.hashes[args[1]], field)
+				n++
+			}
+		}
+		if len(s.hashes[args[1]]) == 0 {
+			delete(s.hashes, args[1])
+		}
+		s.versions[args[1]]++
+		return n
+	case "HGETALL":
+		values := make([]any, 0, 2*len(s.hashes[args[1]]))
+		for field, v := range s.hashes[args[1]] {
+			values = append(values, []byte(field), []byte(v))
+		}
+		return values
+	case "ZADD":
+		z := s.zsets[args[1]]
+		if z == nil {
+			z = make(map[string]float64)
+			s.zsets[args[1]] = z
+		}
+		var n int64
+		for i := 2; i+1 < len(args); i += 2 {
+			score, _ := strconv.ParseFloat(args[i], 64)
+			if _, ok := z[args[i+1]]; !ok {
+				n++
+			}
+			z[args[i+1]] = score
+		}
+		s.versions[args[1]]++
+		return n
+	case "ZREM":
+		var n int64
+		for _, member := range args[2:] {
+			if _, ok := s.zsets[args[1]][member]; ok {
+				delete(s.zsets[args[1]], member)
+				n++
+			}
+		}
+		s.versions[args[1]]++
+		return n
+	case "ZSCORE":
+		if score, ok := s.zsets[args[1]][args[2]]; ok {
+			return []byte(strconv.FormatFloat(score, 'f', -1, 64))
+		}
+		return nil
+	case "ZRANGEBYSCORE":
+		return s.zrangeByScore(args)
+	case "EVAL", "EVALSHA":
+		sha := args[1]
+		if cmd == "EVAL" {
+			sha = fmt.Sprintf("%x", sha1.Sum([]byte(args[1])))
+		}
+		script, ok := s.scripts[sha]
+		if !ok {
+			return errors.New("NOSCRIPT No matching script")
+		}
+		n, _ := strconv.Atoi(args[2])
+		return script(s, args[3:3+n], args[3+n:])
+	case "INCR":
+		n, _ := strconv.ParseInt(s.values[args[1]], 10, 64)
+		n++
+		s.values[args[1]] = strconv.FormatInt(n, 10)
+		s.versions[args[1]]++
+		return n
+	case "PEXPIRE":
+		if _, ok := s.values[args[1]]; !ok {
+			return int64(0)
+		}
+		ms, _ := strconv.ParseInt(args[2], 10, 64)
+		s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		s.versions[args[1]]++
+		return int64(1)
+	case "PTTL":
+		if _, ok := s.values[args[1]]; !ok {
+			return int64(-2)
+		}
+		at, ok := s.expires[args[1]]
+		if !ok {
+			return int64(-1)
+		}
+		return time.Until(at).Milliseconds()
+	}
+	return fmt.Errorf("ERR unknown command '%s'", args[0])
+}
+
+// zrangeByScore ZRANGEBYSCORE key min max [LIMIT offset count]，按分数和成员升序返回成员
+func (s *redisStub) zrangeByScore(args []string) any {
+	bound := func(v string, inf float64) float64 {
+		switch v {
+		case "-inf":
+			return -inf
+		case "+inf":
+			return inf
+		}
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	min, max := bound(args[2], math.MaxFloat64), bound(args[3], math.MaxFloat64)
+	offset, count := 0, -1
+	for i := 4; i < len(args); i++ {
+		if strings.ToUpper(args[i]) == "LIMIT" && i+2 < len(args) {
+			offset, _ = strconv.Atoi(args[i+1])
+			count, _ = strconv.Atoi(args[i+2])
+			i += 2
+		}
+	}
+
+	members := make([]string, 0)
+	z := s.zsets[args[1]]
+	for member, score := range z {
+		if score >= min && score <= max {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if z[members[i]] != z[members[j]] {
+			return z[members[i]] < z[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	values := make([]any, 0)
+	for i := offset; i < len(members) && (count < 0 || len(values) < count); i++ {
+		values = append(values, []byte(members[i]))
+	}
+	return values
+}
+
+func (s *redisStub) write(key, value string, ttl time.Duration) {
+	s.values[key] = value
+	delete(s.expires, key)
+	if ttl > 0 {
+		s.expires[key] = time.Now().Add(ttl)
+	}
+	s.versions[key]++
+}
+
+func (s *redisStub) remove(key string) {
+	delete(s.values, key)
+	delete(s.expires, key)
+	s.versions[key]++
+}
+
+// expire 惰性删除已过期的key
+func (s *redisStub) expire(key string) {
+	if at, ok := s.expires[key]; ok && !time.Now().Before(at) {
+		s.remove(key)
+	}
+}
+
+func readRedisCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected line: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	if n == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+func writeRedisReply(wr *bufio.Writer, re any) {
+	switch v := re.(type) {
+	case nil:
+		_, _ = wr.WriteString("$-1\r\n")
+	case string:
+		_, _ = wr.WriteString("+" + v + "\r\n")
+	case redisRawReply:
+		_, _ = wr.WriteString(string(v))
+	case []byte:
+		_, _ = fmt.Fprintf(wr, "$%d\r\n%s\r\n", len(v), v)
+	case int64:
+		_, _ = fmt.Fprintf(wr, ":%d\r\n", v)
+	case error:
+		_, _ = wr.WriteString("-" + v.Error() + "\r\n")
+	case []any:
+		_, _ = fmt.Fprintf(wr, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeRedisReply(wr, item)
+		}
+	}
+}
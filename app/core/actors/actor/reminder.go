@@ -0,0 +1,445 @@
+package actor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+/*
+	ReminderService 持久化的Actor提醒，与 TimerMgr 的内存定时器不同，Actor被动化、崩溃或节点重启后提醒仍然有效
+	缓存结构:
+	actor_reminders:{actorName} -> hash{reminderName: Reminder(JSON)}
+	actor_reminder_due          -> zset{actorName|reminderName: 下一次触发时间(毫秒)}
+
+	1. Actor通过 IContext.RegisterReminder 注册，名称相同且计划与负载都相同的重复注册不会改变下一次触发时间，
+	   名称相同的一次性提醒已存在时保留原有的提醒，因此可以在每次激活的 HandleInit 中注册，
+	   需要以新的计划覆盖时使用 WithReminderReplace
+	2. 各节点定期扫描到期的提醒，只有Actor解析到本节点时才触发，Actor不在线时被激活
+	3. 触发前通过 WATCH/MULTI 推进下一次触发时间，只有一个节点能够认领同一次触发，保证重启后不会重复触发
+	4. 停机期间错过的多次触发合并为一次，Actor通过 ReminderMessage.Scheduled 得知原定时间
+	5. 提醒以 ReminderMessage 作为Send消息投递，由 Behavior.HandleSend 处理，至多投递一次
+*/
+
+const (
+	reminderDueKey        = "actor_reminder_due"
+	reminderMemberSep     = "|"
+	DefaultReminderPoll   = time.Second
+	defaultReminderBatch  = 256
+	reminderClaimDeadline = 5 * time.Second
+)
+
+type ReminderKind int8
+
+const (
+	ReminderKindOnce   ReminderKind = iota // 在 At 时触发一次
+	ReminderKindDaily                      // 每天 Hour:Minute 触发
+	ReminderKindWeekly                     // 每周 Weekday Hour:Minute 触发
+)
+
+// ReminderSchedule 提醒的触发计划，使用墙上时间
+type ReminderSchedule struct {
+	Kind     ReminderKind `json:"kind"`
+	At       time.Time    `json:"at,omitempty"`
+	Weekday  time.Weekday `json:"weekday,omitempty"`
+	Hour     int          `json:"hour,omitempty"`
+	Minute   int          `json:"minute,omitempty"`
+	Location string       `json:"location,omitempty"` // IANA时区名，为空时使用本地时区
+}
+
+// ReminderAt 在指定时间触发一次
+func ReminderAt(at time.Time) ReminderSchedule {
+	return ReminderSchedule{Kind: ReminderKindOnce, At: at}
+}
+
+// ReminderAfter 在注册时间之后d触发一次
+// 重复注册时已存在的同名一次性提醒生效，不会因注册时间不同而推迟，使用 WithReminderReplace 重新计时
+func ReminderAfter(d time.Duration) ReminderSchedule {
+	return ReminderAt(time.Now().Add(d))
+}
+
+// ReminderDaily 每天在指定时区的 hour:minute 触发，loc为nil时使用本地时区，loc需要能通过 time.LoadLocation 加载
+func ReminderDaily(hour, minute int, loc *time.Location) ReminderSchedule {
+	return ReminderSchedule{Kind: ReminderKindDaily, Hour: hour, Minute: minute, Location: locationName(loc)}
+}
+
+// ReminderWeekly 每周在指定时区的 weekday hour:minute 触发，loc为nil时使用本地时区
+func ReminderWeekly(weekday time.Weekday, hour, minute int, loc *time.Location) ReminderSchedule {
+	return ReminderSchedule{Kind: ReminderKindWeekly, Weekday: weekday, Hour: hour, Minute: minute, Location: locationName(loc)}
+}
+
+func locationName(loc *time.Location) string {
+	if loc == nil || loc == time.Local {
+		return ""
+	}
+	return loc.String()
+}
+
+func (s ReminderSchedule) location() (*time.Location, error) {
+	if s.Location == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(s.Location)
+}
+
+// Next 返回晚于after的下一次触发时间，一次性提醒返回 At
+// 没有下一次或时区无法加载(例如 time.FixedZone)时返回false
+func (s ReminderSchedule) Next(after time.Time) (time.Time, bool) {
+	switch s.Kind {
+	case ReminderKindOnce:
+		return s.At, !s.At.IsZero()
+	case ReminderKindDaily, ReminderKindWeekly:
+		loc, err := s.location()
+		if err != nil {
+			return time.Time{}, false
+		}
+		local := after.In(loc)
+		next := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, local.Location())
+		if s.Kind == ReminderKindWeekly {
+			next = next.AddDate(0, 0, (int(s.Weekday)-int(next.Weekday())+7)%7)
+		}
+		if !next.After(after) {
+			if s.Kind == ReminderKindWeekly {
+				next = next.AddDate(0, 0, 7)
+			} else {
+				next = next.AddDate(0, 0, 1)
+			}
+		}
+		return next, true
+	}
+	return time.Time{}, false
+}
+
+func (s ReminderSchedule) equal(other ReminderSchedule) bool {
+	return s.Kind == other.Kind && s.At.Equal(other.At) && s.Weekday == other.Weekday &&
+		s.Hour == other.Hour && s.Minute == other.Minute && s.Location == other.Location
+}
+
+// Reminder 持久化的提醒
+type Reminder struct {
+	Name      string           `json:"name"`
+	ActorName string           `json:"actor_name"`
+	Pattern   string           `json:"pattern"`
+	Schedule  ReminderSchedule `json:"schedule"`
+	Payload   []byte           `json:"payload,omitempty"`
+	Next      time.Time        `json:"next"`
+}
+
+// ReminderMessage 提醒触发时投递给Actor的消息
+type ReminderMessage struct {
+	Name      string
+	Payload   []byte
+	Scheduled time.Time // 原定的触发时间
+}
+
+type ReminderOption func(s *ReminderService)
+
+// WithReminderPollInterval 设置扫描到期提醒的间隔
+func WithReminderPollInterval(interval time.Duration) ReminderOption {
+	return func(s *ReminderService) {
+		if interval > 0 {
+			s.interval = interval
+		}
+	}
+}
+
+type RegisterReminderOption func(o *registerReminderOptions)
+
+type registerReminderOptions struct {
+	replace bool
+}
+
+// WithReminderReplace 名称相同的提醒已存在时总是以新的计划和负载替换，并重新计算下一次触发时间
+func WithReminderReplace() RegisterReminderOption {
+	return func(o *registerReminderOptions) {
+		o.replace = true
+	}
+}
+
+// ReminderService 持久化提醒服务
+type ReminderService struct {
+	cli      *redis.Client
+	interval time.Duration
+	system   *ActorSystem
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewReminderService(cli *redis.Client, ops ...ReminderOption) *ReminderService {
+	s := &ReminderService{
+		cli:      cli,
+		interval: DefaultReminderPoll,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, op := range ops {
+		op(s)
+	}
+	return s
+}
+
+// sys 返回提醒服务所属的ActorSystem，单独创建的服务使用默认的 System
+func (s *ReminderService) sys() *ActorSystem {
+	if s.system != nil {
+		return s.system
+	}
+	return System
+}
+
+// Start 开始扫描到期的提醒
+func (s *ReminderService) Start() {
+	go s.loop()
+}
+
+// Stop 停止扫描，已认领的提醒投递完成后返回
+func (s *ReminderService) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+}
+
+func (s *ReminderService) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.poll(time.Now())
+		}
+	}
+}
+
+// Register 注册提醒，名称相同且计划与负载都相同时保持原有的下一次触发时间
+// 名称相同的一次性提醒已存在时保留原有的提醒，指定 WithReminderReplace 时总是替换
+func (s *ReminderService) Register(actorName, pattern, name string, schedule ReminderSchedule, payload []byte, ops ...RegisterReminderOption) error {
+	if name == "" || strings.Contains(name, reminderMemberSep) {
+		return ErrReminderName
+	}
+	next, ok := schedule.Next(time.Now())
+	if !ok {
+		return ErrReminderSchedule
+	}
+	var o registerReminderOptions
+	for _, op := range ops {
+		op(&o)
+	}
+
+	ctx := context.Background()
+	key := genReminderKey(actorName)
+	return s.cli.Watch(ctx, func(tx *redis.Tx) error {
+		existing, err := s.load(ctx, tx, actorName, name)
+		if err != nil {
+			return err
+		}
+		if existing != nil && !o.replace && existing.keep(schedule, payload) {
+			return nil
+		}
+
+		content, err := json.Marshal(&Reminder{
+			Name:      name,
+			ActorName: actorName,
+			Pattern:   pattern,
+			Schedule:  schedule,
+			Payload:   payload,
+			Next:      next,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, name, content)
+			pipe.ZAdd(ctx, reminderDueKey, redis.Z{Score: float64(next.UnixMilli()), Member: genReminderMember(actorName, name)})
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// Unregister 删除提醒
+func (s *ReminderService) Unregister(actorName, name string) error {
+	ctx := context.Background()
+	_, err := s.cli.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, genReminderKey(actorName), name)
+		pipe.ZRem(ctx, reminderDueKey, genReminderMember(actorName, name))
+		return nil
+	})
+	return err
+}
+
+// Reminders 返回Actor注册的所有提醒
+func (s *ReminderService) Reminders(actorName string) ([]*Reminder, error) {
+	values, err := s.cli.HGetAll(context.Background(), genReminderKey(actorName)).Result()
+	if err != nil {
+		return nil, err
+	}
+	reminders := make([]*Reminder, 0, len(values))
+	for _, content := range values {
+		r := &Reminder{}
+		if err = json.Unmarshal([]byte(content), r); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, r)
+	}
+	return reminders, nil
+}
+
+// poll 触发所有到期的提醒
+func (s *ReminderService) poll(now time.Time) {
+	ctx := context.Background()
+	members, err := s.cli.ZRangeByScore(ctx, reminderDueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: defaultReminderBatch,
+	}).Result()
+	if err != nil {
+		logger.GetLogger().Error("[Reminder] scan due reminders failed", zap.Error(err))
+		return
+	}
+	for _, member := range members {
+		i := strings.LastIndex(member, reminderMemberSep)
+		if i < 0 {
+			continue
+		}
+		if err = s.fire(ctx, member[:i], member[i+1:], now); err != nil {
+			logger.GetLogger().Error("[Reminder] fire reminder failed",
+				zap.String("ActorName", member[:i]),
+				zap.String("Reminder", member[i+1:]),
+				zap.Error(err))
+		}
+	}
+}
+
+// fire 认领并触发一次到期的提醒，Actor不在本节点时由所在节点触发
+func (s *ReminderService) fire(ctx context.Context, actorName, name string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, reminderClaimDeadline)
+	defer cancel()
+
+	r, err := s.load(ctx, s.cli, actorName, name)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return s.cli.ZRem(ctx, reminderDueKey, genReminderMember(actorName, name)).Err()
+	}
+	if r.Next.After(now) {
+		return nil
+	}
+
+	ref := s.sys().NewActorRef(NewProps(), r.ActorName, r.Pattern)
+	if nodeId, err := ref.remoteNode(); err != nil || nodeId != "" {
+		return err
+	}
+
+	claimed, err := s.claim(ctx, r, now)
+	if err != nil || !claimed {
+		return err
+	}
+	return ref.Send(&ReminderMessage{Name: r.Name, Payload: r.Payload, Scheduled: r.Next})
+}
+
+// claim 推进提醒的下一次触发时间，其他节点已经认领时返回false
+func (s *ReminderService) claim(ctx context.Context, r *Reminder, now time.Time) (bool, error) {
+	key := genReminderKey(r.ActorName)
+	member := genReminderMember(r.ActorName, r.Name)
+	err := s.cli.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := s.load(ctx, tx, r.ActorName, r.Name)
+		if err != nil {
+			return err
+		}
+		if current == nil || !current.Next.Equal(r.Next) {
+			return redis.TxFailedErr
+		}
+
+		next, ok := r.Schedule.Next(now)
+		if r.Schedule.Kind == ReminderKindOnce {
+			ok = false
+		}
+		var content []byte
+		if ok {
+			updated := *r
+			updated.Next = next
+			if content, err = json.Marshal(&updated); err != nil {
+				return err
+			}
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if !ok {
+				pipe.HDel(ctx, key, r.Name)
+				pipe.ZRem(ctx, reminderDueKey, member)
+				return nil
+			}
+			pipe.HSet(ctx, key, r.Name, content)
+			pipe.ZAdd(ctx, reminderDueKey, redis.Z{Score: float64(next.UnixMilli()), Member: member})
+			return nil
+		})
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// keep 重复注册时是否保留已存在的提醒
+func (r *Reminder) keep(schedule ReminderSchedule, payload []byte) bool {
+	if r.Schedule.Kind == ReminderKindOnce && schedule.Kind == ReminderKindOnce {
+		return true
+	}
+	return r.Schedule.equal(schedule) && bytes.Equal(r.Payload, payload)
+}
+
+func (s *ReminderService) load(ctx context.Context, cmd redis.Cmdable, actorName, name string) (*Reminder, error) {
+	content, err := cmd.HGet(ctx, genReminderKey(actorName), name).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := &Reminder{}
+	if err = json.Unmarshal(content, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func genReminderKey(actorName string) string {
+	return "actor_reminders:" + actorName
+}
+
+func genReminderMember(actorName, name string) string {
+	return actorName + reminderMemberSep + name
+}
+
+// RegisterReminder 为当前Actor注册持久化提醒
+func (state *ChildActor) RegisterReminder(name string, schedule ReminderSchedule, payload []byte, ops ...RegisterReminderOption) error {
+	reminders := state.GetSystem().Reminders()
+	if reminders == nil {
+		return ErrRemindersNotStarted
+	}
+	return reminders.Register(state.actorName, state.pattern, name, schedule, payload, ops...)
+}
+
+// UnregisterReminder 删除当前Actor的提醒
+func (state *ChildActor) UnregisterReminder(name string) error {
+	reminders := state.GetSystem().Reminders()
+	if reminders == nil {
+		return ErrRemindersNotStarted
+	}
+	return reminders.Unregister(state.actorName, name)
+}
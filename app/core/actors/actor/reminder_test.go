@@ -0,0 +1,262 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type reminderJoin struct {
+	name     string
+	schedule ReminderSchedule
+}
+
+// ReminderBehavior 按请求注册提醒，记录收到的提醒
+type ReminderBehavior struct {
+	mu    sync.Mutex
+	fired []*ReminderMessage
+}
+
+func (b *ReminderBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	m := msg.(*reminderJoin)
+	return nil, ctx.RegisterReminder(m.name, m.schedule, []byte(m.name))
+}
+
+func (b *ReminderBehavior) HandleSend(ctx IContext, msg any) {
+	if m, ok := msg.(*ReminderMessage); ok {
+		b.mu.Lock()
+		b.fired = append(b.fired, m)
+		b.mu.Unlock()
+	}
+}
+
+func (b *ReminderBehavior) Fired() []*ReminderMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*ReminderMessage(nil), b.fired...)
+}
+
+func (b *ReminderBehavior) HandleForward(ctx IContext, _ any) {}
+
+func (b *ReminderBehavior) HandleInit(ctx IContext) error { return nil }
+
+func (b *ReminderBehavior) HandleStopping(ctx IContext) error { return nil }
+
+func (b *ReminderBehavior) HandleStopped(ctx IContext) error { return nil }
+
+func TestReminderSchedule_Next(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, loc) // 星期三
+
+	next, ok := ReminderDaily(5, 0, loc).Next(now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 5, 2, 5, 0, 0, 0, loc), next)
+	next, _ = ReminderDaily(12, 0, loc).Next(now)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 0, loc), next)
+	next, _ = ReminderDaily(10, 30, loc).Next(now)
+	assert.Equal(t, time.Date(2024, 5, 2, 10, 30, 0, 0, loc), next, "next must be strictly after now")
+
+	next, _ = ReminderWeekly(time.Monday, 0, 0, loc).Next(now)
+	assert.Equal(t, time.Date(2024, 5, 6, 0, 0, 0, 0, loc), next)
+	next, _ = ReminderWeekly(time.Wednesday, 9, 0, loc).Next(now)
+	assert.Equal(t, time.Date(2024, 5, 8, 9, 0, 0, 0, loc), next)
+
+	// 时区不同时按计划的时区计算
+	next, _ = ReminderDaily(0, 0, time.UTC).Next(now)
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), next.UTC())
+
+	_, ok = ReminderAt(time.Time{}).Next(now)
+	assert.False(t, ok)
+	_, ok = ReminderDaily(0, 0, time.FixedZone("UTC+8", 8*3600)).Next(now)
+	assert.False(t, ok, "fixed zones cannot be persisted")
+}
+
+func TestReminder_RegisterIdempotent(t *testing.T) {
+	_, cli := newRedisStub(t)
+	s := NewReminderService(cli)
+
+	schedule := ReminderDaily(5, 0, nil)
+	assert.NoError(t, s.Register("player-1", "player", "daily-reset", schedule, []byte("a")))
+	first, err := s.Reminders("player-1")
+	assert.NoError(t, err)
+	assert.Len(t, first, 1)
+
+	// 相同的计划和负载不改变下一次触发时间
+	assert.NoError(t, s.Register("player-1", "player", "daily-reset", schedule, []byte("a")))
+	again, err := s.Reminders("player-1")
+	assert.NoError(t, err)
+	assert.Equal(t, first[0].Next, again[0].Next)
+
+	assert.NoError(t, s.Register("player-1", "player", "daily-reset", ReminderDaily(6, 0, nil), []byte("a")))
+	changed, err := s.Reminders("player-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 6, changed[0].Next.Hour())
+
+	// 同名的一次性提醒已存在时保留原有的触发时间，WithReminderReplace 重新计时
+	assert.NoError(t, s.Register("player-1", "player", "energy-full", ReminderAfter(time.Hour), nil))
+	next := reminderNext(t, s, "player-1", "energy-full")
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, s.Register("player-1", "player", "energy-full", ReminderAfter(time.Hour), nil))
+	assert.True(t, next.Equal(reminderNext(t, s, "player-1", "energy-full")))
+	assert.NoError(t, s.Register("player-1", "player", "energy-full", ReminderAfter(time.Hour), nil, WithReminderReplace()))
+	assert.True(t, reminderNext(t, s, "player-1", "energy-full").After(next))
+	assert.NoError(t, s.Unregister("player-1", "energy-full"))
+
+	assert.True(t, errors.Is(s.Register("player-1", "player", "a|b", schedule, nil), ErrReminderName))
+	assert.True(t, errors.Is(s.Register("player-1", "player", "once", ReminderAt(time.Time{}), nil), ErrReminderSchedule))
+
+	assert.NoError(t, s.Unregister("player-1", "daily-reset"))
+	empty, err := s.Reminders("player-1")
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func reminderNext(t *testing.T, s *ReminderService, actorName, name string) time.Time {
+	reminders, err := s.Reminders(actorName)
+	assert.NoError(t, err)
+	for _, r := range reminders {
+		if r.Name == name {
+			return r.Next
+		}
+	}
+	t.Fatalf("reminder %s not found", name)
+	return time.Time{}
+}
+
+func TestReminder_FireActivatesActor(t *testing.T) {
+	const pattern = "reminder-player-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	behaviors := make(map[string]*ReminderBehavior)
+	var mu sync.Mutex
+	RegFactory(pattern, func(actorName string) Behavior {
+		mu.Lock()
+		defer mu.Unlock()
+		behaviors[actorName] = &ReminderBehavior{}
+		return behaviors[actorName]
+	})
+	fired := func(name string) []*ReminderMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		if b := behaviors[name]; b != nil {
+			return b.Fired()
+		}
+		return nil
+	}
+
+	ref := NewActorRef(NewProps(), "reminder-player-1", pattern)
+	_, err := ref.RequestFuture(&reminderJoin{name: "x"})
+	assert.True(t, errors.Is(err, ErrRemindersNotStarted))
+
+	_, cli := newRedisStub(t)
+	assert.NoError(t, System.StartReminders(cli, WithReminderPollInterval(20*time.Millisecond)))
+
+	_, err = ref.RequestFuture(&reminderJoin{name: "energy-full", schedule: ReminderAfter(200 * time.Millisecond)})
+	assert.NoError(t, err)
+
+	// Actor停止后提醒到期时重新激活
+	ref.Stop()
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist("reminder-player-1")
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return len(fired("reminder-player-1")) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	msg := fired("reminder-player-1")[0]
+	assert.Equal(t, "energy-full", msg.Name)
+	assert.Equal(t, []byte("energy-full"), msg.Payload)
+
+	// 一次性提醒触发后被删除，不再重复触发
+	reminders, err := System.Reminders().Reminders("reminder-player-1")
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, fired("reminder-player-1"), 1)
+}
+
+func TestReminder_ClaimOnce(t *testing.T) {
+	const pattern = "reminder-claim-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	behavior := &ReminderBehavior{}
+	RegFactory(pattern, func(actorName string) Behavior {
+		return behavior
+	})
+
+	_, cli := newRedisStub(t)
+	a, b := NewReminderService(cli), NewReminderService(cli)
+	scheduled := time.Now().Add(-time.Hour)
+	assert.NoError(t, a.Register("reminder-claim-1", pattern, "weekly", ReminderWeekly(scheduled.Weekday(), scheduled.Hour(), scheduled.Minute(), nil), nil))
+
+	// 模拟停机期间错过了触发时间，两个节点同时扫描只有一个能认领
+	reminders, err := a.Reminders("reminder-claim-1")
+	assert.NoError(t, err)
+	now := reminders[0].Next.Add(time.Minute)
+	var wg sync.WaitGroup
+	for _, s := range []*ReminderService{a, b} {
+		wg.Add(1)
+		go func(s *ReminderService) {
+			defer wg.Done()
+			s.poll(now)
+		}(s)
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		return len(behavior.Fired()) == 1
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, behavior.Fired(), 1)
+	assert.Equal(t, reminders[0].Next, behavior.Fired()[0].Scheduled)
+
+	// 周期提醒推进到下一周
+	reminders, err = a.Reminders("reminder-claim-1")
+	assert.NoError(t, err)
+	assert.True(t, reminders[0].Next.After(now))
+	a.poll(now)
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, behavior.Fired(), 1)
+}
+
+// Actor运行期间启用提醒，Actor协程中的注册与启用并发执行
+func TestReminder_StartWhileActorsRunning(t *testing.T) {
+	const pattern = "reminder-start-pattern"
+	factories := NewFactoryRegistry()
+	factories.Reg(pattern, func(actorName string) Behavior {
+		return &ReminderBehavior{}
+	})
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		ref := af.NewActorRef(NewProps(), "reminder-start-"+strconv.Itoa(i), pattern)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := ref.RequestFuture(&reminderJoin{name: "energy-full", schedule: ReminderAfter(time.Hour)})
+				if err != nil {
+					assert.True(t, errors.Is(err, ErrRemindersNotStarted))
+				}
+			}
+		}()
+	}
+
+	_, cli := newRedisStub(t)
+	assert.NoError(t, af.StartReminders(cli))
+	started := af.Reminders()
+	assert.NoError(t, af.StartReminders(cli), "starting twice keeps the running service")
+	assert.Same(t, started, af.Reminders())
+	wg.Wait()
+}
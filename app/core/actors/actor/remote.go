@@ -0,0 +1,651 @@
+package actor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitee.com/orbit-w/meteor/bases/misc/utils"
+	"gitee.com/orbit-w/meteor/modules/net/network"
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"gitee.com/orbit-w/orbit/lib/tracing"
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/orbit-w/mux-go"
+	"go.uber.org/zap"
+)
+
+// NodeConfig 节点配置
+type NodeConfig struct {
+	NodeId string
+	Addr   string // host:port
+	Region string // 所属区域，用于 DISPATCHER_TYPE_IN_REGION 放置
+}
+
+// NodeDiscovery 节点发现，根据节点ID查找节点地址
+type NodeDiscovery interface {
+	Lookup(nodeId string) (addr string, ok bool)
+	Nodes() []NodeConfig
+}
+
+// StaticDiscovery 基于静态配置列表的节点发现
+type StaticDiscovery struct {
+	nodes []NodeConfig
+	addrs map[string]string
+}
+
+func NewStaticDiscovery(nodes ...NodeConfig) *StaticDiscovery {
+	d := &StaticDiscovery{
+		nodes: nodes,
+		addrs: make(map[string]string, len(nodes)),
+	}
+	for _, node := range nodes {
+		d.addrs[node.NodeId] = node.Addr
+	}
+	return d
+}
+
+func (d *StaticDiscovery) Lookup(nodeId string) (string, bool) {
+	addr, ok := d.addrs[nodeId]
+	return addr, ok
+}
+
+func (d *StaticDiscovery) Nodes() []NodeConfig {
+	return d.nodes
+}
+
+// RemoteConfig 跨节点通信配置
+type RemoteConfig struct {
+	NodeId    string        // 当前节点ID
+	Addr      string        // 监听地址，为空时使用 Discovery 中当前节点的地址
+	Discovery NodeDiscovery // 节点发现，同时作为允许连接的节点列表
+	Secret    string        // 集群共享密钥，建立连接时校验，为空时只校验对端是否为 Discovery 中的节点
+}
+
+// Remote 跨节点通信层
+//
+//  1. 每个节点启动一个mux-go服务，接收其他节点转发的消息并投递到本地Actor
+//  2. 向每个远程节点维持一条多路复用连接，连接断开后下一次发送时重新建立
+//  3. 消息内容使用 pb 包生成的协议ID序列化，只有协议ID表中的protobuf消息可以跨节点发送
+//  4. ActorRef 根据 Meta.Dispatcher.NodeId 判断Actor所在节点，非当前节点的消息自动经由Remote转发
+//  5. 连接建立后先发送握手帧，服务端只接受 Discovery 中的节点，配置了 Secret 时同时校验共享密钥
+type Remote struct {
+	nodeId    string
+	addr      string
+	secret    string
+	discovery NodeDiscovery
+	server    *mux.Server
+	stopped   atomic.Bool
+
+	mu      sync.Mutex
+	clients map[string]*remoteClient
+	system  *ActorSystem
+}
+
+func NewRemote(conf *RemoteConfig) *Remote {
+	discovery := conf.Discovery
+	if discovery == nil {
+		discovery = NewStaticDiscovery()
+	}
+	addr := conf.Addr
+	if addr == "" {
+		addr, _ = discovery.Lookup(conf.NodeId)
+	}
+	return &Remote{
+		nodeId:    conf.NodeId,
+		addr:      addr,
+		secret:    conf.Secret,
+		discovery: discovery,
+		clients:   make(map[string]*remoteClient),
+	}
+}
+
+// sys 返回接收消息的ActorSystem，单独创建的Remote使用默认的 System
+func (r *Remote) sys() *ActorSystem {
+	if r.system != nil {
+		return r.system
+	}
+	return System
+}
+
+func (r *Remote) Start() error {
+	server := new(mux.Server)
+	if err := server.Serve(r.addr, r.serve); err != nil {
+		return err
+	}
+	r.server = server
+	logger.GetLogger().Info("[Remote] server listened...", zap.String("NodeId", r.nodeId), zap.String("Addr", server.Addr()))
+	return nil
+}
+
+func (r *Remote) Stop() error {
+	if !r.stopped.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = make(map[string]*remoteClient)
+	r.mu.Unlock()
+	for _, cli := range clients {
+		cli.close(ErrRemoteUnavailable)
+	}
+
+	if r.server != nil {
+		return r.server.Stop()
+	}
+	return nil
+}
+
+func (r *Remote) NodeId() string {
+	return r.nodeId
+}
+
+// Addr 返回服务实际监听的地址
+func (r *Remote) Addr() string {
+	if r.server != nil {
+		return r.server.Addr()
+	}
+	return r.addr
+}
+
+func (r *Remote) Discovery() NodeDiscovery {
+	return r.discovery
+}
+
+// Send 将Send/Forward消息转发到远程节点
+func (r *Remote) Send(nodeId string, msg *RequestMessage) error {
+	cli, err := r.client(nodeId)
+	if err != nil {
+		return err
+	}
+	return cli.send(&remoteFrame{
+		kind:      remoteFrameSend,
+		msgType:   msg.MsgType,
+		priority:  msg.Priority,
+		actorName: msg.ActorName,
+		pattern:   msg.Pattern,
+		message:   msg.Message,
+		trace:     msg.Trace,
+	})
+}
+
+// RequestFuture 向远程节点上的Actor发送请求并等待回复
+func (r *Remote) RequestFuture(nodeId, actorName, pattern string, msg any, timeout ...time.Duration) (any, error) {
+	return r.request(context.Background(), nodeId, actorName, pattern, msg, parseTimeout(timeout...))
+}
+
+// RequestFutureContext 以 ctx 剩余的时间作为远程节点处理请求的截止时间，取消不会传递到远程节点
+func (r *Remote) RequestFutureContext(ctx context.Context, nodeId, actorName, pattern string, msg any) (any, error) {
+	timeout, err := contextTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.request(ctx, nodeId, actorName, pattern, msg, timeout)
+}
+
+// request timeout 小于0时不设超时，帧中的timeout为0，远程节点使用默认超时
+func (r *Remote) request(ctx context.Context, nodeId, actorName, pattern string, msg any, timeout time.Duration) (any, error) {
+	cli, err := r.client(nodeId)
+	if err != nil {
+		return nil, err
+	}
+	msg, sc := untrace(msg)
+	if !sc.IsValid() {
+		sc = tracing.SpanContextFromContext(ctx)
+	}
+	var timeoutMs int64
+	if timeout > 0 {
+		timeoutMs = max(timeout.Milliseconds(), 1)
+	}
+	return cli.request(ctx, &remoteFrame{
+		kind:      remoteFrameRequest,
+		timeoutMs: timeoutMs,
+		actorName: actorName,
+		pattern:   pattern,
+		message:   msg,
+		trace:     sc,
+	}, timeout)
+}
+
+// forwardRequest 按发送顺序将Request转发到远程节点，远程节点在读取帧的协程中投递，与同一连接上的Send消息保持顺序
+// 帧发出后立即返回，收到回复、超时或调用方取消时在其他协程中调用 reply
+func (r *Remote) forwardRequest(nodeId string, msg *RequestMessage, reply func(re any, err error)) error {
+	cli, err := r.client(nodeId)
+	if err != nil {
+		return err
+	}
+	ctx, timeout := msg.remaining()
+	var timeoutMs int64
+	if timeout > 0 {
+		timeoutMs = max(timeout.Milliseconds(), 1)
+	}
+	reqId, ch, err := cli.post(&remoteFrame{
+		kind:      remoteFrameOrderedRequest,
+		timeoutMs: timeoutMs,
+		actorName: msg.ActorName,
+		pattern:   msg.Pattern,
+		message:   msg.Message,
+		trace:     msg.Trace,
+	})
+	if err != nil {
+		return err
+	}
+	utils.GoRecoverPanic(func() {
+		reply(cli.await(ctx, reqId, ch, timeout))
+	})
+	return nil
+}
+
+// Migrate 将迁移的Actor状态发送到远程节点，远程节点激活Actor后返回
+func (r *Remote) Migrate(nodeId, actorName, pattern string, state []byte, timeout time.Duration) error {
+	cli, err := r.client(nodeId)
+	if err != nil {
+		return err
+	}
+	_, err = cli.request(context.Background(), &remoteFrame{
+		kind:      remoteFrameMigrate,
+		timeoutMs: timeout.Milliseconds(),
+		actorName: actorName,
+		pattern:   pattern,
+		message:   state,
+	}, timeout)
+	return err
+}
+
+// StopActor 停止远程节点上的Actor
+func (r *Remote) StopActor(nodeId, actorName, pattern string) error {
+	cli, err := r.client(nodeId)
+	if err != nil {
+		return err
+	}
+	return cli.send(&remoteFrame{
+		kind:      remoteFrameStop,
+		actorName: actorName,
+		pattern:   pattern,
+	})
+}
+
+func (r *Remote) client(nodeId string) (*remoteClient, error) {
+	if r.stopped.Load() {
+		return nil, ErrRemoteUnavailable
+	}
+
+	r.mu.Lock()
+	cli, ok := r.clients[nodeId]
+	r.mu.Unlock()
+	if ok {
+		return cli, nil
+	}
+
+	addr, ok := r.discovery.Lookup(nodeId)
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	// 在锁外建立连接，避免一个节点不可达时阻塞发往其他节点的消息
+	// 连接断开后从缓存中移除，下一次发送时重新建立连接
+	hello := &remoteFrame{
+		kind:    remoteFrameHandshake,
+		nodeId:  r.nodeId,
+		message: remoteToken(r.secret, r.nodeId),
+	}
+	cli, err := dialRemote(nodeId, addr, hello, r.sys().RemoteErrors(), func(cli *remoteClient) {
+		r.mu.Lock()
+		if r.clients[nodeId] == cli {
+			delete(r.clients, nodeId)
+		}
+		r.mu.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped.Load() {
+		go cli.close(ErrRemoteUnavailable)
+		return nil, ErrRemoteUnavailable
+	}
+	if exist, ok := r.clients[nodeId]; ok {
+		// 并发建立了多个连接，保留先建立的连接
+		go cli.close(ErrRemoteUnavailable)
+		return exist, nil
+	}
+	r.clients[nodeId] = cli
+	return cli, nil
+}
+
+// serve 处理其他节点建立的虚拟连接
+// 第一帧必须是握手帧，校验通过后处理消息；同一连接上的Send消息和按顺序转发的Request按顺序投递，其他Request消息并发处理
+func (r *Remote) serve(conn mux.IServerConn) error {
+	if !r.handshake(conn) {
+		return nil
+	}
+	for {
+		in, err := conn.Recv(context.Background())
+		if err != nil {
+			if !network.IsClosedConnError(err) && !errors.Is(err, io.EOF) {
+				logger.GetLogger().Error("[Remote] read stream failed", zap.Error(err))
+			}
+			return nil
+		}
+
+		f, err := decodeRemoteFrame(in, r.sys().RemoteErrors())
+		if err != nil {
+			logger.GetLogger().Error("[Remote] decode frame failed", zap.Error(err))
+			if f != nil && (f.kind == remoteFrameRequest || f.kind == remoteFrameOrderedRequest) {
+				r.respond(conn, f.reqId, nil, err)
+			}
+			continue
+		}
+		r.handleFrame(conn, f)
+	}
+}
+
+// handshake 读取并校验握手帧，对端不是 Discovery 中的节点或密钥不匹配时回复 ErrRemoteUnauthorized
+func (r *Remote) handshake(conn mux.IServerConn) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteDialTimeout)
+	defer cancel()
+	in, err := conn.Recv(ctx)
+	if err != nil {
+		return false
+	}
+
+	f, err := decodeRemoteFrame(in, r.sys().RemoteErrors())
+	if err != nil || f.kind != remoteFrameHandshake {
+		logger.GetLogger().Warn("[Remote] reject connection without handshake")
+		return false
+	}
+	if err = r.authenticate(f.nodeId, f.message.([]byte)); err != nil {
+		logger.GetLogger().Warn("[Remote] reject connection", zap.String("NodeId", f.nodeId), zap.Error(err))
+	}
+	r.respond(conn, f.reqId, nil, err)
+	return err == nil
+}
+
+func (r *Remote) authenticate(nodeId string, token []byte) error {
+	if _, ok := r.discovery.Lookup(nodeId); !ok {
+		return ErrRemoteUnauthorized
+	}
+	if !hmac.Equal(token, remoteToken(r.secret, nodeId)) {
+		return ErrRemoteUnauthorized
+	}
+	return nil
+}
+
+// remoteToken 握手时证明持有共享密钥，只发送节点ID的HMAC，密钥本身不经过网络
+func remoteToken(secret, nodeId string) []byte {
+	if secret == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(nodeId))
+	return mac.Sum(nil)
+}
+
+// handleFrame 将消息投递到本地Actor，Actor未激活时先激活
+func (r *Remote) handleFrame(conn mux.IServerConn, f *remoteFrame) {
+	defer utils.RecoverPanic()
+
+	system := r.sys()
+	ref := system.NewActorRef(NewProps(), f.actorName, f.pattern)
+	switch f.kind {
+	case remoteFrameSend:
+		err := ref.deliver(&RequestMessage{
+			MsgType:   f.msgType,
+			Priority:  f.priority,
+			Message:   f.message,
+			ActorName: f.actorName,
+			Pattern:   f.pattern,
+			Trace:     f.trace,
+			props:     ref.Props,
+		})
+		r.invalidate(system, f.actorName, err)
+		if err != nil {
+			logger.GetLogger().Error("[Remote] deliver message failed", zap.String("ActorName", f.actorName), zap.Error(err))
+		}
+	case remoteFrameRequest:
+		utils.GoRecoverPanic(func() {
+			re, err := ref.RequestFuture(Traced(f.trace, f.message), time.Duration(f.timeoutMs)*time.Millisecond)
+			r.invalidate(system, f.actorName, err)
+			r.respond(conn, f.reqId, re, err)
+		})
+	case remoteFrameOrderedRequest:
+		r.deliverOrdered(conn, system, ref, f)
+	case remoteFrameStop:
+		ref.Stop()
+	case remoteFrameMigrate:
+		utils.GoRecoverPanic(func() {
+			r.respond(conn, f.reqId, nil, system.activateMigrated(f.actorName, f.pattern, f.message.([]byte)))
+		})
+	}
+}
+
+// deliverOrdered 在读取帧的协程中将请求投递到本地Actor，保持与同一连接上其他消息的顺序
+// 回复的接收方是本地的future，在其他协程中等待回复后发回请求方
+func (r *Remote) deliverOrdered(conn mux.IServerConn, system *ActorSystem, ref *ActorRef, f *remoteFrame) {
+	timeout := time.Duration(f.timeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = parseTimeout()
+	}
+	future := actor.NewFuture(system.actorSystem, timeout)
+	rm := &RequestMessage{
+		MsgType:   MessageTypeRequest,
+		Message:   f.message,
+		ActorName: f.actorName,
+		Pattern:   f.pattern,
+		Trace:     f.trace,
+		props:     ref.Props,
+		sender:    future.PID(),
+	}
+	rm.withContext(context.Background(), timeout)
+	if err := ref.deliverLocal(rm); err != nil {
+		pid := future.PID()
+		system.actorSystem.Root.Stop(actor.NewPID(pid.Address, pid.Id))
+		r.invalidate(system, f.actorName, err)
+		r.respond(conn, f.reqId, nil, err)
+		return
+	}
+	utils.GoRecoverPanic(func() {
+		re, err := requestResult(future.Result())
+		r.invalidate(system, f.actorName, err)
+		r.respond(conn, f.reqId, re, err)
+	})
+}
+
+// invalidate 本地Actor已经停止、不存在或已经迁出时，本节点缓存的位置已经过期，
+// 删除缓存，下一次投递时重新确认持有者，避免在两个节点之间来回转发
+func (r *Remote) invalidate(system *ActorSystem, actorName string, err error) {
+	o := system.ownership
+	if o == nil {
+		return
+	}
+	if errors.Is(err, ErrActorStopped) || errors.Is(err, ErrActorNotFound) || system.migratedTo(actorName) != "" {
+		o.Invalidate(actorName)
+	}
+}
+
+func (r *Remote) respond(conn mux.IServerConn, reqId uint64, re any, err error) {
+	out, encErr := encodeRemoteFrame(&remoteFrame{
+		kind:    remoteFrameResponse,
+		reqId:   reqId,
+		message: re,
+		err:     err,
+	})
+	if encErr == nil {
+		encErr = conn.Send(out)
+	}
+	if encErr != nil {
+		logger.GetLogger().Error("[Remote] send response failed", zap.Error(encErr))
+	}
+}
+
+// remoteClient 到某个远程节点的连接
+type remoteClient struct {
+	nodeId  string
+	mux     mux.IMux
+	conn    mux.IConn
+	reqId   atomic.Uint64
+	closed  atomic.Bool
+	errs    *RemoteErrorRegistry // 还原远程节点返回的错误
+	onClose func(cli *remoteClient)
+
+	mu      sync.Mutex
+	pending map[uint64]chan *remoteFrame
+}
+
+// dialRemote 建立到远程节点的连接并发送握手帧 hello，连接建立或握手失败时返回错误，下一次发送时重新建立
+// errs 用于还原远程节点返回的错误，onClose 在连接断开后由连接的goroutine调用
+func dialRemote(nodeId, addr string, hello *remoteFrame, errs *RemoteErrorRegistry, onClose func(cli *remoteClient)) (*remoteClient, error) {
+	cli := &remoteClient{
+		nodeId:  nodeId,
+		errs:    errs,
+		onClose: onClose,
+		pending: make(map[uint64]chan *remoteFrame),
+	}
+
+	conn, err := dialRemoteConn(addr)
+	if err != nil {
+		return nil, err
+	}
+	cli.mux = mux.NewMultiplexer(context.Background(), conn, mux.WithDisconnectedCallback(func(err error) {
+		cli.close(ErrRemoteUnavailable)
+	}))
+	vc, err := cli.mux.NewVirtualConn(context.Background())
+	if err != nil {
+		cli.closed.Store(true)
+		go cli.mux.Close()
+		return nil, err
+	}
+	cli.conn = vc
+
+	go cli.recvLoop()
+	if _, err = cli.request(context.Background(), hello, remoteDialTimeout); err != nil {
+		cli.close(err)
+		return nil, err
+	}
+	return cli, nil
+}
+
+func (cli *remoteClient) send(f *remoteFrame) error {
+	if cli.closed.Load() {
+		return ErrRemoteUnavailable
+	}
+	out, err := encodeRemoteFrame(f)
+	if err != nil {
+		return err
+	}
+	return cli.conn.Send(out)
+}
+
+// request 发送请求帧并等待回复，f.reqId 由连接分配，timeout 小于0时只在 ctx 取消时结束等待
+func (cli *remoteClient) request(ctx context.Context, f *remoteFrame, timeout time.Duration) (any, error) {
+	reqId, ch, err := cli.post(f)
+	if err != nil {
+		return nil, err
+	}
+	return cli.await(ctx, reqId, ch, timeout)
+}
+
+// post 分配 f.reqId 并发送请求帧，回复写入返回的channel，由 await 等待
+func (cli *remoteClient) post(f *remoteFrame) (uint64, chan *remoteFrame, error) {
+	reqId := cli.reqId.Add(1)
+	ch := make(chan *remoteFrame, 1)
+	cli.mu.Lock()
+	cli.pending[reqId] = ch
+	cli.mu.Unlock()
+
+	f.reqId = reqId
+	if err := cli.send(f); err != nil {
+		cli.forget(reqId)
+		return 0, nil, err
+	}
+	return reqId, ch, nil
+}
+
+func (cli *remoteClient) forget(reqId uint64) {
+	cli.mu.Lock()
+	delete(cli.pending, reqId)
+	cli.mu.Unlock()
+}
+
+// await 等待 post 发送的请求的回复
+func (cli *remoteClient) await(ctx context.Context, reqId uint64, ch chan *remoteFrame, timeout time.Duration) (any, error) {
+	defer cli.forget(reqId)
+
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case re := <-ch:
+		if re.err != nil {
+			return nil, re.err
+		}
+		return re.message, nil
+	case <-expired:
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, actor.ErrTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (cli *remoteClient) recvLoop() {
+	for {
+		in, err := cli.conn.Recv(context.Background())
+		if err != nil {
+			cli.close(ErrRemoteUnavailable)
+			return
+		}
+
+		f, err := decodeRemoteFrame(in, cli.errs)
+		if err != nil && f == nil {
+			logger.GetLogger().Error("[Remote] decode response failed", zap.String("NodeId", cli.nodeId), zap.Error(err))
+			continue
+		}
+		if err != nil {
+			f.err = err
+		}
+		cli.resolve(f)
+	}
+}
+
+func (cli *remoteClient) resolve(f *remoteFrame) {
+	cli.mu.Lock()
+	ch, ok := cli.pending[f.reqId]
+	delete(cli.pending, f.reqId)
+	cli.mu.Unlock()
+	if ok {
+		ch <- f
+	}
+}
+
+// close 关闭连接，所有等待回复的请求收到 reason
+func (cli *remoteClient) close(reason error) {
+	if !cli.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	cli.mu.Lock()
+	pending := cli.pending
+	cli.pending = make(map[uint64]chan *remoteFrame)
+	cli.mu.Unlock()
+	for _, ch := range pending {
+		ch <- &remoteFrame{kind: remoteFrameResponse, err: reason}
+	}
+
+	cli.mux.Close()
+	if cli.onClose != nil {
+		cli.onClose(cli)
+	}
+	logger.GetLogger().Info("[Remote] connection closed", zap.String("NodeId", cli.nodeId))
+}
@@ -0,0 +1,362 @@
+package actor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gitee.com/orbit-w/meteor/modules/net/packet"
+	"gitee.com/orbit-w/orbit/app/proto/pb"
+	"gitee.com/orbit-w/orbit/lib/tracing"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+/*
+	跨节点消息帧格式：
+
+	Send:     kind(1byte) | msgType(1byte) | priority(1byte) | actorName | pattern | pid(4byte) | payload | [trace]
+	Request:  kind(1byte) | reqId(8byte) | timeout(8byte,ms) | actorName | pattern | pid(4byte) | payload | [trace]
+	Stop:     kind(1byte) | actorName | pattern
+	Response: kind(1byte) | reqId(8byte) | status(1byte) | pid(4byte) | payload 或 错误描述
+	Migrate:  kind(1byte) | reqId(8byte) | timeout(8byte,ms) | actorName | pattern | state
+	Handshake: kind(1byte) | reqId(8byte) | nodeId | token
+	OrderedRequest: 与 Request 相同，目标节点在读取帧的协程中投递，与同一连接上的Send消息保持发送顺序
+
+	actorName/pattern 以 uint16长度+内容 编码，payload 以 uint32长度+内容 编码
+	trace 为可选的 traceId(16byte) | spanId(8byte)，消息没有链路时省略，兼容不携带trace的旧节点
+	消息内容使用客户端协议相同的协议ID(pb.AllMessageNameToID)序列化
+*/
+
+const (
+	remoteFrameSend int8 = iota + 1
+	remoteFrameRequest
+	remoteFrameStop
+	remoteFrameResponse
+	remoteFrameMigrate
+	remoteFrameHandshake
+	remoteFrameOrderedRequest
+)
+
+const (
+	remoteStatusOK int8 = iota
+	remoteStatusNil
+	remoteStatusError
+)
+
+// RemoteErrorRegistry 可以跨节点传递的错误，每个 ActorSystem 可以使用独立的注册表(WithRemoteErrors)
+// 远程节点返回的同名错误会被还原为注册的错误，以便调用者使用 errors.Is 判断；框架内置错误已注册
+type RemoteErrorRegistry struct {
+	mu     sync.RWMutex
+	errors map[string]error
+}
+
+func NewRemoteErrorRegistry() *RemoteErrorRegistry {
+	r := &RemoteErrorRegistry{
+		errors: make(map[string]error),
+	}
+	for _, err := range []error{
+		ErrActorNotFound,
+		ErrActorStopped,
+		ErrSupervisionStopped,
+		ErrActorCrashed,
+		ErrDeadLetter,
+		ErrMailboxFull,
+		ErrNotMigratable,
+		ErrActorAlreadyActive,
+		ErrRemoteUnauthorized,
+	} {
+		r.errors[err.Error()] = err
+	}
+	return r
+}
+
+// defaultRemoteErrors 未指定注册表的 ActorSystem 以及包级别的 RegRemoteError 使用的注册表
+var defaultRemoteErrors = NewRemoteErrorRegistry()
+
+// DefaultRemoteErrors 返回默认的跨节点错误注册表
+func DefaultRemoteErrors() *RemoteErrorRegistry {
+	return defaultRemoteErrors
+}
+
+// Reg 注册可以跨节点传递的错误，同名错误以最后一次注册为准
+func (r *RemoteErrorRegistry) Reg(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[err.Error()] = err
+}
+
+// Get 还原远程节点返回的错误，未注册的错误只保留描述
+func (r *RemoteErrorRegistry) Get(text string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err, ok := r.errors[text]; ok {
+		return err
+	}
+	return errors.New(text)
+}
+
+// remoteMessageTypes 协议ID到消息类型的映射，第一次使用时由已注册的protobuf消息生成
+var remoteMessageTypes struct {
+	once  sync.Once
+	types map[uint32]protoreflect.MessageType
+}
+
+// remoteMessageName 返回生成协议ID使用的消息名称 "包名-消息名"，嵌套消息以下划线连接，与生成的Go类型名一致
+func remoteMessageName(desc protoreflect.MessageDescriptor) string {
+	pkg := string(desc.ParentFile().Package())
+	name := strings.TrimPrefix(string(desc.FullName()), pkg+".")
+	return pkg + "-" + strings.ReplaceAll(name, ".", "_")
+}
+
+// remoteProtocolID 返回消息的协议ID，与客户端协议共用 pb.AllMessageNameToID
+func remoteProtocolID(desc protoreflect.MessageDescriptor) (uint32, bool) {
+	return pb.GetProtocolID(remoteMessageName(desc))
+}
+
+// remoteMessageType 返回协议ID对应的消息类型
+func remoteMessageType(pid uint32) (protoreflect.MessageType, bool) {
+	remoteMessageTypes.once.Do(func() {
+		remoteMessageTypes.types = make(map[uint32]protoreflect.MessageType, len(pb.AllIDToMessageName))
+		protoregistry.GlobalTypes.RangeMessages(func(mt protoreflect.MessageType) bool {
+			if id, ok := remoteProtocolID(mt.Descriptor()); ok {
+				remoteMessageTypes.types[id] = mt
+			}
+			return true
+		})
+	})
+	mt, ok := remoteMessageTypes.types[pid]
+	return mt, ok
+}
+
+// RegRemoteError 注册可以跨节点传递的错误，远程节点返回的同名错误会被还原为 err，以便调用者使用 errors.Is 判断
+func RegRemoteError(err error) {
+	defaultRemoteErrors.Reg(err)
+}
+
+func marshalRemoteMessage(msg any) (uint32, []byte, error) {
+	pm, ok := msg.(proto.Message)
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: %T is not a proto message", ErrRemoteMessageNotRegistered, msg)
+	}
+
+	pid, ok := remoteProtocolID(pm.ProtoReflect().Descriptor())
+	if !ok {
+		return 0, nil, fmt.Errorf("%w: %T", ErrRemoteMessageNotRegistered, msg)
+	}
+
+	data, err := proto.Marshal(pm)
+	if err != nil {
+		return 0, nil, err
+	}
+	return pid, data, nil
+}
+
+func unmarshalRemoteMessage(pid uint32, data []byte) (any, error) {
+	mt, ok := remoteMessageType(pid)
+	if !ok {
+		return nil, fmt.Errorf("%w: pid=0x%08x", ErrRemoteMessageNotRegistered, pid)
+	}
+
+	msg := mt.New().Interface()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// remoteFrame 解码后的消息帧
+type remoteFrame struct {
+	kind      int8
+	msgType   int8
+	priority  int8
+	status    int8
+	reqId     uint64
+	timeoutMs int64
+	actorName string
+	pattern   string
+	nodeId    string // 握手帧中发起连接的节点
+	message   any
+	err       error
+	trace     tracing.SpanContext
+}
+
+func encodeRemoteFrame(f *remoteFrame) ([]byte, error) {
+	var (
+		pid  uint32
+		data []byte
+		err  error
+	)
+	switch f.kind {
+	case remoteFrameSend, remoteFrameRequest, remoteFrameOrderedRequest:
+		if pid, data, err = marshalRemoteMessage(f.message); err != nil {
+			return nil, err
+		}
+	case remoteFrameMigrate, remoteFrameHandshake:
+		data, _ = f.message.([]byte)
+	case remoteFrameResponse:
+		switch {
+		case f.err != nil:
+			f.status, data = remoteStatusError, []byte(f.err.Error())
+		case f.message == nil:
+			f.status = remoteStatusNil
+		default:
+			if pid, data, err = marshalRemoteMessage(f.message); err != nil {
+				f.status, data = remoteStatusError, []byte(err.Error())
+			}
+		}
+	}
+
+	w := packet.Writer(32 + len(f.actorName) + len(f.pattern) + len(f.nodeId) + len(data))
+	w.WriteInt8(f.kind)
+	switch f.kind {
+	case remoteFrameSend:
+		w.WriteInt8(f.msgType)
+		w.WriteInt8(f.priority)
+		w.WriteString(f.actorName)
+		w.WriteString(f.pattern)
+		w.WriteUint32(pid)
+		w.WriteBytes32(data)
+		writeTrace(w, f.trace)
+	case remoteFrameRequest, remoteFrameOrderedRequest:
+		w.WriteUint64(f.reqId)
+		w.WriteInt64(f.timeoutMs)
+		w.WriteString(f.actorName)
+		w.WriteString(f.pattern)
+		w.WriteUint32(pid)
+		w.WriteBytes32(data)
+		writeTrace(w, f.trace)
+	case remoteFrameStop:
+		w.WriteString(f.actorName)
+		w.WriteString(f.pattern)
+	case remoteFrameMigrate:
+		w.WriteUint64(f.reqId)
+		w.WriteInt64(f.timeoutMs)
+		w.WriteString(f.actorName)
+		w.WriteString(f.pattern)
+		w.WriteBytes32(data)
+	case remoteFrameHandshake:
+		w.WriteUint64(f.reqId)
+		w.WriteString(f.nodeId)
+		w.WriteBytes32(data)
+	case remoteFrameResponse:
+		w.WriteUint64(f.reqId)
+		w.WriteInt8(f.status)
+		w.WriteUint32(pid)
+		w.WriteBytes32(data)
+	default:
+		return nil, fmt.Errorf("unknown remote frame kind: %d", f.kind)
+	}
+	return w.Data(), nil
+}
+
+// decodeRemoteFrame 解码消息帧，回复中的错误由 errs 还原
+func decodeRemoteFrame(in []byte, errs *RemoteErrorRegistry) (*remoteFrame, error) {
+	r := packet.Reader(in)
+	f := &remoteFrame{}
+
+	var (
+		err  error
+		pid  uint32
+		data []byte
+		name []byte
+	)
+	readString := func() string {
+		if err == nil {
+			name, err = r.ReadBytes()
+		}
+		return string(name)
+	}
+	readPayload := func() {
+		if err == nil {
+			pid, err = r.ReadUint32()
+		}
+		if err == nil {
+			data, err = r.ReadBytes32()
+		}
+	}
+
+	if f.kind, err = r.ReadInt8(); err != nil {
+		return nil, err
+	}
+	switch f.kind {
+	case remoteFrameSend:
+		if f.msgType, err = r.ReadInt8(); err == nil {
+			f.priority, err = r.ReadInt8()
+		}
+		f.actorName, f.pattern = readString(), readString()
+		readPayload()
+		if err == nil {
+			f.trace = readTrace(r)
+		}
+	case remoteFrameRequest, remoteFrameOrderedRequest:
+		if f.reqId, err = r.ReadUint64(); err == nil {
+			f.timeoutMs, err = r.ReadInt64()
+		}
+		f.actorName, f.pattern = readString(), readString()
+		readPayload()
+		if err == nil {
+			f.trace = readTrace(r)
+		}
+	case remoteFrameStop:
+		f.actorName, f.pattern = readString(), readString()
+	case remoteFrameMigrate:
+		if f.reqId, err = r.ReadUint64(); err == nil {
+			f.timeoutMs, err = r.ReadInt64()
+		}
+		f.actorName, f.pattern = readString(), readString()
+		if err == nil {
+			data, err = r.ReadBytes32()
+		}
+	case remoteFrameHandshake:
+		f.reqId, err = r.ReadUint64()
+		f.nodeId = readString()
+		if err == nil {
+			data, err = r.ReadBytes32()
+		}
+	case remoteFrameResponse:
+		if f.reqId, err = r.ReadUint64(); err == nil {
+			f.status, err = r.ReadInt8()
+		}
+		readPayload()
+	default:
+		return nil, fmt.Errorf("unknown remote frame kind: %d", f.kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case f.kind == remoteFrameResponse && f.status == remoteStatusError:
+		f.err = errs.Get(string(data))
+	case f.kind == remoteFrameResponse && f.status == remoteStatusNil:
+	case f.kind == remoteFrameMigrate, f.kind == remoteFrameHandshake:
+		// 状态在其他goroutine中使用，不能引用接收缓冲区
+		f.message = append([]byte(nil), data...)
+	case f.kind != remoteFrameStop:
+		f.message, err = unmarshalRemoteMessage(pid, data)
+	}
+	return f, err
+}
+
+const remoteTraceSize = 24
+
+func writeTrace(w packet.IPacket, sc tracing.SpanContext) {
+	if sc.IsValid() {
+		w.Write(sc.TraceID[:])
+		w.Write(sc.SpanID[:])
+	}
+}
+
+// readTrace 读取可选的trace，剩余数据不足时视为没有链路
+func readTrace(r packet.IPacket) tracing.SpanContext {
+	var sc tracing.SpanContext
+	if len(r.Remain()) < remoteTraceSize {
+		return sc
+	}
+	_, _ = r.Read(sc.TraceID[:])
+	_, _ = r.Read(sc.SpanID[:])
+	return sc
+}
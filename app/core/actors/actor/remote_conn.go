@@ -0,0 +1,139 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/meteor/bases/misc/utils"
+	"gitee.com/orbit-w/meteor/modules/net/network"
+	"gitee.com/orbit-w/meteor/modules/net/packet"
+)
+
+const (
+	remoteDialTimeout       = 3 * time.Second
+	remoteHeartbeatInterval = 10 * time.Second
+)
+
+// remoteConn 到远程节点的TCP连接，作为 mux 多路复用的底层连接
+// 帧格式与 mux.Server 一致：size<int32> | gzipped<bool> | type<int8> | body<bytes>，
+// body 由若干条带4字节长度前缀的消息组成；服务端在读超时内收不到数据会断开连接，因此定时发送心跳
+type remoteConn struct {
+	conn   net.Conn
+	codec  *network.Codec
+	r      *network.BlockReceiver
+	mu     sync.Mutex // 保证整帧写入
+	closed chan struct{}
+	once   sync.Once
+}
+
+func dialRemoteConn(addr string) (*remoteConn, error) {
+	conn, err := net.DialTimeout("tcp", addr, remoteDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &remoteConn{
+		conn:   conn,
+		codec:  network.NewCodec(network.MaxIncomingPacket, false, network.ReadTimeout),
+		r:      network.NewBlockReceiver(),
+		closed: make(chan struct{}),
+	}
+	go c.readLoop()
+	go c.keepalive()
+	return c, nil
+}
+
+func (c *remoteConn) Send(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	body := packet.WriterP(4 + len(data))
+	defer packet.Return(body)
+	body.WriteBytes32(data)
+	return c.write(body.Data(), network.TypeMessageRaw)
+}
+
+func (c *remoteConn) Recv(ctx context.Context) ([]byte, error) {
+	return c.r.Recv(ctx)
+}
+
+func (c *remoteConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+		_ = c.conn.Close()
+	})
+	return nil
+}
+
+func (c *remoteConn) write(data []byte, h int8) error {
+	pack, err := c.codec.Encode(data, h)
+	if err != nil {
+		return err
+	}
+	defer packet.Return(pack)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err = c.conn.SetWriteDeadline(time.Now().Add(network.WriteTimeout)); err == nil {
+		_, err = c.conn.Write(pack.Data())
+	}
+	if err != nil {
+		_ = c.Close()
+	}
+	return err
+}
+
+func (c *remoteConn) readLoop() {
+	var err error
+	defer utils.RecoverPanic()
+	defer func() {
+		_ = c.Close()
+		if errors.Is(err, io.EOF) || network.IsClosedConnError(err) {
+			err = network.ErrCanceled
+		}
+		c.r.OnClose(err)
+	}()
+
+	header := make([]byte, network.HeadLen)
+	buf := make([]byte, network.MaxIncomingPacket)
+	for {
+		var (
+			in   []byte
+			head int8
+		)
+		in, head, err = c.codec.BlockDecodeBody(c.conn, header, buf)
+		if err != nil {
+			return
+		}
+		if head == network.TypeMessageHeartbeat || len(in) == 0 {
+			continue
+		}
+		// buf 会被下一次读取覆盖，ReaderP 复制一份数据
+		reader := packet.ReaderP(in)
+		for len(reader.Remain()) > 0 {
+			data, rErr := reader.ReadBytes32()
+			if rErr != nil {
+				break
+			}
+			c.r.Put(data, nil)
+		}
+	}
+}
+
+func (c *remoteConn) keepalive() {
+	ticker := time.NewTicker(remoteHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.write(nil, network.TypeMessageHeartbeat); err != nil {
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
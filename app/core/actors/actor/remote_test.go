@@ -0,0 +1,289 @@
+package actor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/proto/pb"
+	"gitee.com/orbit-w/orbit/app/proto/pb/pb_core"
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	remoteTestPattern = "remote-echo-pattern"
+	remoteTestNodeEnv = "ORBIT_REMOTE_TEST_NODE"
+	remoteTestSecret  = "remote-test-secret"
+)
+
+// EchoBehavior 回复收到的查询，记录收到的Send消息
+type EchoBehavior struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (b *EchoBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	req := msg.(*pb_core.Request_SearchBook)
+	switch req.Query {
+	case "fail":
+		return nil, ErrMailboxFull
+	case "sent":
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return bookRsp(strings.Join(b.sent, ",")), nil
+	}
+	return bookRsp(ctx.GetActorName() + ":" + req.Query), nil
+}
+
+func (b *EchoBehavior) HandleSend(ctx IContext, msg any) {
+	b.mu.Lock()
+	b.sent = append(b.sent, msg.(*pb_core.Request_SearchBook).Query)
+	b.mu.Unlock()
+}
+
+func (b *EchoBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *EchoBehavior) HandleInit(ctx IContext) error {
+	return nil
+}
+
+func (b *EchoBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *EchoBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func bookRsp(content string) *pb_core.Request_SearchBook_Rsp {
+	return &pb_core.Request_SearchBook_Rsp{Result: &pb_core.Book{Content: content}}
+}
+
+func bookContent(re any) string {
+	return re.(*pb_core.Request_SearchBook_Rsp).GetResult().GetContent()
+}
+
+// TestRemote_Node 作为独立进程运行的远程节点，由 TestRemote_TwoProcesses 启动
+func TestRemote_Node(t *testing.T) {
+	nodeId := os.Getenv(remoteTestNodeEnv)
+	if nodeId == "" {
+		t.Skip("only runs as a child process of TestRemote_TwoProcesses")
+	}
+
+	service := setup(remoteTestPattern)
+	defer service.Stop(context.Background())
+	RegFactory(remoteTestPattern, func(actorName string) Behavior {
+		return &EchoBehavior{}
+	})
+	RegFactory(migrateTestPattern, func(actorName string) Behavior {
+		return &CounterBehavior{}
+	})
+	RegFactory(migrateOrderTestPattern, func(actorName string) Behavior {
+		return &OrderBehavior{}
+	})
+
+	if err := System.StartRemote(&RemoteConfig{
+		NodeId:    nodeId,
+		Addr:      "127.0.0.1:0",
+		Discovery: NewStaticDiscovery(NodeConfig{NodeId: "node-a"}),
+		Secret:    remoteTestSecret,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Printf("remote-node-addr=%s\n", System.Remote().Addr())
+
+	// 父进程关闭stdin后退出
+	_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+}
+
+func TestRemote_TwoProcesses(t *testing.T) {
+	if os.Getenv(remoteTestNodeEnv) != "" {
+		t.Skip()
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRemote_Node$", "-test.count=1")
+	cmd.Env = append(os.Environ(), remoteTestNodeEnv+"=node-b")
+	stdin, err := cmd.StdinPipe()
+	assert.NoError(t, err)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	defer func() {
+		_ = stdin.Close()
+		_ = cmd.Wait()
+	}()
+
+	addr := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "remote-node-addr=") {
+				addr <- strings.TrimPrefix(line, "remote-node-addr=")
+			}
+		}
+	}()
+
+	var nodeB string
+	select {
+	case nodeB = <-addr:
+	case <-time.After(10 * time.Second):
+		t.Fatal("remote node not ready")
+	}
+
+	service := setup(remoteTestPattern)
+	defer service.Stop(context.Background())
+	assert.NoError(t, System.StartRemote(&RemoteConfig{
+		NodeId: "node-a",
+		Addr:   "127.0.0.1:0",
+		Discovery: NewStaticDiscovery(
+			NodeConfig{NodeId: "node-b", Addr: nodeB},
+		),
+		Secret: remoteTestSecret,
+	}))
+
+	meta := NewMeta("remote-echo-actor", remoteTestPattern, "1", &Dispatcher{NodeId: "node-b"})
+	ref := NewActorRef(NewProps(), "remote-echo-actor", remoteTestPattern, WithMeta(meta))
+
+	re, err := ref.RequestFuture(&pb_core.Request_SearchBook{Query: "hello"}, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "remote-echo-actor:hello", bookContent(re))
+	assert.False(t, System.actors.Exist("remote-echo-actor"), "actor should not be activated locally")
+
+	for _, name := range []string{"a", "b", "c"} {
+		assert.NoError(t, ref.Send(&pb_core.Request_SearchBook{Query: name}))
+	}
+	re, err = ref.RequestFuture(&pb_core.Request_SearchBook{Query: "sent"}, 5*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b,c", bookContent(re), "messages from one node should keep their order")
+
+	_, err = ref.RequestFuture(&pb_core.Request_SearchBook{Query: "fail"}, 5*time.Second)
+	assert.True(t, errors.Is(err, ErrMailboxFull), "remote errors should keep their identity")
+
+	err = ref.Send("not a proto message")
+	assert.True(t, errors.Is(err, ErrRemoteMessageNotRegistered))
+	err = ref.Send(&Meta{})
+	assert.True(t, errors.Is(err, ErrRemoteMessageNotRegistered), "messages without protocol id cannot be sent")
+
+	unknown := NewActorRef(NewProps(), "remote-unknown-actor", remoteTestPattern,
+		WithMeta(NewMeta("remote-unknown-actor", remoteTestPattern, "1", &Dispatcher{NodeId: "node-x"})))
+	assert.True(t, errors.Is(unknown.Send(&pb_core.Request_SearchBook{}), ErrNodeNotFound))
+
+	t.Run("Migrate", testMigrate)
+}
+
+// 只接受 Discovery 中持有共享密钥的节点建立连接
+func TestRemote_Handshake(t *testing.T) {
+	server := NewRemote(&RemoteConfig{
+		NodeId:    "handshake-server",
+		Addr:      "127.0.0.1:0",
+		Discovery: NewStaticDiscovery(NodeConfig{NodeId: "handshake-peer"}),
+		Secret:    remoteTestSecret,
+	})
+	assert.NoError(t, server.Start())
+	defer server.Stop()
+
+	dial := func(nodeId, secret string) error {
+		r := NewRemote(&RemoteConfig{
+			NodeId:    nodeId,
+			Discovery: NewStaticDiscovery(NodeConfig{NodeId: "handshake-server", Addr: server.Addr()}),
+			Secret:    secret,
+		})
+		defer r.Stop()
+		_, err := r.client("handshake-server")
+		return err
+	}
+
+	assert.NoError(t, dial("handshake-peer", remoteTestSecret))
+	assert.True(t, errors.Is(dial("handshake-peer", "wrong-secret"), ErrRemoteUnauthorized), "wrong secret should be rejected")
+	assert.True(t, errors.Is(dial("handshake-peer", ""), ErrRemoteUnauthorized), "missing secret should be rejected")
+	assert.True(t, errors.Is(dial("handshake-unknown", remoteTestSecret), ErrRemoteUnauthorized), "unknown node should be rejected")
+}
+
+// initFailBehavior 初始化失败，Actor无法激活
+type initFailBehavior struct {
+	MockBehavior
+}
+
+func (b *initFailBehavior) HandleInit(ctx IContext) error {
+	return errors.New("init failed")
+}
+
+// recordServerConn 记录Remote回复的帧
+type recordServerConn struct {
+	out chan []byte
+}
+
+func (c *recordServerConn) Send(data []byte) error {
+	c.out <- data
+	return nil
+}
+
+func (c *recordServerConn) Recv(ctx context.Context) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *recordServerConn) Context() context.Context {
+	return context.Background()
+}
+
+func (c *recordServerConn) Close() {}
+
+// 远程请求的目标Actor激活失败时，回复错误而不是让发送方等待超时
+func TestRemote_RequestActivationFailed(t *testing.T) {
+	const pattern = "remote-init-fail-pattern"
+	factories := NewFactoryRegistry()
+	factories.Reg(pattern, func(actorName string) Behavior {
+		return &initFailBehavior{}
+	})
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+
+	r := NewRemote(&RemoteConfig{NodeId: "remote-test-node"})
+	r.system = af
+	conn := &recordServerConn{out: make(chan []byte, 1)}
+	r.handleFrame(conn, &remoteFrame{
+		kind:      remoteFrameRequest,
+		reqId:     7,
+		timeoutMs: time.Second.Milliseconds(),
+		actorName: "remote-init-fail-actor",
+		pattern:   pattern,
+	})
+
+	select {
+	case out := <-conn.out:
+		f, err := decodeRemoteFrame(out, DefaultRemoteErrors())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), f.reqId)
+		assert.Equal(t, remoteStatusError, f.status)
+		assert.EqualError(t, f.err, "init failed")
+	case <-time.After(2 * time.Second):
+		t.Fatal("remote request was not answered")
+	}
+}
+
+// 跨节点消息使用与客户端协议相同的协议ID
+func TestRemote_ProtocolID(t *testing.T) {
+	pid, data, err := marshalRemoteMessage(&pb_core.Request_SearchBook{Query: "go"})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.PID_Core_Request_SearchBook, pid)
+	msg, err := unmarshalRemoteMessage(pid, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "go", msg.(*pb_core.Request_SearchBook).Query)
+
+	pid, _, err = marshalRemoteMessage(&pb_core.Request_SearchBook_Rsp{})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.PID_Core_Request_SearchBook_Rsp, pid)
+
+	_, err = unmarshalRemoteMessage(0, nil)
+	assert.True(t, errors.Is(err, ErrRemoteMessageNotRegistered))
+}
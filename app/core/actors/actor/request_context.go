@@ -0,0 +1,153 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/tracing"
+	actor "github.com/asynkron/protoactor-go/actor"
+)
+
+/*
+	请求的取消与截止时间
+
+	1. RequestFutureContext 以 ctx 的截止时间作为等待回复的超时，ctx 取消时立即返回 ctx.Err()并停止等待回复的future；
+	   ctx 没有截止时间时等待到取消为止，不能取消的ctx(例如 context.Background())使用默认超时
+	2. 被调用的Actor通过 IContext.Context 获取调用方的ctx，处理期间可以观察到取消；
+	   取消发生在消息出队之前时不再调用 HandleRequest，直接回复 ctx.Err()
+	3. 使用 IContext.Context 请求下游Actor时，截止时间沿调用链传递，每一跳剩余的时间逐渐减少；
+	   RequestFuture 的超时同样作为被调用方 IContext.Context 的截止时间
+	4. 跨节点时只传递剩余的时间，取消不会传递到远程节点；没有截止时间的请求在远程节点使用默认超时
+*/
+
+// contextTimeout 返回ctx剩余的时间，没有截止时间时返回-1(等待到ctx取消)，ctx 不能取消时返回默认超时
+func contextTimeout(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		if ctx.Done() == nil {
+			return parseTimeout(), nil
+		}
+		return -1, nil
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+	return timeout, nil
+}
+
+type futureOutcome struct {
+	result any
+	err    error
+}
+
+// awaitFuture 等待future的结果，ctx 取消时停止future并立即返回 ctx.Err()
+// 停止后future从进程注册表中移除，等待结果的协程随之退出，被调用方之后的回复进入死信
+func awaitFuture(ctx context.Context, root *actor.RootContext, future *actor.Future) (any, error) {
+	if ctx.Done() == nil {
+		return future.Result()
+	}
+	ch := make(chan futureOutcome, 1)
+	go func() {
+		result, err := future.Result()
+		ch <- futureOutcome{result: result, err: err}
+	}()
+	select {
+	case o := <-ch:
+		if _, ok := ctx.Deadline(); ok && errors.Is(o.err, actor.ErrTimeout) {
+			return nil, context.DeadlineExceeded
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		// 使用新的PID停止，避免缓存的进程引用留在回复使用的PID上，之后的回复按已移除的进程进入死信
+		pid := future.PID()
+		root.Stop(actor.NewPID(pid.Address, pid.Id))
+		return nil, ctx.Err()
+	}
+}
+
+// withContext 记录调用方的ctx和等待回复的截止时间，ctx 没有链路时使用其中的 SpanContext
+func (rm *RequestMessage) withContext(ctx context.Context, timeout time.Duration) {
+	if ctx.Done() != nil {
+		rm.ctx = ctx
+	}
+	if timeout > 0 {
+		rm.deadline = time.Now().Add(timeout)
+	}
+	if !rm.Trace.IsValid() {
+		rm.Trace = tracing.SpanContextFromContext(ctx)
+	}
+}
+
+// remaining 返回转发请求时调用方的ctx和剩余的时间
+func (rm *RequestMessage) remaining() (context.Context, time.Duration) {
+	ctx := rm.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	switch {
+	case !rm.deadline.IsZero():
+		return ctx, max(time.Until(rm.deadline), time.Millisecond)
+	case rm.ctx != nil:
+		return ctx, -1
+	}
+	return ctx, parseTimeout()
+}
+
+// canceled 调用方的ctx是否已经取消
+func (rm *RequestMessage) canceled() error {
+	if rm.ctx == nil {
+		return nil
+	}
+	return rm.ctx.Err()
+}
+
+// requestContext 当前处理的消息的ctx，第一次调用 Context 时创建
+type requestContext struct {
+	parent   context.Context
+	deadline time.Time
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// Context 返回当前处理的消息的调用方ctx，携带调用方的截止时间和当前的Span
+// 处理定时器等没有调用方的消息时返回 context.Background()
+func (state *ChildActor) Context() context.Context {
+	rc := state.handling.reqCtx
+	if rc == nil {
+		return tracing.ContextWithSpanContext(context.Background(), state.SpanContext())
+	}
+	if rc.ctx == nil {
+		ctx := rc.parent
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if !rc.deadline.IsZero() {
+			ctx, rc.cancel = context.WithDeadline(ctx, rc.deadline)
+		}
+		rc.ctx = tracing.ContextWithSpanContext(ctx, state.SpanContext())
+	}
+	return rc.ctx
+}
+
+// beginRequest 开始处理消息，返回之前的 requestContext，由 endRequest 恢复
+func (state *ChildActor) beginRequest(msg *RequestMessage) *requestContext {
+	prev := state.handling.reqCtx
+	if msg.ctx == nil && msg.deadline.IsZero() {
+		state.handling.reqCtx = nil
+	} else {
+		state.handling.reqCtx = &requestContext{parent: msg.ctx, deadline: msg.deadline}
+	}
+	return prev
+}
+
+func (state *ChildActor) endRequest(prev *requestContext) {
+	if rc := state.handling.reqCtx; rc != nil && rc.cancel != nil {
+		rc.cancel()
+	}
+	state.handling.reqCtx = prev
+}
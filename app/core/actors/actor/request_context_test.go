@@ -0,0 +1,131 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// ctxBehavior 按请求内容观察调用方的ctx
+type ctxBehavior struct {
+	MockBehavior
+	release  chan struct{}
+	observed chan error
+	handled  *atomic.Int32
+}
+
+func (b *ctxBehavior) HandleSend(ctx IContext, msg any) {
+	if msg == "block" {
+		<-b.release
+	}
+}
+
+func (b *ctxBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	b.handled.Add(1)
+	switch msg {
+	case "wait":
+		<-ctx.Context().Done()
+		b.observed <- ctx.Context().Err()
+		return nil, ctx.Context().Err()
+	case "deadline":
+		deadline, _ := ctx.Context().Deadline()
+		return deadline, nil
+	case "forward":
+		// 以自己的ctx请求下游，截止时间沿调用链传递
+		ref := ctx.GetSystem().NewActorRef(NewProps(), "ctx-downstream", "ctx-actor")
+		return ref.RequestFutureContext(ctx.Context(), "deadline")
+	}
+	return msg, nil
+}
+
+func TestRequestFutureContext(t *testing.T) {
+	var handled atomic.Int32
+	release, observed := make(chan struct{}), make(chan error, 1)
+	factories := NewFactoryRegistry()
+	factories.Reg("ctx-actor", func(actorName string) Behavior {
+		return &ctxBehavior{release: release, observed: observed, handled: &handled}
+	})
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+	ref := af.NewActorRef(NewProps(), "ctx-upstream", "ctx-actor")
+
+	// 调用方取消后立即返回，被调用方通过 IContext.Context 观察到取消
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(30*time.Millisecond, cancel)
+	begin := time.Now()
+	_, err := ref.RequestFutureContext(ctx, "wait")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, time.Since(begin), time.Second)
+	select {
+	case err = <-observed:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("callee did not observe cancellation")
+	}
+
+	// 到达截止时间返回 context.DeadlineExceeded
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	_, err = ref.RequestFutureContext(ctx, "wait")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	<-observed
+	_, err = ref.RequestFutureContext(ctx, "ping")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// 截止时间沿调用链传递，RequestFuture 的超时同样作为被调用方的截止时间
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	expect, _ := ctx.Deadline()
+	re, err := ref.RequestFutureContext(ctx, "forward")
+	assert.NoError(t, err)
+	assert.Equal(t, expect, re)
+	re, err = ref.RequestFuture("deadline", time.Second)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Second), re.(time.Time), 100*time.Millisecond)
+
+	// 出队前已经取消的请求不再处理
+	assert.NoError(t, ref.Send("block"))
+	ctx, cancel = context.WithCancel(context.Background())
+	count := handled.Load()
+	done := make(chan error, 1)
+	go func() {
+		_, err := ref.RequestFutureContext(ctx, "ping")
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.True(t, errors.Is(<-done, context.Canceled))
+	close(release)
+	_, err = ref.RequestFuture("ping")
+	assert.NoError(t, err)
+	assert.Equal(t, count+1, handled.Load())
+}
+
+// 没有截止时间的ctx取消后停止future，被调用方不回复时也不会遗留future和等待的协程
+func TestAwaitFuture_StopOnCancel(t *testing.T) {
+	system := actor.NewActorSystem()
+	future := actor.NewFuture(system, -1)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err := awaitFuture(ctx, system.Root, future)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, ok := system.ProcessRegistry.GetLocal(future.PID().Id)
+	assert.False(t, ok, "future should be removed from the process registry")
+	assert.NoError(t, future.Wait())
+
+	// 不能取消的ctx使用默认超时，可以取消的ctx等待到取消为止
+	timeout, err := contextTimeout(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, parseTimeout(), timeout)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	timeout, err = contextTimeout(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), timeout)
+}
@@ -0,0 +1,253 @@
+package actor
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+	Router 将同一个ActorName背后的一组相同Behavior(routee)作为一个整体使用，适用于无状态的工作，例如名称校验、寻路
+
+	1. 按Pattern注册 RouterPolicy 后，发往该Pattern的 ActorRef 的消息按 RouterMode 路由到某个routee
+	2. routee 是普通的子Actor，名称为 {ActorName}#{序号}，使用Pattern注册的 Factory 创建，
+	   与其他Actor一样按Pattern所属的Level停止，首次收到消息时激活
+	3. Resize 调整routee数量，缩容时多余的routee处理完邮箱中的消息后停止
+	4. RouterConsistentHash 按 RouterPolicy.HashKey 或消息实现的 RouterHashKey 选择routee，
+	   相同key的消息总是由同一个routee处理，扩缩容时只有少量key改变routee
+	5. RouterBroadcast 将Send消息投递给所有routee，不支持 RequestFuture
+*/
+
+type RouterMode int8
+
+const (
+	RouterRoundRobin RouterMode = iota
+	RouterRandom
+	RouterConsistentHash
+	RouterBroadcast
+)
+
+const DefaultRouterSize = 4
+
+// RouterHashKey 一致性哈希路由时，消息可以实现此接口提供路由key
+type RouterHashKey interface {
+	RouterHashKey() string
+}
+
+// RouterPolicy 路由策略，按Pattern注册
+type RouterPolicy struct {
+	Mode    RouterMode
+	Size    int                  // 初始routee数量，为0时使用 DefaultRouterSize
+	HashKey func(msg any) string // RouterConsistentHash 时提取消息的路由key，为nil时使用 RouterHashKey
+}
+
+// RouterPolicyRegistry 按Pattern注册的路由策略，每个 ActorSystem 可以使用独立的注册表(WithRouterPolicies)
+type RouterPolicyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]*RouterPolicy
+}
+
+func NewRouterPolicyRegistry() *RouterPolicyRegistry {
+	return &RouterPolicyRegistry{
+		policies: make(map[string]*RouterPolicy),
+	}
+}
+
+// defaultRouterPolicies 未指定注册表的 ActorSystem 以及包级别的 RegRouter 使用的注册表
+var defaultRouterPolicies = NewRouterPolicyRegistry()
+
+// DefaultRouterPolicies 返回默认的路由策略注册表
+func DefaultRouterPolicies() *RouterPolicyRegistry {
+	return defaultRouterPolicies
+}
+
+// Reg 为Pattern注册路由策略，重复注册时panic
+func (r *RouterPolicyRegistry) Reg(pattern string, policy *RouterPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[pattern]; ok {
+		panic("router policy already registered: " + pattern)
+	}
+	r.policies[pattern] = policy
+}
+
+// Get 返回Pattern的路由策略，未注册时返回nil
+func (r *RouterPolicyRegistry) Get(pattern string) *RouterPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.policies[pattern]
+}
+
+// RegRouter registers a router policy for a specific actor pattern
+func RegRouter(pattern string, policy *RouterPolicy) {
+	defaultRouterPolicies.Reg(pattern, policy)
+}
+
+// GetRouterPolicy returns the router policy of the pattern, nil if not registered
+func GetRouterPolicy(pattern string) *RouterPolicy {
+	return defaultRouterPolicies.Get(pattern)
+}
+
+// Router 一个ActorName对应的routee池
+type Router struct {
+	name    string
+	pattern string
+	policy  *RouterPolicy
+	next    atomic.Uint64
+
+	mu      sync.RWMutex
+	routees []string
+	ring    *hashRing
+	system  *ActorSystem
+}
+
+func newRouter(system *ActorSystem, name, pattern string, policy *RouterPolicy) *Router {
+	r := &Router{
+		name:    name,
+		pattern: pattern,
+		policy:  policy,
+		system:  system,
+	}
+	size := policy.Size
+	if size <= 0 {
+		size = DefaultRouterSize
+	}
+	r.Resize(size)
+	return r
+}
+
+// Router 返回ActorName对应的routee池，Pattern未注册 RouterPolicy 时返回nil
+func (af *ActorSystem) Router(actorName, pattern string) *Router {
+	policy := af.RouterPolicies().Get(pattern)
+	if policy == nil {
+		return nil
+	}
+	if v, ok := af.routers.Load(actorName); ok {
+		return v.(*Router)
+	}
+	v, _ := af.routers.LoadOrStore(actorName, newRouter(af, actorName, pattern, policy))
+	return v.(*Router)
+}
+
+// Size 返回当前routee数量
+func (r *Router) Size() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.routees)
+}
+
+// Routees 返回所有routee的ActorName
+func (r *Router) Routees() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.routees...)
+}
+
+// Resize 调整routee数量，最少为1
+// 缩容时被移除的routee处理完邮箱中已有的消息后停止
+func (r *Router) Resize(size int) {
+	if size < 1 {
+		size = 1
+	}
+
+	r.mu.Lock()
+	var removed []string
+	if size < len(r.routees) {
+		removed = append(removed, r.routees[size:]...)
+		r.routees = r.routees[:size]
+	}
+	for i := len(r.routees); i < size; i++ {
+		r.routees = append(r.routees, r.name+"#"+strconv.Itoa(i))
+	}
+	if r.policy.Mode == RouterConsistentHash {
+		r.ring = newHashRing(r.routees)
+	}
+	r.mu.Unlock()
+
+	for _, name := range removed {
+		_ = r.system.StopActor(name, r.pattern)
+	}
+}
+
+// Stop 停止所有routee，下一条消息到达时重新激活
+func (r *Router) Stop() {
+	for _, name := range r.Routees() {
+		_ = r.system.StopActor(name, r.pattern)
+	}
+}
+
+// route 为消息选择routee
+func (r *Router) route(msg any) (string, error) {
+	msg, _ = untrace(msg)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch r.policy.Mode {
+	case RouterRandom:
+		return r.routees[rand.Intn(len(r.routees))], nil
+	case RouterConsistentHash:
+		var key string
+		if r.policy.HashKey != nil {
+			key = r.policy.HashKey(msg)
+		} else if k, ok := msg.(RouterHashKey); ok {
+			key = k.RouterHashKey()
+		} else {
+			return "", ErrRouterNoHashKey
+		}
+		name, _ := r.ring.get(key)
+		return name, nil
+	default:
+		return r.routees[(r.next.Add(1)-1)%uint64(len(r.routees))], nil
+	}
+}
+
+func (r *Router) routee(name string, props *Props) *ActorRef {
+	ref := &ActorRef{ActorName: name, Pattern: r.pattern, Props: props}
+	if ref.sys() != r.system {
+		// Props 没有绑定到路由所在的ActorSystem，复制后绑定，不修改调用者的 Props
+		cp := NewProps()
+		if props != nil {
+			*cp = *props
+		}
+		cp.system = r.system
+		ref.Props = cp
+	}
+	return ref
+}
+
+// deliver 将Send消息投递到routee，广播时返回第一个投递失败的错误
+func (r *Router) deliver(props *Props, rm *RequestMessage) error {
+	if r.policy.Mode == RouterBroadcast {
+		var first error
+		for _, name := range r.Routees() {
+			msg := *rm
+			msg.ActorName = name
+			if err := r.routee(name, props).deliverDirect(&msg); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	name, err := r.route(rm.Message)
+	if err != nil {
+		return err
+	}
+	msg := *rm
+	msg.ActorName = name
+	return r.routee(name, props).deliverDirect(&msg)
+}
+
+func (r *Router) requestFuture(ctx context.Context, props *Props, msg any, timeout time.Duration) (any, error) {
+	if r.policy.Mode == RouterBroadcast {
+		return nil, ErrRouterBroadcastRequest
+	}
+	name, err := r.route(msg)
+	if err != nil {
+		return nil, err
+	}
+	return r.routee(name, props).requestFutureDirect(ctx, msg, timeout)
+}
@@ -0,0 +1,128 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type routerKeyed struct {
+	key string
+}
+
+func (m *routerKeyed) RouterHashKey() string {
+	return m.key
+}
+
+// RouteeBehavior 回复自己的ActorName，统计收到的Send消息
+type RouteeBehavior struct {
+	sent *sync.Map
+}
+
+func (b *RouteeBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	return ctx.GetActorName(), nil
+}
+
+func (b *RouteeBehavior) HandleSend(ctx IContext, msg any) {
+	v, _ := b.sent.LoadOrStore(ctx.GetActorName(), new(atomic.Int32))
+	v.(*atomic.Int32).Add(1)
+}
+
+func (b *RouteeBehavior) HandleForward(ctx IContext, _ any) {}
+
+func (b *RouteeBehavior) HandleInit(ctx IContext) error { return nil }
+
+func (b *RouteeBehavior) HandleStopping(ctx IContext) error { return nil }
+
+func (b *RouteeBehavior) HandleStopped(ctx IContext) error { return nil }
+
+func TestRouter_Modes(t *testing.T) {
+	const (
+		roundRobinPattern = "router-round-robin-pattern"
+		hashPattern       = "router-hash-pattern"
+		broadcastPattern  = "router-broadcast-pattern"
+	)
+	service := setup(roundRobinPattern)
+	defer service.Stop(context.Background())
+
+	sent := &sync.Map{}
+	factory := func(actorName string) Behavior {
+		return &RouteeBehavior{sent: sent}
+	}
+	for _, pattern := range []string{roundRobinPattern, hashPattern, broadcastPattern} {
+		RegFactory(pattern, factory)
+	}
+	RegRouter(roundRobinPattern, &RouterPolicy{Mode: RouterRoundRobin, Size: 3})
+	RegRouter(hashPattern, &RouterPolicy{Mode: RouterConsistentHash})
+	RegRouter(broadcastPattern, &RouterPolicy{Mode: RouterBroadcast, Size: 3})
+
+	// 轮询
+	validator := NewActorRef(NewProps(), "name-validator", roundRobinPattern)
+	counts := make(map[string]int)
+	for i := 0; i < 9; i++ {
+		re, err := validator.RequestFuture("check")
+		assert.NoError(t, err)
+		counts[re.(string)]++
+	}
+	assert.Equal(t, map[string]int{"name-validator#0": 3, "name-validator#1": 3, "name-validator#2": 3}, counts)
+	assert.False(t, System.actors.Exist("name-validator"), "the router name itself is never activated")
+
+	// 一致性哈希
+	pathfinder := NewActorRef(NewProps(), "pathfinder", hashPattern)
+	assert.Equal(t, DefaultRouterSize, System.Router("pathfinder", hashPattern).Size())
+	for i := 0; i < 10; i++ {
+		first, err := pathfinder.RequestFuture(&routerKeyed{key: fmt.Sprintf("map-%d", i)})
+		assert.NoError(t, err)
+		again, err := pathfinder.RequestFuture(&routerKeyed{key: fmt.Sprintf("map-%d", i)})
+		assert.NoError(t, err)
+		assert.Equal(t, first, again, "same key should go to the same routee")
+	}
+	_, err := pathfinder.RequestFuture("no key")
+	assert.True(t, errors.Is(err, ErrRouterNoHashKey))
+
+	// 广播
+	notifier := NewActorRef(NewProps(), "notifier", broadcastPattern)
+	assert.NoError(t, notifier.Send("reload"))
+	assert.Eventually(t, func() bool {
+		n := 0
+		for _, name := range System.Router("notifier", broadcastPattern).Routees() {
+			if v, ok := sent.Load(name); ok && v.(*atomic.Int32).Load() == 1 {
+				n++
+			}
+		}
+		return n == 3
+	}, time.Second, 10*time.Millisecond)
+	_, err = notifier.RequestFuture("reload")
+	assert.True(t, errors.Is(err, ErrRouterBroadcastRequest))
+
+	// 缩容后多余的routee停止，扩容后新routee按需激活
+	router := System.Router("name-validator", roundRobinPattern)
+	router.Resize(1)
+	assert.Equal(t, []string{"name-validator#0"}, router.Routees())
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist("name-validator#1") && !System.actors.Exist("name-validator#2")
+	}, time.Second, 10*time.Millisecond)
+	re, err := validator.RequestFuture("check")
+	assert.NoError(t, err)
+	assert.Equal(t, "name-validator#0", re)
+
+	router.Resize(2)
+	names := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		re, err = validator.RequestFuture("check")
+		assert.NoError(t, err)
+		names[re.(string)] = true
+	}
+	assert.Equal(t, map[string]bool{"name-validator#0": true, "name-validator#1": true}, names)
+
+	validator.Stop()
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist("name-validator#0") && !System.actors.Exist("name-validator#1")
+	}, time.Second, 10*time.Millisecond)
+}
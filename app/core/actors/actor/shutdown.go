@@ -0,0 +1,247 @@
+package actor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"go.uber.org/zap"
+)
+
+/*
+	按Level有序关闭
+
+	1. 等级越高停止越晚：LevelNormal 的Actor全部停止后才开始停止 LevelHigh，依此类推
+	2. 等级停止后其supervisor不再激活新的Actor，更高等级的Actor在停止过程中仍然可以访问尚未停止的等级
+	3. WithLevelDeadline 为等级设置停止期限，超过期限不再等待，继续停止下一个等级
+	4. 停止过程中每个等级剩余的Actor记录在 ShutdownProgress 中，通过 WithShutdownReporter 或 ActorSystem.ShutdownProgress 获取
+*/
+
+const (
+	shutdownSuccessThreshold = 5   // 需要连续成功的次数
+	shutdownMaxAttempts      = 100 // 最大尝试次数，防止无限循环
+	shutdownLogRemaining     = 20  // 日志中最多输出的剩余Actor数量
+)
+
+type LevelShutdownState int8
+
+const (
+	LevelShutdownPending    LevelShutdownState = iota // 等待更低的等级停止
+	LevelShutdownRunning                              // 正在停止
+	LevelShutdownDone                                 // 全部停止
+	LevelShutdownIncomplete                           // 超过期限或关闭被取消
+)
+
+func (s LevelShutdownState) String() string {
+	switch s {
+	case LevelShutdownPending:
+		return "pending"
+	case LevelShutdownRunning:
+		return "running"
+	case LevelShutdownDone:
+		return "done"
+	case LevelShutdownIncomplete:
+		return "incomplete"
+	}
+	return fmt.Sprintf("unknown(%d)", int8(s))
+}
+
+// LevelProgress 一个等级的停止进度
+type LevelProgress struct {
+	Level     Level
+	State     LevelShutdownState
+	Remaining []string // 尚未停止的Actor
+	Attempts  int
+	StartedAt time.Time
+	Elapsed   time.Duration
+	Err       error
+}
+
+// ShutdownProgress 关闭进度快照
+type ShutdownProgress struct {
+	Levels []LevelProgress
+}
+
+// Done 所有等级是否都已经停止
+func (p ShutdownProgress) Done() bool {
+	for _, lv := range p.Levels {
+		if lv.State != LevelShutdownDone {
+			return false
+		}
+	}
+	return true
+}
+
+// Remaining 返回所有等级尚未停止的Actor数量
+func (p ShutdownProgress) Remaining() int {
+	n := 0
+	for _, lv := range p.Levels {
+		n += len(lv.Remaining)
+	}
+	return n
+}
+
+type shutdownConfig struct {
+	levels    int
+	deadlines map[Level]time.Duration
+	reporter  func(progress ShutdownProgress)
+
+	mu       sync.Mutex
+	progress *ShutdownProgress
+}
+
+func (c *shutdownConfig) levelCount() int {
+	if c.levels > 0 {
+		return c.levels
+	}
+	return int(LevelMaxLimit)
+}
+
+// WithLevelCount 设置停止等级的数量，默认为 LevelMaxLimit
+// 等级为 0..n-1，超出范围的等级使用最高等级
+func WithLevelCount(n int) SystemOption {
+	return func(af *ActorSystem) {
+		af.shutdown.levels = n
+	}
+}
+
+// WithLevelDeadline 设置等级的停止期限，未设置时只受 Stop 的ctx限制
+func WithLevelDeadline(level Level, d time.Duration) SystemOption {
+	return func(af *ActorSystem) {
+		if af.shutdown.deadlines == nil {
+			af.shutdown.deadlines = make(map[Level]time.Duration)
+		}
+		af.shutdown.deadlines[level] = d
+	}
+}
+
+// WithShutdownReporter 设置关闭进度的回调，等级的状态或剩余Actor数量变化时调用
+func WithShutdownReporter(reporter func(progress ShutdownProgress)) SystemOption {
+	return func(af *ActorSystem) {
+		af.shutdown.reporter = reporter
+	}
+}
+
+// LevelCount 返回停止等级的数量
+func (af *ActorSystem) LevelCount() int {
+	return len(af.supervisors)
+}
+
+// ShutdownProgress 返回最近一次关闭的进度，尚未开始关闭时返回false
+func (af *ActorSystem) ShutdownProgress() (ShutdownProgress, bool) {
+	af.shutdown.mu.Lock()
+	defer af.shutdown.mu.Unlock()
+	if af.shutdown.progress == nil {
+		return ShutdownProgress{}, false
+	}
+	return af.shutdown.progress.clone(), true
+}
+
+func newShutdownProgress(levels int) *ShutdownProgress {
+	p := &ShutdownProgress{Levels: make([]LevelProgress, levels)}
+	for lv := range p.Levels {
+		p.Levels[lv].Level = Level(lv)
+	}
+	return p
+}
+
+func (p *ShutdownProgress) clone() ShutdownProgress {
+	c := ShutdownProgress{Levels: make([]LevelProgress, len(p.Levels))}
+	for i, lv := range p.Levels {
+		lv.Remaining = append([]string(nil), lv.Remaining...)
+		c.Levels[i] = lv
+	}
+	return c
+}
+
+// updateProgress 修改等级的进度，状态或剩余数量变化时通知 reporter
+func (af *ActorSystem) updateProgress(progress *ShutdownProgress, lv Level, fn func(p *LevelProgress)) {
+	af.shutdown.mu.Lock()
+	af.shutdown.progress = progress
+	p := &progress.Levels[lv]
+	state, remaining := p.State, len(p.Remaining)
+	fn(p)
+	if !p.StartedAt.IsZero() {
+		p.Elapsed = time.Since(p.StartedAt)
+	}
+	changed := p.State != state || len(p.Remaining) != remaining
+	var snapshot ShutdownProgress
+	if changed {
+		snapshot = progress.clone()
+	}
+	af.shutdown.mu.Unlock()
+
+	if changed && af.shutdown.reporter != nil {
+		af.shutdown.reporter(snapshot)
+	}
+}
+
+// stopLevel 停止一个等级的所有Actor，直到连续多次确认没有剩余的Actor
+func (af *ActorSystem) stopLevel(ctx context.Context, lv Level, progress *ShutdownProgress) error {
+	if d := af.shutdown.deadlines[lv]; d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	af.updateProgress(progress, lv, func(p *LevelProgress) {
+		p.State = LevelShutdownRunning
+		p.StartedAt = time.Now()
+	})
+
+	shutdownManager := NewGracefulShutdownManagerWithMaxAttempts(shutdownSuccessThreshold, shutdownMaxAttempts, func() bool {
+		resp, err := af.stopSupervisor(lv)
+		if err != nil {
+			logger.GetLogger().Error("Failed to stop supervisor",
+				zap.Int32("level", int32(lv)),
+				zap.Error(err))
+			af.updateProgress(progress, lv, func(p *LevelProgress) { p.Attempts++ })
+			return false
+		}
+
+		af.updateProgress(progress, lv, func(p *LevelProgress) {
+			p.Attempts++
+			p.Remaining = resp.Remaining
+		})
+		if !resp.Complete {
+			logger.GetLogger().Info("supervisor not completed",
+				zap.Int32("level", int32(lv)),
+				zap.Int("remaining", len(resp.Remaining)),
+				zap.Strings("actors", headNames(resp.Remaining)))
+		}
+		return resp.Complete
+	})
+
+	err := shutdownManager.Shutdown(ctx)
+	af.updateProgress(progress, lv, func(p *LevelProgress) {
+		if err != nil {
+			p.State = LevelShutdownIncomplete
+			p.Err = err
+		} else {
+			p.State = LevelShutdownDone
+			p.Remaining = nil
+		}
+	})
+	if err != nil {
+		snapshot, _ := af.ShutdownProgress()
+		remaining := snapshot.Levels[lv].Remaining
+		logger.GetLogger().Error("Level shutdown incomplete",
+			zap.Int32("level", int32(lv)),
+			zap.Int("remaining", len(remaining)),
+			zap.Strings("actors", headNames(remaining)),
+			zap.Error(err))
+		return fmt.Errorf("%w: level %d: %v", ErrShutdownIncomplete, lv, err)
+	}
+
+	logger.GetLogger().Info("Level shutdown completed", zap.Int32("level", int32(lv)))
+	return nil
+}
+
+func headNames(names []string) []string {
+	if len(names) > shutdownLogRemaining {
+		return names[:shutdownLogRemaining]
+	}
+	return names
+}
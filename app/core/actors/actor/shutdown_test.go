@@ -0,0 +1,117 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// LevelBehavior 停止时记录自己的等级，用于验证按等级有序关闭
+type LevelBehavior struct {
+	MockBehavior
+	level   Level
+	delay   time.Duration
+	mu      *sync.Mutex
+	stopped *[]Level
+}
+
+func (b *LevelBehavior) HandleStopping(ctx IContext) error {
+	time.Sleep(b.delay)
+	b.mu.Lock()
+	*b.stopped = append(*b.stopped, b.level)
+	b.mu.Unlock()
+	return nil
+}
+
+func newLevelSystem(levels int, delays map[Level]time.Duration, ops ...SystemOption) (*ActorSystem, func() []Level) {
+	var mu sync.Mutex
+	var stopped []Level
+	factories := NewFactoryRegistry()
+	registry := NewLevelRegistry()
+	for lv := Level(0); lv < Level(levels); lv++ {
+		level := lv
+		pattern := fmt.Sprintf("shutdown-level-%d", lv)
+		registry.Set(pattern, level)
+		factories.Reg(pattern, func(actorName string) Behavior {
+			return &LevelBehavior{level: level, delay: delays[level], mu: &mu, stopped: &stopped}
+		})
+	}
+	ops = append([]SystemOption{WithFactories(factories), WithLevels(registry), WithLevelCount(levels)}, ops...)
+	af := NewActorFacade(actor.NewActorSystem(), ops...)
+	return af, func() []Level {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Level(nil), stopped...)
+	}
+}
+
+func TestShutdown_LevelOrder(t *testing.T) {
+	var mu sync.Mutex
+	var reports []ShutdownProgress
+	af, stopped := newLevelSystem(3, map[Level]time.Duration{0: 30 * time.Millisecond},
+		WithShutdownReporter(func(p ShutdownProgress) {
+			mu.Lock()
+			reports = append(reports, p)
+			mu.Unlock()
+		}))
+	assert.Equal(t, 3, af.LevelCount())
+
+	// 高等级的Actor先激活，停止时仍然最后停止
+	for lv := 2; lv >= 0; lv-- {
+		for i := 0; i < 3; i++ {
+			_, err := af.GetOrStartActor(fmt.Sprintf("shutdown-%d-%d", lv, i), fmt.Sprintf("shutdown-level-%d", lv), nil)
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.NoError(t, af.Stop(context.Background()))
+	assert.Equal(t, []Level{0, 0, 0, 1, 1, 1, 2, 2, 2}, stopped())
+
+	progress, ok := af.ShutdownProgress()
+	assert.True(t, ok)
+	assert.True(t, progress.Done())
+	assert.Equal(t, 0, progress.Remaining())
+
+	// 等级0停止期间，更高的等级都在等待，且报告了剩余的Actor
+	mu.Lock()
+	defer mu.Unlock()
+	var sawRemaining bool
+	for _, r := range reports {
+		if r.Levels[0].State == LevelShutdownRunning {
+			assert.Equal(t, LevelShutdownPending, r.Levels[1].State)
+			assert.Equal(t, LevelShutdownPending, r.Levels[2].State)
+			if len(r.Levels[0].Remaining) > 0 {
+				sawRemaining = true
+				assert.Contains(t, r.Levels[0].Remaining, "shutdown-0-0")
+			}
+		}
+	}
+	assert.True(t, sawRemaining, "progress should list remaining actors")
+}
+
+func TestShutdown_LevelDeadline(t *testing.T) {
+	af, stopped := newLevelSystem(2, map[Level]time.Duration{0: time.Second}, WithLevelDeadline(0, 200*time.Millisecond))
+	_, err := af.GetOrStartActor("shutdown-slow", "shutdown-level-0", nil)
+	assert.NoError(t, err)
+	_, err = af.GetOrStartActor("shutdown-fast", "shutdown-level-1", nil)
+	assert.NoError(t, err)
+
+	// 等级0超过期限后继续停止等级1
+	start := time.Now()
+	err = af.Stop(context.Background())
+	assert.True(t, errors.Is(err, ErrShutdownIncomplete))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []Level{1}, stopped())
+
+	progress, _ := af.ShutdownProgress()
+	assert.Equal(t, LevelShutdownIncomplete, progress.Levels[0].State)
+	assert.Equal(t, []string{"shutdown-slow"}, progress.Levels[0].Remaining)
+	assert.Equal(t, LevelShutdownDone, progress.Levels[1].State)
+	assert.Eventually(t, func() bool { return len(stopped()) == 2 }, 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,159 @@
+package actor
+
+import (
+	"gitee.com/orbit-w/meteor/bases/misc/utils"
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/asynkron/protoactor-go/actor"
+	"go.uber.org/zap"
+)
+
+// 子Actor初始化状态
+const (
+	initRunning  int8 = iota // 正在执行 HandleInit
+	initAwaiting             // HandleInit 已返回，等待 AwaitInit 的异步加载完成
+	initDone
+)
+
+// asyncInitResult AwaitInit 异步加载的结果，作为内部消息投递，不会被暂存
+type asyncInitResult struct {
+	owner  *ChildActor // 发起加载的Actor实例，重启后旧实例的结果会被忽略
+	result any
+	err    error
+}
+
+func (*asyncInitResult) internalMessage() {}
+
+// stashState 子Actor暂存的消息
+type stashState struct {
+	untilInit bool                     // 初始化完成前暂存业务消息
+	messages  []*actor.MessageEnvelope // 按暂存顺序排列
+	current   bool                     // 当前处理的消息已被暂存，不回复调用者
+}
+
+// Stash 暂存当前正在处理的业务消息，调用 UnstashAll 后按暂存顺序重新处理
+// Request消息被暂存后不会立即回复调用者，调用者在消息重新处理后收到回复
+// 只能在处理业务消息(HandleRequest/HandleSend/HandleForward)或定时器消息时调用
+func (state *ChildActor) Stash() {
+	if state.context == nil {
+		return
+	}
+	switch msg := state.context.Message().(type) {
+	case *RequestMessage:
+		state.stash.messages = append(state.stash.messages, &actor.MessageEnvelope{
+			Message: msg,
+			Sender:  state.context.Sender(),
+		})
+		state.stash.current = true
+	case *TimerMessage:
+		// 定时器消息连同key一起暂存，重新处理时与到期时一样分发
+		if state.handling.timer != nil {
+			state.stash.messages = append(state.stash.messages, &actor.MessageEnvelope{Message: state.handling.timer})
+		}
+	case *timerFired:
+		state.stash.messages = append(state.stash.messages, &actor.MessageEnvelope{Message: msg})
+	default:
+		logger.GetLogger().Error("Stash called with unsupported message",
+			zap.String("ActorName", state.actorName), zap.Any("Message", msg))
+	}
+}
+
+// UnstashAll 将所有暂存的消息放回邮箱头部，它们会先于邮箱中尚未处理的消息被处理
+func (state *ChildActor) UnstashAll() {
+	if len(state.stash.messages) == 0 {
+		return
+	}
+	stashed := state.stash.messages
+	state.stash.messages = nil
+
+	if state.mailbox != nil {
+		state.mailbox.prepend(stashed)
+		return
+	}
+	for _, env := range stashed {
+		state.context.RequestWithCustomSender(state.context.Self(), env.Message, env.Sender)
+	}
+}
+
+// StashSize 返回暂存的消息数量
+func (state *ChildActor) StashSize() int {
+	return len(state.stash.messages)
+}
+
+// AwaitInit 在 HandleInit 中发起异步加载，加载完成前Actor处于未完成初始化状态
+//
+//  1. load 在独立的goroutine中执行，不允许访问Actor的状态
+//  2. complete 在Actor的goroutine中执行，用于将加载结果写入Actor状态，返回的错误视为初始化失败
+//  3. 配置了 WithStashUntilInit 时，初始化完成前收到的业务消息和定时器消息会被自动暂存
+//  4. 初始化完成后自动调用 UnstashAll；初始化失败时暂存的Request消息收到错误回复，Actor被停止
+func (state *ChildActor) AwaitInit(load func() (any, error), complete func(result any, err error) error) {
+	if state.initState != initRunning {
+		logger.GetLogger().Error("AwaitInit must be called in HandleInit", zap.String("ActorName", state.actorName))
+		return
+	}
+	state.initState = initAwaiting
+	state.initComplete = complete
+
+	self, system := state.context.Self(), state.context.ActorSystem()
+	utils.GoRecoverPanic(func() {
+		result, err := load()
+		system.Root.Send(self, &asyncInitResult{owner: state, result: result, err: err})
+	})
+}
+
+// stashing 初始化完成前或迁移冻结期间是否需要自动暂存消息
+func (state *ChildActor) stashing() bool {
+	return state.migration.frozen || (state.stash.untilInit && state.initState != initDone)
+}
+
+// handleAsyncInitResult 异步加载完成，在Actor的goroutine中完成初始化
+func (state *ChildActor) handleAsyncInitResult(context actor.Context, msg *asyncInitResult) {
+	if msg.owner != state || state.initState != initAwaiting {
+		return
+	}
+
+	err := msg.err
+	if state.initComplete != nil {
+		err = state.initComplete(msg.result, err)
+	}
+	state.initComplete = nil
+	state.initState = initDone
+
+	if err != nil {
+		logger.GetLogger().Error("Child actor async initialization failed", zap.String("ActorName", state.actorName), zap.Error(err))
+		state.dropStash(err)
+		context.Send(context.Parent(), &PoisonActorMessage{
+			ActorName: state.actorName,
+			Pattern:   state.pattern,
+		})
+		return
+	}
+
+	logger.GetLogger().Info("Child actor async initialization completed", zap.String("ActorName", state.actorName))
+	state.UnstashAll()
+}
+
+// dropStash 丢弃所有暂存的消息：Request消息回复 reason，Send/Forward消息发布到死信
+func (state *ChildActor) dropStash(reason error) {
+	stashed := state.stash.messages
+	state.stash.messages = nil
+	for _, env := range stashed {
+		msg, ok := env.Message.(*RequestMessage)
+		if !ok {
+			// 定时器消息随实例一起丢弃
+			continue
+		}
+		if msg.MsgType == MessageTypeRequest && env.Sender != nil {
+			state.context.Send(env.Sender, reason)
+			continue
+		}
+		if system := state.GetSystem(); system != nil && system.DeadLetters() != nil {
+			system.DeadLetters().Publish(&DeadLetter{
+				ActorName: state.actorName,
+				Pattern:   state.pattern,
+				Message:   msg,
+				Sender:    env.Sender,
+				Reason:    reason,
+			})
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AsyncLoadBehavior 在 HandleInit 中异步加载数据，加载完成前收到的消息应当被暂存
+type AsyncLoadBehavior struct {
+	release chan error
+	loaded  []any
+	data    string
+}
+
+func (b *AsyncLoadBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	b.loaded = append(b.loaded, msg)
+	return b.data, nil
+}
+
+func (b *AsyncLoadBehavior) HandleSend(ctx IContext, msg any) {
+	b.loaded = append(b.loaded, msg)
+}
+
+func (b *AsyncLoadBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *AsyncLoadBehavior) HandleInit(ctx IContext) error {
+	ctx.AwaitInit(func() (any, error) {
+		if err := <-b.release; err != nil {
+			return nil, err
+		}
+		return "loaded", nil
+	}, func(result any, err error) error {
+		if err != nil {
+			return err
+		}
+		b.data = result.(string)
+		return nil
+	})
+	return nil
+}
+
+func (b *AsyncLoadBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *AsyncLoadBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+// GateBehavior 未打开时手动暂存消息，收到"open"后打开并取出所有暂存消息
+type GateBehavior struct {
+	open     bool
+	received chan any
+}
+
+func (b *GateBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if msg == "open" {
+		b.open = true
+		ctx.UnstashAll()
+		return ctx.StashSize(), nil
+	}
+	if !b.open {
+		ctx.Stash()
+		return nil, nil
+	}
+	return msg, nil
+}
+
+func (b *GateBehavior) HandleSend(ctx IContext, msg any) {
+	if !b.open {
+		ctx.Stash()
+		return
+	}
+	b.received <- msg
+}
+
+func (b *GateBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *GateBehavior) HandleInit(ctx IContext) error {
+	return nil
+}
+
+func (b *GateBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *GateBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func TestStash_UntilAsyncInit(t *testing.T) {
+	const pattern = "stash-async-init-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	release := make(chan error)
+	behavior := &AsyncLoadBehavior{release: release}
+	RegFactory(pattern, func(actorName string) Behavior {
+		return behavior
+	})
+
+	ref := NewActorRef(NewProps(), "stash-async-init-actor", pattern, WithStashUntilInit())
+	assert.NoError(t, ref.Send(1))
+	assert.NoError(t, ref.SendPriority(2))
+
+	results := make(chan any, 1)
+	go func() {
+		re, err := ref.RequestFuture(3)
+		assert.NoError(t, err)
+		results <- re
+	}()
+
+	select {
+	case <-results:
+		t.Fatal("request should be stashed until init completes")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release <- nil
+	select {
+	case re := <-results:
+		assert.Equal(t, "loaded", re)
+	case <-time.After(time.Second):
+		t.Fatal("stashed request not handled after init")
+	}
+	assert.Equal(t, []any{2, 1, 3}, behavior.loaded)
+}
+
+func TestStash_AsyncInitFailed(t *testing.T) {
+	const pattern = "stash-async-init-failed-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	release := make(chan error, 1)
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &AsyncLoadBehavior{release: release}
+	})
+
+	loadErr := errors.New("load failed")
+	name := "stash-async-init-failed-actor"
+	ref := NewActorRef(NewProps(), name, pattern, WithStashUntilInit())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		release <- loadErr
+	}()
+
+	_, err := ref.RequestFuture("req")
+	assert.True(t, errors.Is(err, loadErr))
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist(name)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStash_Manual(t *testing.T) {
+	const pattern = "stash-manual-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	received := make(chan any, 4)
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &GateBehavior{received: received}
+	})
+
+	ref := NewActorRef(NewProps(), "stash-manual-actor", pattern)
+	assert.NoError(t, ref.Send("a"))
+	assert.NoError(t, ref.Send("b"))
+
+	results := make(chan any, 1)
+	go func() {
+		re, err := ref.RequestFuture("c")
+		assert.NoError(t, err)
+		results <- re
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	re, err := ref.RequestFuture("open")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, re)
+
+	assert.Equal(t, "a", <-received)
+	assert.Equal(t, "b", <-received)
+	select {
+	case re := <-results:
+		assert.Equal(t, "c", re)
+	case <-time.After(time.Second):
+		t.Fatal("stashed request not answered")
+	}
+}
@@ -0,0 +1,259 @@
+package actor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"github.com/asynkron/protoactor-go/actor"
+	"go.uber.org/zap"
+)
+
+// Directive 子Actor崩溃(panic)后的处理指令
+type Directive int
+
+const (
+	// DirectiveResume 忽略本次崩溃，保留Actor当前状态继续处理后续消息
+	DirectiveResume Directive = iota
+	// DirectiveRestart 重建Actor实例并重新执行 Behavior.HandleInit
+	DirectiveRestart
+	// DirectiveStop 停止Actor，下一次访问时由 GetOrStartActor 重新激活
+	DirectiveStop
+	// DirectiveEscalate 将故障上报给所属等级的Supervisor，
+	// Supervisor会被重启，同等级下的所有Actor都会被停止
+	DirectiveEscalate
+)
+
+func (d Directive) String() string {
+	switch d {
+	case DirectiveResume:
+		return "resume"
+	case DirectiveRestart:
+		return "restart"
+	case DirectiveStop:
+		return "stop"
+	case DirectiveEscalate:
+		return "escalate"
+	default:
+		return fmt.Sprintf("directive(%d)", int(d))
+	}
+}
+
+func (d Directive) toProtoDirective() actor.Directive {
+	switch d {
+	case DirectiveRestart:
+		return actor.RestartDirective
+	case DirectiveStop:
+		return actor.StopDirective
+	case DirectiveEscalate:
+		return actor.EscalateDirective
+	default:
+		return actor.ResumeDirective
+	}
+}
+
+// CrashRecord 记录一次子Actor崩溃的现场信息
+type CrashRecord struct {
+	ActorName string
+	Pattern   string
+	Reason    any
+	Message   any    // 导致崩溃的消息
+	Stack     []byte // 崩溃时的调用栈
+	Directive Directive
+	Failures  int           // 统计窗口内的崩溃次数(包含本次)
+	Backoff   time.Duration // 重启前的等待时间，仅在 DirectiveRestart 时有效
+	Time      time.Time
+}
+
+// ActorCrash 子Actor在处理消息时发生panic后上抛的故障原因
+// 由 ChildActor 捕获原始panic并附带调用栈后重新抛出，交由Supervisor按策略处理
+type ActorCrash struct {
+	ActorName string
+	Pattern   string
+	Reason    any
+	Stack     []byte
+}
+
+func (c *ActorCrash) Error() string {
+	return fmt.Sprintf("actor %s(%s) crashed: %v", c.ActorName, c.Pattern, c.Reason)
+}
+
+// SupervisionPolicy 子Actor的监督策略，按Pattern注册
+//
+//  1. Decider 为空时，所有崩溃都使用 Directive 处理
+//  2. 在 Within 时间窗口内重启次数超过 MaxRestarts 时，Actor会被停止，MaxRestarts<=0 表示不限制
+//  3. 重启前等待 Backoff * 2^(n-1)，最大不超过 MaxBackoff，等待期间新消息会保留在邮箱中
+//  4. OnCrash 在每次崩溃时调用，为空时输出带调用栈的错误日志
+type SupervisionPolicy struct {
+	Directive   Directive
+	Decider     func(reason any) Directive
+	MaxRestarts int
+	Within      time.Duration
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	OnCrash     func(record *CrashRecord)
+}
+
+// DefaultSupervisionPolicy 未注册策略的Pattern使用的默认监督策略
+// 忽略崩溃，保留Actor当前状态继续处理后续消息
+var DefaultSupervisionPolicy = &SupervisionPolicy{
+	Directive: DirectiveResume,
+}
+
+// SupervisionPolicyRegistry 按Pattern注册的监督策略，每个 ActorSystem 可以使用独立的注册表(WithSupervisionPolicies)
+// 未注册的Pattern使用 DefaultSupervisionPolicy
+type SupervisionPolicyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]*SupervisionPolicy
+}
+
+func NewSupervisionPolicyRegistry() *SupervisionPolicyRegistry {
+	return &SupervisionPolicyRegistry{
+		policies: make(map[string]*SupervisionPolicy),
+	}
+}
+
+// defaultSupervisionPolicies 未指定注册表的 ActorSystem 以及包级别的 RegSupervisionPolicy 使用的注册表
+var defaultSupervisionPolicies = NewSupervisionPolicyRegistry()
+
+// DefaultSupervisionPolicies 返回默认的监督策略注册表
+func DefaultSupervisionPolicies() *SupervisionPolicyRegistry {
+	return defaultSupervisionPolicies
+}
+
+// Reg 为Pattern注册监督策略，重复注册时panic
+func (r *SupervisionPolicyRegistry) Reg(pattern string, policy *SupervisionPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[pattern]; ok {
+		panic("supervision policy already registered: " + pattern)
+	}
+	r.policies[pattern] = policy
+}
+
+// Get 返回Pattern的监督策略，未注册时返回 DefaultSupervisionPolicy
+func (r *SupervisionPolicyRegistry) Get(pattern string) *SupervisionPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if policy, ok := r.policies[pattern]; ok && policy != nil {
+		return policy
+	}
+	return DefaultSupervisionPolicy
+}
+
+// RegSupervisionPolicy registers a supervision policy for a specific actor pattern
+func RegSupervisionPolicy(pattern string, policy *SupervisionPolicy) {
+	defaultSupervisionPolicies.Reg(pattern, policy)
+}
+
+// GetSupervisionPolicy returns the supervision policy of the pattern,
+// falling back to DefaultSupervisionPolicy
+func GetSupervisionPolicy(pattern string) *SupervisionPolicy {
+	return defaultSupervisionPolicies.Get(pattern)
+}
+
+func (p *SupervisionPolicy) decide(reason any) Directive {
+	if p.Decider != nil {
+		return p.Decider(reason)
+	}
+	return p.Directive
+}
+
+// backoff 计算第 failures 次崩溃后的重启等待时间
+func (p *SupervisionPolicy) backoff(failures int) time.Duration {
+	if p.Backoff <= 0 || failures <= 0 {
+		return 0
+	}
+	d := p.Backoff
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+func (p *SupervisionPolicy) crash(record *CrashRecord) {
+	if p.OnCrash != nil {
+		p.OnCrash(record)
+		return
+	}
+	logger.GetLogger().Error("Child actor crashed",
+		zap.String("ActorName", record.ActorName),
+		zap.String("Pattern", record.Pattern),
+		zap.Any("Reason", record.Reason),
+		zap.String("Directive", record.Directive.String()),
+		zap.Int("Failures", record.Failures),
+		zap.Duration("Backoff", record.Backoff),
+		zap.ByteString("Stack", record.Stack))
+}
+
+// childSupervisorStrategy 实现protoactor的SupervisorStrategy，
+// 按崩溃Actor的Pattern在所属 ActorSystem 的注册表中查找 SupervisionPolicy 决定处理方式
+type childSupervisorStrategy struct {
+	system *ActorSystem
+}
+
+func newChildSupervisorStrategy(af *ActorSystem) actor.SupervisorStrategy {
+	return &childSupervisorStrategy{system: af}
+}
+
+func (s *childSupervisorStrategy) HandleFailure(actorSystem *actor.ActorSystem, supervisor actor.Supervisor, child *actor.PID,
+	rs *actor.RestartStatistics, reason any, message any) {
+	record := &CrashRecord{
+		ActorName: ExtractActorName(child),
+		Reason:    reason,
+		Message:   message,
+		Time:      time.Now(),
+	}
+	if crash, ok := reason.(*ActorCrash); ok {
+		record.ActorName = crash.ActorName
+		record.Pattern = crash.Pattern
+		record.Reason = crash.Reason
+		record.Stack = crash.Stack
+	}
+
+	policy := s.system.SupervisionPolicies().Get(record.Pattern)
+	record.Directive = policy.decide(record.Reason)
+
+	if record.Directive == DirectiveRestart {
+		rs.Fail()
+		record.Failures = rs.NumberOfFailures(policy.Within)
+		if policy.MaxRestarts > 0 && record.Failures > policy.MaxRestarts {
+			// 重启预算耗尽，停止Actor
+			rs.Reset()
+			record.Directive = DirectiveStop
+		} else {
+			record.Backoff = policy.backoff(record.Failures)
+		}
+	}
+
+	policy.crash(record)
+	actorSystem.EventStream.Publish(&actor.SupervisorEvent{
+		Child:     child,
+		Reason:    reason,
+		Directive: record.Directive.toProtoDirective(),
+	})
+
+	switch record.Directive {
+	case DirectiveResume:
+		supervisor.ResumeChildren(child)
+	case DirectiveRestart:
+		if record.Backoff > 0 {
+			time.AfterFunc(record.Backoff, func() {
+				supervisor.RestartChildren(child)
+			})
+		} else {
+			supervisor.RestartChildren(child)
+		}
+	case DirectiveStop:
+		supervisor.StopChildren(child)
+	case DirectiveEscalate:
+		supervisor.EscalateFailure(reason, message)
+	}
+}
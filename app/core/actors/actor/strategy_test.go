@@ -0,0 +1,192 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// CrashBehavior 收到"panic"消息时崩溃，用于测试监督策略
+type CrashBehavior struct {
+	inits *atomic.Int32
+	state int
+}
+
+func (b *CrashBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if msg == "panic" {
+		b.state = -1
+		panic("boom")
+	}
+	b.state++
+	return b.state, nil
+}
+
+func (b *CrashBehavior) HandleSend(ctx IContext, msg any) {
+}
+
+func (b *CrashBehavior) HandleForward(ctx IContext, _ any) {
+}
+
+func (b *CrashBehavior) HandleInit(ctx IContext) error {
+	b.inits.Add(1)
+	return nil
+}
+
+func (b *CrashBehavior) HandleStopping(ctx IContext) error {
+	return nil
+}
+
+func (b *CrashBehavior) HandleStopped(ctx IContext) error {
+	return nil
+}
+
+func TestSupervisionPolicy_Backoff(t *testing.T) {
+	policy := &SupervisionPolicy{
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+
+	assert.Equal(t, time.Duration(0), policy.backoff(0))
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(100))
+
+	assert.Equal(t, time.Duration(0), (&SupervisionPolicy{}).backoff(3))
+}
+
+// 校验Restart策略：崩溃后重新执行HandleInit，状态被重置，并记录带调用栈的崩溃信息
+func TestSupervisionPolicy_Restart(t *testing.T) {
+	const pattern = "supervision-restart-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	var inits atomic.Int32
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &CrashBehavior{inits: &inits}
+	})
+
+	records := make(chan *CrashRecord, 1)
+	RegSupervisionPolicy(pattern, &SupervisionPolicy{
+		Directive:   DirectiveRestart,
+		MaxRestarts: 3,
+		Within:      time.Minute,
+		OnCrash: func(record *CrashRecord) {
+			records <- record
+		},
+	})
+
+	ref := NewActorRef(NewProps(), "supervision-restart-actor", pattern)
+	re, err := ref.RequestFuture("inc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, re)
+
+	_, err = ref.RequestFuture("panic")
+	assert.True(t, errors.Is(err, ErrActorCrashed))
+
+	select {
+	case record := <-records:
+		assert.Equal(t, "supervision-restart-actor", record.ActorName)
+		assert.Equal(t, pattern, record.Pattern)
+		assert.Equal(t, "boom", record.Reason)
+		assert.Equal(t, DirectiveRestart, record.Directive)
+		assert.Equal(t, 1, record.Failures)
+		assert.NotEmpty(t, record.Stack)
+	case <-time.After(time.Second):
+		t.Fatal("crash record not reported")
+	}
+
+	re, err = ref.RequestFuture("inc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, re, "restarted actor should start from fresh state")
+	assert.Equal(t, int32(2), inits.Load(), "HandleInit should run again after restart")
+}
+
+// 校验Stop策略：崩溃后Actor被停止，下一次访问时重新激活
+func TestSupervisionPolicy_Stop(t *testing.T) {
+	const pattern = "supervision-stop-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	var inits atomic.Int32
+	RegFactory(pattern, func(actorName string) Behavior {
+		return &CrashBehavior{inits: &inits}
+	})
+	RegSupervisionPolicy(pattern, &SupervisionPolicy{
+		Directive: DirectiveStop,
+		OnCrash:   func(record *CrashRecord) {},
+	})
+
+	name := "supervision-stop-actor"
+	ref := NewActorRef(NewProps(), name, pattern)
+	_, err := ref.RequestFuture("panic")
+	assert.Error(t, err)
+
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist(name)
+	}, time.Second, 10*time.Millisecond)
+
+	re, err := ref.RequestFuture("inc")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, re)
+	assert.Equal(t, int32(2), inits.Load())
+}
+
+// blockingInitBehavior HandleInit 阻塞到 release 关闭
+type blockingInitBehavior struct {
+	MockBehavior
+	release chan struct{}
+}
+
+func (b *blockingInitBehavior) HandleInit(ctx IContext) error {
+	<-b.release
+	return nil
+}
+
+// 校验Escalate策略：Supervisor重启时，等待启动结果的调用者立即收到 ErrSupervisionRestarting
+func TestSupervisionPolicy_EscalateFailsPendingStarts(t *testing.T) {
+	const (
+		slowPattern  = "supervision-escalate-slow-pattern"
+		crashPattern = "supervision-escalate-crash-pattern"
+	)
+	var inits atomic.Int32
+	release := make(chan struct{})
+	factories := NewFactoryRegistry()
+	factories.Reg(slowPattern, func(actorName string) Behavior {
+		return &blockingInitBehavior{release: release}
+	})
+	factories.Reg(crashPattern, func(actorName string) Behavior {
+		return &CrashBehavior{inits: &inits}
+	})
+	RegSupervisionPolicy(crashPattern, &SupervisionPolicy{Directive: DirectiveEscalate})
+
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+	defer close(release)
+
+	crash := af.NewActorRef(NewProps(), "supervision-escalate-crash", crashPattern)
+	_, err := crash.RequestFuture("inc")
+	assert.NoError(t, err)
+
+	pending := make(chan error, 1)
+	go func() {
+		_, err := af.GetOrStartActor("supervision-escalate-slow", slowPattern, NewProps())
+		pending <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	_, _ = crash.RequestFuture("panic", 100*time.Millisecond)
+
+	select {
+	case err = <-pending:
+		assert.True(t, errors.Is(err, ErrSupervisionRestarting))
+	case <-time.After(time.Second):
+		t.Fatal("pending start was not failed on supervisor restart")
+	}
+}
@@ -2,6 +2,7 @@ package actor
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -23,6 +24,7 @@ const (
 type ActorSupervision struct {
 	state       atomic.Int32
 	level       Level
+	system      *ActorSystem
 	actorSystem *actor.ActorSystem
 	starting    *Queue
 	stopping    *Queue
@@ -31,10 +33,11 @@ type ActorSupervision struct {
 }
 
 // NewActorSupervision creates a new instance of ActorManager
-func NewActorSupervision(actorSystem *actor.ActorSystem, level Level) *ActorSupervision {
+func NewActorSupervision(system *ActorSystem, level Level) *ActorSupervision {
 	return &ActorSupervision{
 		level:       level,
-		actorSystem: actorSystem,
+		system:      system,
+		actorSystem: system.ActorSystem(),
 		starting:    NewPriorityQueue(),
 		stopping:    NewPriorityQueue(),
 		restarting:  NewPriorityQueue(),
@@ -73,6 +76,12 @@ func (m *ActorSupervision) Receive(context actor.Context) {
 	case *StopAllRequest:
 		m.handleStoppingAll(context)
 
+	case *inspectSupervisor:
+		context.Respond(m.snapshot(context))
+
+	case *actor.Restarting:
+		m.handleRestarting(context)
+
 	case *actor.Stopped:
 		m.handleStopped(context)
 
@@ -85,7 +94,7 @@ func (m *ActorSupervision) Receive(context actor.Context) {
 // 异步启动Actor
 func (m *ActorSupervision) handleStartActor(context actor.Context, msg *StartActorRequest) {
 	// 如果Actor已经存在，则直接返回
-	if p, exists := actorsCache.Get(msg.ActorName); exists {
+	if p, exists := m.system.actors.Get(msg.ActorName); exists {
 		context.Respond(p)
 		return
 	}
@@ -102,25 +111,30 @@ func (m *ActorSupervision) handleStartActor(context actor.Context, msg *StartAct
 		if m.restarting.Exists(msg.ActorName) {
 			m.restarting.PushFuture(msg.ActorName, msg.Future)
 		} else {
-			m.restarting.Insert(msg.ActorName, NewItem(msg.ActorName, msg.Pattern, nil, msg.Props, msg.Future), time.Now().UnixNano())
+			item := NewItem(msg.ActorName, msg.Pattern, nil, msg.Props, msg.Future)
+			item.migrationState = msg.MigrationState
+			m.restarting.Insert(msg.ActorName, item, time.Now().UnixNano())
 		}
 		context.Respond(startActorWaitMessage)
 		return
 	}
 
 	if m.isAllActorStopped() {
+		m.activationFailed(msg.ActorName)
 		context.Respond(ErrSupervisionStopped)
 		return
 	}
 
-	pid, err := m.startActor(context, msg.Pattern, msg.ActorName, msg.Props)
+	pid, mb, err := m.startActor(context, msg.Pattern, msg.ActorName, msg.Props, msg.MigrationState)
 	if err != nil {
+		m.activationFailed(msg.ActorName)
 		context.Respond(err)
 		return
 	}
 
 	// 将Actor添加到正在启动的列表中
 	item := NewItem(msg.ActorName, msg.Pattern, pid, msg.Props, msg.Future)
+	item.mailbox = mb
 	err = m.starting.Insert(msg.ActorName, item, time.Now().UnixNano())
 	if err != nil {
 		m.logger.Error("[StartActor] Failed to insert starting queue", zap.String("ActorName", msg.ActorName), zap.Error(err))
@@ -131,32 +145,48 @@ func (m *ActorSupervision) handleStartActor(context actor.Context, msg *StartAct
 	context.Respond(startActorWaitMessage)
 }
 
-func (m *ActorSupervision) startActor(context actor.Context, pattern, actorName string, props *Props) (*actor.PID, error) {
+// activationFailed Actor没有创建就激活失败，不会收到 Terminated，释放调用者为激活而取得的集群归属租约
+// 已经创建的Actor初始化失败时在 handleActorStopped 中释放
+func (m *ActorSupervision) activationFailed(actorName string) {
+	if o := m.system.ownership; o != nil {
+		o.passivated(actorName)
+	}
+}
+
+// startActor 创建Actor，migrationState 只交给第一个实例，崩溃重启时工厂再次创建的实例不再恢复
+func (m *ActorSupervision) startActor(context actor.Context, pattern, actorName string, props *Props, migrationState []byte) (*actor.PID, *mailbox, error) {
+	mb := newMailbox(context.ActorSystem(), actorName, pattern, m.system.Mailboxes().Get(pattern))
+	mb.deadLetters = m.system.DeadLetters()
+
 	// 创建Actor工厂函数
 	actorFactory := func() actor.Actor {
-		behavior := CreateBehaviorWithID(pattern, actorName)
+		behavior := m.system.Factories().Create(pattern, actorName)
 
 		// 设置初始化完成通知
 		childActor := NewChildActor(behavior, actorName, pattern, props.GetMeta(), props.GetAliveTimeout(), func(err error) error {
 			context.Send(context.Self(), &ChildStartedNotification{ActorName: actorName, Error: err})
 			return nil
 		})
+		childActor.mailbox = mb
+		childActor.system = m.system
+		childActor.SetProps(props)
+		childActor.migration.state, migrationState = migrationState, nil
 
 		return childActor
 	}
 
 	// Create new actor
-	pid, err := context.SpawnNamed(actor.PropsFromProducer(actorFactory), actorName)
+	pid, err := context.SpawnNamed(actor.PropsFromProducer(actorFactory, actor.WithMailbox(mb.producer())), actorName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return pid, nil
+	return pid, mb, nil
 }
 
 // handleStopActor handles stopping an actor
 func (m *ActorSupervision) handlePoisonActor(context actor.Context, msg *PoisonActorMessage) {
-	p, exists := actorsCache.Get(msg.ActorName)
+	p, exists := m.system.actors.Get(msg.ActorName)
 	if !exists {
 		context.Respond(nil)
 		return
@@ -171,7 +201,7 @@ func (m *ActorSupervision) handlePoisonActor(context actor.Context, msg *PoisonA
 // 而不是等到Supervisor收到目标Actor Terminated的信号后才返回调用者
 func (m *ActorSupervision) poisonActor(context actor.Context, name string, p *Process) {
 	// 立刻将Process从缓存中删除
-	actorsCache.Delete(name)
+	m.system.actors.Delete(name)
 	// 立刻将Process状态设置为停止状态
 	p.Stop()
 	// 异步停止Actor
@@ -188,23 +218,31 @@ func (m *ActorSupervision) stopActorWithPID(context actor.Context, actorName, pa
 
 	// 从缓存中删除Actor
 	if clear {
-		actorsCache.Delete(actorName)
+		m.system.actors.Delete(actorName)
 	}
 }
 
 // handleActorStopped handles notification that an actor has stopped
 func (m *ActorSupervision) handleActorStopped(context actor.Context, actorName string) {
+	restarted := false
 	defer func() {
-		actorsCache.Delete(actorName)
+		m.system.actors.Delete(actorName)
+		// Actor没有在本节点重新激活时释放(或移交)集群归属租约
+		if o := m.system.ownership; o != nil {
+			if restarted {
+				o.cancelHandover(actorName)
+			} else {
+				o.passivated(actorName)
+			}
+		}
 	}()
 
-	_, ok := m.stopping.Pop(actorName)
+	stopped, ok := m.stopping.Pop(actorName)
 	if !ok {
-		if m.state.Load() < StateActorSupervisionStopping {
-			m.logger.Error("Actor stopped notification received for unknown actor", zap.String("ActorName", actorName))
-		}
+		m.handleActorStoppedUnexpectedly(context, actorName)
 		return
 	}
+	m.system.Metrics().ActorStopped(stopped.Pattern)
 
 	// 如果所有Actor都已经停止，则不启动新的Actor
 	if m.isAllActorStopped() {
@@ -213,7 +251,7 @@ func (m *ActorSupervision) handleActorStopped(context actor.Context, actorName s
 
 	watching, ok := m.restarting.Pop(actorName)
 	if ok && watching.FuturesNum() > 0 {
-		pid, err := m.startActor(context, watching.Pattern, actorName, watching.Props)
+		pid, mb, err := m.startActor(context, watching.Pattern, actorName, watching.Props, watching.migrationState)
 		if err != nil {
 			m.logger.Error("Failed to start actor", zap.String("ActorName", actorName), zap.Error(err))
 			for i := range watching.Future {
@@ -223,13 +261,58 @@ func (m *ActorSupervision) handleActorStopped(context actor.Context, actorName s
 			return
 		}
 
+		restarted = true
 		newItem := NewItem(actorName, watching.Pattern, pid, watching.Props, watching.Future...)
+		newItem.mailbox = mb
+		newItem.createdAt = watching.createdAt
 		if err := m.starting.Insert(actorName, newItem, time.Now().UnixNano()); err != nil {
 			logger.GetLogger().Error("[HandleActorRestart] Failed to insert starting queue", zap.String("ActorName", actorName), zap.Error(err))
 		}
 	}
 }
 
+// handleRestarting 子Actor上报故障(DirectiveEscalate)后Supervisor被重启，同等级的Actor全部停止
+// 新的Supervisor实例使用空的队列，旧实例不再重新激活Actor，等待启动结果的调用者立即收到 ErrSupervisionRestarting
+func (m *ActorSupervision) handleRestarting(context actor.Context) {
+	m.state.Store(StateActorSupervisionStopped)
+	for _, q := range []*Queue{m.starting, m.restarting} {
+		for _, item := range q.Items() {
+			for _, future := range item.Futures() {
+				context.Send(future, ErrSupervisionRestarting)
+			}
+		}
+		q.Free()
+	}
+	m.logger.Warn("Supervision restarting after escalated failure", zap.Int("Level", int(m.level)))
+}
+
+// handleActorStoppedUnexpectedly 处理非主动停止的Actor终止通知
+// 例如Actor崩溃后被 SupervisionPolicy 停止，此时需要：
+//  1. 将缓存中的Process标记为停止状态，之后的消息会重新激活Actor
+//  2. 如果Actor在初始化期间终止，通知所有等待启动结果的调用者
+func (m *ActorSupervision) handleActorStoppedUnexpectedly(context actor.Context, actorName string) {
+	p, exists := m.system.actors.Get(actorName)
+	if exists {
+		p.Stop()
+		m.system.Metrics().ActorStopped(p.Pattern)
+	}
+
+	if item, ok := m.starting.Pop(actorName); ok {
+		if !exists {
+			m.system.Metrics().ActorStopped(item.Pattern)
+		}
+		for _, future := range item.Futures() {
+			context.Send(future, ErrActorStopped)
+		}
+		m.logger.Error("Actor terminated during initialization", zap.String("ActorName", actorName))
+		return
+	}
+
+	if m.state.Load() < StateActorSupervisionStopping {
+		m.logger.Warn("Actor terminated unexpectedly", zap.String("ActorName", actorName))
+	}
+}
+
 func (m *ActorSupervision) handleNotifyChildStarted(context actor.Context, msg *ChildStartedNotification) {
 	//TODO: 需要修复逻辑顺序问题
 	watchers := make([]*actor.PID, 0)
@@ -239,20 +322,24 @@ func (m *ActorSupervision) handleNotifyChildStarted(context actor.Context, msg *
 	})
 
 	if !ok {
-		m.logger.Error("ChildStartedNotification received for unknown actor", zap.String("ActorName", msg.ActorName))
+		m.handleChildRestarted(context, msg)
 		return
 	}
 
 	if msg.Error == nil {
 		m.logger.Info("Child actor started", zap.String("ActorName", msg.ActorName))
+		m.system.Metrics().ActorStarted(item.Pattern, time.Since(item.createdAt))
 		p := NewActorProcess(msg.ActorName, item.Pattern, item.Child, item.Props)
-		actorsCache.Set(msg.ActorName, p)
+		p.mailbox = item.mailbox
+		p.system = m.system
+		m.system.actors.Set(msg.ActorName, p)
 		for i := range watchers {
 			w := watchers[i]
 			context.Send(w, p)
 		}
 	} else {
 		m.logger.Error("Child actor started with error", zap.String("ActorName", msg.ActorName), zap.Error(msg.Error))
+		m.system.Metrics().ActorInitFailed(item.Pattern)
 		m.stopActorWithPID(context, msg.ActorName, item.Pattern, item.Child, false)
 		for i := range watchers {
 			w := watchers[i]
@@ -261,6 +348,25 @@ func (m *ActorSupervision) handleNotifyChildStarted(context actor.Context, msg *
 	}
 }
 
+// handleChildRestarted 处理Actor被 SupervisionPolicy 重启后重新执行 HandleInit 的通知
+// 重新初始化失败时停止Actor
+func (m *ActorSupervision) handleChildRestarted(context actor.Context, msg *ChildStartedNotification) {
+	p, exists := m.system.actors.Get(msg.ActorName)
+	if !exists {
+		m.logger.Error("ChildStartedNotification received for unknown actor", zap.String("ActorName", msg.ActorName))
+		return
+	}
+
+	if msg.Error != nil {
+		m.logger.Error("Child actor restarted with error", zap.String("ActorName", msg.ActorName), zap.Error(msg.Error))
+		m.system.Metrics().ActorInitFailed(p.Pattern)
+		m.poisonActor(context, msg.ActorName, p)
+		return
+	}
+
+	m.logger.Info("Child actor restarted", zap.String("ActorName", msg.ActorName))
+}
+
 func (m *ActorSupervision) handleStoppingAll(context actor.Context) {
 	m.state.Store(StateActorSupervisionStopping)
 	children := context.Children()
@@ -270,7 +376,7 @@ func (m *ActorSupervision) handleStoppingAll(context actor.Context) {
 		if m.stopping.Exists(name) {
 			continue
 		}
-		p, exists := actorsCache.Get(name)
+		p, exists := m.system.actors.Get(name)
 		if exists {
 			m.poisonActor(context, name, p)
 		} else {
@@ -278,8 +384,9 @@ func (m *ActorSupervision) handleStoppingAll(context actor.Context) {
 		}
 	}
 
+	remaining := m.remaining(context)
 	if !m.starting.Empty() || !m.stopping.Empty() || !m.restarting.Empty() {
-		context.Respond(&StopAllResponse{Complete: false})
+		context.Respond(&StopAllResponse{Complete: false, Remaining: remaining})
 		return
 	}
 
@@ -287,7 +394,26 @@ func (m *ActorSupervision) handleStoppingAll(context actor.Context) {
 	if complete {
 		m.state.Store(StateActorSupervisionStopped)
 	}
-	context.Respond(&StopAllResponse{Complete: complete})
+	context.Respond(&StopAllResponse{Complete: complete, Remaining: remaining})
+}
+
+// remaining 返回尚未停止的Actor，按名称排序
+func (m *ActorSupervision) remaining(context actor.Context) []string {
+	set := make(map[string]struct{})
+	for _, child := range context.Children() {
+		set[ExtractActorName(child)] = struct{}{}
+	}
+	for _, q := range []*Queue{m.starting, m.stopping, m.restarting} {
+		for _, name := range q.Keys() {
+			set[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (m *ActorSupervision) handleStopped(_ actor.Context) {
@@ -11,6 +11,7 @@ import (
 	"gitee.com/orbit-w/meteor/bases/misc/utils"
 	"gitee.com/orbit-w/orbit/lib/logger"
 	"github.com/asynkron/protoactor-go/actor"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -23,16 +24,11 @@ const (
 )
 
 var (
+	// System 默认的ActorSystem，包级别的 NewActorRef、GetOrStartActor、StopActor 使用此实例
 	System              *ActorSystem
-	once                sync.Once
-	ActorFacadeStopping atomic.Bool
-)
+	ActorFacadeStopping atomic.Bool // 默认的 System 开始停止
 
-func init() {
-	once.Do(func() {
-		actorsCache = NewActorsCache()
-	})
-}
+)
 
 type IService interface {
 	Start() error
@@ -44,19 +40,316 @@ type ActorSystem struct {
 	state       atomic.Int32
 	actorSystem *actor.ActorSystem
 	supervisors []*actor.PID
+	deadLetters *DeadLetterSink
+	topics      *TopicRegistry
+	remote      *Remote
+	ownership   *OwnershipRegistry
+	placement   *PlacementService
+	reminders   atomic.Pointer[ReminderService] // Actor协程中读取，可以在Actor运行后启用
+	migrations  sync.Map                        // actorName -> *migrationRecord 迁出后所在的节点
+	migrating   sync.Map                        // 正在迁出的Actor，期间的消息投递到本地被冻结的Actor
+	routers     sync.Map                        // actorName -> *Router
+	actors      *ActorsCache
+	factories   *FactoryRegistry
+	levels      *LevelRegistry
+	mailboxes   *MailboxRegistry
+	policies    *SupervisionPolicyRegistry
+	placements  *PlacementPolicyRegistry
+	routing     *RouterPolicyRegistry
+	watchdogs   *WatchdogPolicyRegistry
+	warmups     *WarmupRegistry
+	remoteErrs  *RemoteErrorRegistry
+	shutdown    shutdownConfig
+	metrics     Metrics
+
+	watchdog         *watchdog
+	watchdogInterval time.Duration
+}
+
+// SystemOption ActorSystem 的配置项
+type SystemOption func(af *ActorSystem)
+
+// WithFactories 使用独立的工厂注册表，默认使用 RegFactory 注册的工厂
+func WithFactories(factories *FactoryRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.factories = factories
+	}
+}
+
+// WithLevels 使用独立的停止等级映射，默认使用 InitPatternLevelMap 设置的映射
+func WithLevels(levels *LevelRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.levels = levels
+	}
+}
+
+// WithMailboxes 使用独立的邮箱配置注册表，默认使用 RegMailbox 注册的配置
+func WithMailboxes(mailboxes *MailboxRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.mailboxes = mailboxes
+	}
+}
+
+// WithSupervisionPolicies 使用独立的监督策略注册表，默认使用 RegSupervisionPolicy 注册的策略
+func WithSupervisionPolicies(policies *SupervisionPolicyRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.policies = policies
+	}
+}
+
+// WithPlacementPolicies 使用独立的放置策略注册表，默认使用 RegPlacementPolicy 注册的策略
+func WithPlacementPolicies(placements *PlacementPolicyRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.placements = placements
+	}
+}
+
+// WithRouterPolicies 使用独立的路由策略注册表，默认使用 RegRouter 注册的策略
+func WithRouterPolicies(routing *RouterPolicyRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.routing = routing
+	}
+}
+
+// WithWatchdogPolicies 使用独立的慢处理检测策略注册表，默认使用 RegWatchdogPolicy 注册的策略
+func WithWatchdogPolicies(watchdogs *WatchdogPolicyRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.watchdogs = watchdogs
+	}
+}
+
+// WithWarmups 使用独立的预热注册表，默认使用 RegWarmup 注册的Actor
+func WithWarmups(warmups *WarmupRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.warmups = warmups
+	}
+}
+
+// WithRemoteErrors 使用独立的跨节点错误注册表，默认使用 RegRemoteError 注册的错误
+func WithRemoteErrors(remoteErrs *RemoteErrorRegistry) SystemOption {
+	return func(af *ActorSystem) {
+		af.remoteErrs = remoteErrs
+	}
+}
+
+// NewSystem 创建尚未启动的ActorSystem，由 Start 启动
+// 同一进程中可以创建多个ActorSystem，每个实例拥有独立的Actor缓存和监督者
+func NewSystem(ops ...SystemOption) *ActorSystem {
+	af := &ActorSystem{}
+	for _, op := range ops {
+		op(af)
+	}
+	return af
 }
 
+// Start 创建protoactor系统和监督者
+// 默认的 System 为空或已经停止时，当前实例成为默认的 System
 func (af *ActorSystem) Start() error {
 	system := actor.NewActorSystem()
 	af.actorSystem = system
-	af.supervisors = make([]*actor.PID, LevelMaxLimit)
-	for lv := LevelNormal; lv < LevelMaxLimit; lv++ {
-		af.supervisors[lv] = newSupervisor(system, lv)
+	af.init()
+	if System == nil || !System.isRunning() {
+		System = af
 	}
-	System = af
 	return nil
 }
 
+func (af *ActorSystem) init() {
+	if af.actors == nil {
+		af.actors = NewActorsCache()
+	}
+	af.supervisors = make([]*actor.PID, af.shutdown.levelCount())
+	for lv := range af.supervisors {
+		af.supervisors[lv] = newSupervisor(af, Level(lv))
+	}
+	af.initDeadLetters()
+	af.topics = NewTopicRegistry()
+	af.topics.system = af
+	af.watchdog = newWatchdog(af, af.watchdogInterval)
+}
+
+// Actors 返回本实例已激活的Actor
+func (af *ActorSystem) Actors() *ActorsCache {
+	return af.actors
+}
+
+// Factories 返回本实例使用的工厂注册表
+func (af *ActorSystem) Factories() *FactoryRegistry {
+	if af.factories == nil {
+		return defaultFactories
+	}
+	return af.factories
+}
+
+// Mailboxes 返回本实例使用的邮箱配置注册表
+func (af *ActorSystem) Mailboxes() *MailboxRegistry {
+	if af.mailboxes == nil {
+		return defaultMailboxes
+	}
+	return af.mailboxes
+}
+
+// Levels 返回本实例使用的停止等级映射
+func (af *ActorSystem) Levels() *LevelRegistry {
+	if af.levels == nil {
+		return defaultLevels
+	}
+	return af.levels
+}
+
+// SupervisionPolicies 返回本实例使用的监督策略注册表
+func (af *ActorSystem) SupervisionPolicies() *SupervisionPolicyRegistry {
+	if af == nil || af.policies == nil {
+		return defaultSupervisionPolicies
+	}
+	return af.policies
+}
+
+// PlacementPolicies 返回本实例使用的放置策略注册表
+func (af *ActorSystem) PlacementPolicies() *PlacementPolicyRegistry {
+	if af == nil || af.placements == nil {
+		return defaultPlacementPolicies
+	}
+	return af.placements
+}
+
+// RouterPolicies 返回本实例使用的路由策略注册表
+func (af *ActorSystem) RouterPolicies() *RouterPolicyRegistry {
+	if af == nil || af.routing == nil {
+		return defaultRouterPolicies
+	}
+	return af.routing
+}
+
+// WatchdogPolicies 返回本实例使用的慢处理检测策略注册表
+func (af *ActorSystem) WatchdogPolicies() *WatchdogPolicyRegistry {
+	if af == nil || af.watchdogs == nil {
+		return defaultWatchdogPolicies
+	}
+	return af.watchdogs
+}
+
+// Warmups 返回本实例使用的预热注册表
+func (af *ActorSystem) Warmups() *WarmupRegistry {
+	if af == nil || af.warmups == nil {
+		return defaultWarmups
+	}
+	return af.warmups
+}
+
+// RemoteErrors 返回本实例使用的跨节点错误注册表
+func (af *ActorSystem) RemoteErrors() *RemoteErrorRegistry {
+	if af == nil || af.remoteErrs == nil {
+		return defaultRemoteErrors
+	}
+	return af.remoteErrs
+}
+
+// NewActorRef 创建绑定到当前实例的ActorRef
+func (af *ActorSystem) NewActorRef(props *Props, actorName, pattern string, ops ...PropsOption) *ActorRef {
+	ref := NewActorRef(props, actorName, pattern, ops...)
+	ref.Props.system = af
+	return ref
+}
+
+// initDeadLetters 订阅protoactor的死信事件，转发到 DeadLetterSink
+func (af *ActorSystem) initDeadLetters() {
+	if af.deadLetters == nil {
+		af.deadLetters = NewDeadLetterSink()
+	}
+	af.deadLetters.system = af
+	af.actorSystem.EventStream.Subscribe(af.deadLetters.onProtoDeadLetter)
+}
+
+// MailboxStats 返回指定Actor邮箱的统计信息，Actor未激活时返回false
+func (af *ActorSystem) MailboxStats(actorName string) (MailboxStats, bool) {
+	p, exists := af.actors.Get(actorName)
+	if !exists {
+		return MailboxStats{}, false
+	}
+	return p.MailboxStats()
+}
+
+// StartRemote 启用跨节点通信，Meta.Dispatcher.NodeId 指向其他节点的 ActorRef 会将消息转发到对应节点
+func (af *ActorSystem) StartRemote(conf *RemoteConfig) error {
+	remote := NewRemote(conf)
+	remote.system = af
+	if err := remote.Start(); err != nil {
+		return err
+	}
+	af.remote = remote
+	return nil
+}
+
+// StartOwnership 启用集群范围的单激活，需要先调用 StartRemote
+// 启用后未指定 Meta.Dispatcher.NodeId 的 ActorRef 通过Redis租约确定Actor所在节点
+func (af *ActorSystem) StartOwnership(cli *redis.Client, metas *ActorMetaCache, ops ...OwnershipOption) error {
+	if af.remote == nil {
+		return ErrRemoteNotStarted
+	}
+	registry := NewOwnershipRegistry(cli, metas, af.remote.NodeId(), ops...)
+	registry.system = af
+	registry.Start()
+	af.ownership = registry
+	return nil
+}
+
+// Ownership 返回集群归属登记，未启用时返回nil
+func (af *ActorSystem) Ownership() *OwnershipRegistry {
+	return af.ownership
+}
+
+// StartPlacement 启用按 DispatcherType 的Actor放置，需要先调用 StartRemote
+// 初始的健康节点为 RemoteConfig.Discovery 中的所有节点
+func (af *ActorSystem) StartPlacement(metas *ActorMetaCache) error {
+	if af.remote == nil {
+		return ErrRemoteNotStarted
+	}
+	af.placement = NewPlacementService(af.remote.NodeId(), metas, af.remote.Discovery().Nodes()...)
+	af.placement.system = af
+	return nil
+}
+
+// Placement 返回Actor放置服务，未启用时返回nil
+func (af *ActorSystem) Placement() *PlacementService {
+	return af.placement
+}
+
+// StartReminders 启用持久化提醒，开始扫描并触发到期的提醒
+func (af *ActorSystem) StartReminders(cli *redis.Client, ops ...ReminderOption) error {
+	s := NewReminderService(cli, ops...)
+	s.system = af
+	if !af.reminders.CompareAndSwap(nil, s) {
+		return nil
+	}
+	s.Start()
+	return nil
+}
+
+// Reminders 返回持久化提醒服务，未启用时返回nil
+func (af *ActorSystem) Reminders() *ReminderService {
+	return af.reminders.Load()
+}
+
+// Remote 返回跨节点通信层，未启用时返回nil
+func (af *ActorSystem) Remote() *Remote {
+	return af.remote
+}
+
+func (af *ActorSystem) isRunning() bool {
+	return af.state.Load() == ActorSystemStateRunning
+}
+
+// Topics 返回本节点的主题订阅表
+func (af *ActorSystem) Topics() *TopicRegistry {
+	return af.topics
+}
+
+// DeadLetters 返回ActorSystem的死信汇聚点，可订阅无法投递的消息
+func (af *ActorSystem) DeadLetters() *DeadLetterSink {
+	return af.deadLetters
+}
+
 // Stop 开始ActorSystem的优雅关闭流程
 // 参数:
 //   - ctx: 用于控制停止操作超时和取消的上下文
@@ -82,44 +375,49 @@ func (af *ActorSystem) Stop(ctx context.Context) error {
 //   - 如果停止操作成功完成则返回nil，如果发生错误则返回错误
 //
 // 实现细节:
-//   - 使用GracefulShutdownManager确保所有supervisor正确停止
-//   - 要求所有supervisor连续5次成功报告完成才视为停止成功
+//   - 按Level从低到高依次停止，低等级的Actor全部停止(HandleStopping 持久化完成)后才开始停止下一个等级
+//   - 每个等级使用GracefulShutdownManager确保supervisor正确停止，要求连续5次成功报告完成才视为停止成功
 //   - 设置最大尝试次数为100，防止永远无法达到成功阈值时的无限重试
+//   - 等级配置了 WithLevelDeadline 时超过期限不再等待，继续停止下一个等级，返回 ErrShutdownIncomplete
 //   - 当所有supervisor停止后，将系统状态设置为Stopped
 func (af *ActorSystem) stop(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	ActorFacadeStopping.Store(true)
+	if af == System {
+		ActorFacadeStopping.Store(true)
+	}
 
-	// 创建一个有最大尝试次数限制的关闭管理器
-	const (
-		successThreshold = 5   // 需要连续成功的次数
-		maxAttempts      = 100 // 最大尝试次数，防止无限循环
-	)
+	// 停止触发提醒，避免关闭期间重新激活Actor
+	if reminders := af.reminders.Load(); reminders != nil {
+		reminders.Stop()
+	}
 
-	shutdownManager := NewGracefulShutdownManagerWithMaxAttempts(successThreshold, maxAttempts, func() bool {
-		for lv := LevelNormal; lv < LevelMaxLimit; lv++ {
-			completed, err := af.stopSupervisor(lv)
-			if err != nil {
-				logger.GetLogger().Error("Failed to stop supervisor",
-					zap.Int32("level", int32(lv)),
-					zap.Error(err))
-				return false
-			}
+	// 停止接收其他节点转发的消息
+	if af.remote != nil {
+		_ = af.remote.Stop()
+	}
 
-			if !completed {
-				logger.GetLogger().Info("supervisor not completed",
-					zap.Int32("level", int32(lv)))
-				return false
-			}
+	progress := newShutdownProgress(len(af.supervisors))
+	var errs []error
+	for lv := range af.supervisors {
+		if err := af.stopLevel(ctx, Level(lv), progress); err != nil {
+			errs = append(errs, err)
 		}
+	}
+	err := errors.Join(errs...)
+	if err == nil {
 		logger.GetLogger().Info("All supervisors stopped complete")
-		return true
-	})
+	}
 
-	err := shutdownManager.Shutdown(ctx)
+	// 所有Actor停止后释放剩余的租约
+	if af.ownership != nil {
+		af.ownership.Stop()
+	}
+	if af.watchdog != nil {
+		af.watchdog.Stop()
+	}
 
 	// 无论结果如何，都将状态设置为Stopped
 	af.state.CompareAndSwap(ActorSystemStateStopping, ActorSystemStateStopped)
@@ -131,45 +429,37 @@ func (af *ActorSystem) ActorSystem() *actor.ActorSystem {
 	return af.actorSystem
 }
 
-func (af *ActorSystem) stopSupervisor(lv Level) (bool, error) {
+func (af *ActorSystem) stopSupervisor(lv Level) (*StopAllResponse, error) {
 	result, err := retry(func() (any, error) {
 		future := af.actorSystem.Root.RequestFuture(af.supervisors[lv], &StopAllRequest{}, 30*time.Second)
 		result, err := future.Result()
 		return result, err
 	}, 10)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	resp := result.(*StopAllResponse)
-	return resp.Complete, nil
+	return result.(*StopAllResponse), nil
 }
 
 // NewActorFacade creates a new instance of ActorFacade
-func NewActorFacade(actorSystem *actor.ActorSystem) *ActorSystem {
-	af := &ActorSystem{
-		actorSystem: actorSystem,
-		supervisors: make([]*actor.PID, LevelMaxLimit),
-	}
-
-	for lv := LevelNormal; lv < LevelMaxLimit; lv++ {
-		af.supervisors[lv] = newSupervisor(actorSystem, lv)
-	}
-
+// 不会替换默认的 System，同一个protoactor系统只能创建一个实例
+func NewActorFacade(actorSystem *actor.ActorSystem, ops ...SystemOption) *ActorSystem {
+	af := NewSystem(ops...)
+	af.actorSystem = actorSystem
+	af.init()
 	return af
 }
 
-func newSupervisor(actorSystem *actor.ActorSystem, level Level) *actor.PID {
-	decider := func(reason any) actor.Directive {
-		return actor.ResumeDirective
-	}
-	supervisor := actor.NewOneForOneStrategy(10, 1000, decider)
+func newSupervisor(af *ActorSystem, level Level) *actor.PID {
+	// 子Actor崩溃后按Pattern注册的 SupervisionPolicy 处理
+	supervisor := newChildSupervisorStrategy(af)
 	producer := func() actor.Actor {
-		return NewActorSupervision(actorSystem, level)
+		return NewActorSupervision(af, level)
 	}
 	props := actor.PropsFromProducer(producer, actor.WithSupervisor(supervisor))
 
-	managerPID, err := actorSystem.Root.SpawnNamed(props, GenManagerName(level))
+	managerPID, err := af.actorSystem.Root.SpawnNamed(props, GenManagerName(level))
 	if err != nil {
 		panic(err) // In a real application, handle this error appropriately
 	}
@@ -177,24 +467,35 @@ func newSupervisor(actorSystem *actor.ActorSystem, level Level) *actor.PID {
 	return managerPID
 }
 
-// GetOrStartActor 获取一个就绪的Actor对象
+// GetOrStartActor 在默认的 System 中获取一个就绪的Actor对象
 func GetOrStartActor(actorName, pattern string, props *Props) (*Process, error) {
+	return System.GetOrStartActor(actorName, pattern, props)
+}
+
+// GetOrStartActor 获取一个就绪的Actor对象，Actor未激活时激活
+func (af *ActorSystem) GetOrStartActor(actorName, pattern string, props *Props) (*Process, error) {
+	return af.getOrStartActor(&StartActorRequest{
+		ActorName: actorName,
+		Pattern:   pattern,
+		Props:     props,
+	}, StartActorTimeout, ManagerStartActorFutureTimeout)
+}
+
+// getOrStartActor 由 req 激活Actor，req.Future 由本方法设置
+// requestTimeout 为supervisor回复 StartActorRequest 的超时，waitTimeout 为等待Actor初始化完成的超时
+func (af *ActorSystem) getOrStartActor(req *StartActorRequest, requestTimeout, waitTimeout time.Duration) (*Process, error) {
 	// First check if manager already has this actor
-	if actor, exists := actorsCache.Get(actorName); exists {
+	if actor, exists := af.actors.Get(req.ActorName); exists {
 		if !actor.IsStopped() {
 			return actor, nil
 		}
 	}
 
-	system := System.actorSystem
-	future := actor.NewFuture(system, ManagerStartActorFutureTimeout)
-	mPid := System.supervisorByPattern(pattern)
-	rf := system.Root.RequestFuture(mPid, &StartActorRequest{
-		ActorName: actorName,
-		Pattern:   pattern,
-		Future:    future.PID(),
-		Props:     props,
-	}, StartActorTimeout)
+	system := af.actorSystem
+	future := actor.NewFuture(system, waitTimeout)
+	mPid := af.supervisorByPattern(req.Pattern)
+	req.Future = future.PID()
+	rf := system.Root.RequestFuture(mPid, req, requestTimeout)
 
 	result, err := waitFuture(rf)
 	if err != nil {
@@ -220,9 +521,14 @@ func GetOrStartActor(actorName, pattern string, props *Props) (*Process, error)
 	}
 }
 
-// StopActor stops the actor with the given ID
+// StopActor stops the actor with the given ID in the default System
 func StopActor(actorName, pattern string) error {
-	result, err := System.RequestFuture(pattern, &PoisonActorMessage{
+	return System.StopActor(actorName, pattern)
+}
+
+// StopActor stops the actor with the given ID
+func (af *ActorSystem) StopActor(actorName, pattern string) error {
+	result, err := af.RequestFuture(pattern, &PoisonActorMessage{
 		ActorName: actorName,
 		Pattern:   pattern,
 	}, StopActorTimeout)
@@ -251,11 +557,18 @@ func (f *ActorSystem) RequestFuture(pattern string, msg any, timeout time.Durati
 }
 
 func (f *ActorSystem) supervisorByPattern(pattern string) *actor.PID {
-	level := GetLevelByPattern(pattern)
+	level := f.Levels().Get(pattern)
 	return f.supervisorByLevel(level)
 }
 
+// supervisorByLevel 超出配置范围的等级使用最高等级的supervisor
 func (f *ActorSystem) supervisorByLevel(level Level) *actor.PID {
+	switch {
+	case level < 0:
+		level = 0
+	case int(level) >= len(f.supervisors):
+		level = Level(len(f.supervisors) - 1)
+	}
 	return f.supervisors[level]
 }
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -57,7 +58,6 @@ func TestGetOrStartActor(t *testing.T) {
 	// Clean setup
 	actorSystem := actor.NewActorSystem()
 	System = NewActorFacade(actorSystem)
-	actorsCache = NewActorsCache()
 
 	// Register a mock behavior factory
 	const testPattern = "test-pattern"
@@ -128,6 +128,104 @@ func TestGetOrStartActor(t *testing.T) {
 	time.Sleep(time.Second * 5)
 }
 
+// tagBehavior 回复创建时指定的标记，用于区分Actor所在的系统
+type tagBehavior struct {
+	MockBehavior
+	tag string
+}
+
+func (b *tagBehavior) HandleRequest(ctx IContext, _ any) (any, error) {
+	return b.tag, nil
+}
+
+func TestActorSystem_MultipleSystems(t *testing.T) {
+	const pattern = "multi-system-pattern"
+	newSystem := func(tag string, level Level) *ActorSystem {
+		factories := NewFactoryRegistry()
+		factories.Reg(pattern, func(actorName string) Behavior {
+			return &tagBehavior{tag: tag}
+		})
+		af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories),
+			WithLevels(NewLevelRegistry(PatternLevel{Pattern: pattern, Level: level})))
+		return af
+	}
+	a, b := newSystem("a", LevelNormal), newSystem("b", LevelHigh)
+	defer a.Stop(context.Background())
+	defer b.Stop(context.Background())
+
+	// 同名的Actor在两个系统中各自激活，互不影响
+	re, err := a.NewActorRef(NewProps(), "multi-system-actor", pattern).RequestFuture("tag")
+	assert.NoError(t, err)
+	assert.Equal(t, "a", re)
+	re, err = b.NewActorRef(NewProps(), "multi-system-actor", pattern).RequestFuture("tag")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", re)
+
+	assert.True(t, a.Actors().Exist("multi-system-actor"))
+	assert.True(t, b.Actors().Exist("multi-system-actor"))
+	assert.Equal(t, LevelHigh, b.Levels().Get(pattern))
+	assert.False(t, DefaultFactories().Exists(pattern), "per-system factories must not leak into the default registry")
+
+	assert.NoError(t, a.StopActor("multi-system-actor", pattern))
+	assert.Eventually(t, func() bool {
+		return !a.Actors().Exist("multi-system-actor")
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, b.Actors().Exist("multi-system-actor"))
+
+	// 未登记的Pattern属于 LevelNormal
+	assert.Equal(t, LevelNormal, NewLevelRegistry().Get("unknown-pattern"))
+}
+
+// 每个ActorSystem使用独立的策略注册表，互不影响，也不写入默认的注册表
+func TestActorSystem_PerSystemRegistries(t *testing.T) {
+	const pattern = "per-system-registry-pattern"
+	newSystem := func(ops ...SystemOption) *ActorSystem {
+		var inits atomic.Int32
+		factories := NewFactoryRegistry()
+		factories.Reg(pattern, func(actorName string) Behavior {
+			return &CrashBehavior{inits: &inits}
+		})
+		return NewActorFacade(actor.NewActorSystem(), append(ops, WithFactories(factories))...)
+	}
+
+	policies := NewSupervisionPolicyRegistry()
+	policies.Reg(pattern, &SupervisionPolicy{Directive: DirectiveStop, OnCrash: func(record *CrashRecord) {}})
+	warmups := NewWarmupRegistry()
+	warmups.Reg("per-system", func() []ActivateTarget {
+		return []ActivateTarget{{ActorName: "per-system-warmup", Pattern: pattern}}
+	})
+	a := newSystem(WithSupervisionPolicies(policies), WithWarmups(warmups))
+	b := newSystem(WithSupervisionPolicies(NewSupervisionPolicyRegistry()))
+	defer a.Stop(context.Background())
+	defer b.Stop(context.Background())
+
+	// a 按注册的策略停止崩溃的Actor，b 使用默认的Resume策略保留Actor
+	for _, af := range []*ActorSystem{a, b} {
+		ref := af.NewActorRef(NewProps(), "per-system-crash", pattern)
+		_, err := ref.RequestFuture("inc")
+		assert.NoError(t, err)
+		_, err = ref.RequestFuture("panic", 200*time.Millisecond)
+		assert.Error(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return !a.Actors().Exist("per-system-crash")
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, b.Actors().Exist("per-system-crash"))
+	assert.Same(t, DefaultSupervisionPolicy, GetSupervisionPolicy(pattern), "per-system policies must not leak into the default registry")
+
+	// 预热只激活本实例注册的Actor
+	assert.NoError(t, NewWarmup(a).Start())
+	assert.True(t, a.Actors().Exist("per-system-warmup"))
+	assert.False(t, b.Actors().Exist("per-system-warmup"))
+	assert.Empty(t, NewWarmupRegistry().Targets())
+
+	// 未指定注册表时使用默认的注册表
+	assert.Same(t, DefaultPlacementPolicies(), a.PlacementPolicies())
+	assert.Same(t, DefaultRouterPolicies(), a.RouterPolicies())
+	assert.Same(t, DefaultWatchdogPolicies(), a.WatchdogPolicies())
+	assert.Same(t, DefaultRemoteErrors(), a.RemoteErrors())
+}
+
 func Test_retry(t *testing.T) {
 	// 测试用例1: 函数始终失败，应该重试指定次数后返回错误
 	t.Run("持续失败的情况", func(t *testing.T) {
@@ -275,7 +373,6 @@ func setup(pattern string) IService {
 	// Setup
 	actorSystem := actor.NewActorSystem()
 	System = NewActorFacade(actorSystem)
-	actorsCache = NewActorsCache()
 	InitPatternLevelMap([]struct {
 		Pattern string
 		Level   Level
@@ -1,6 +1,8 @@
 package actor
 
 import (
+	"math"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -13,7 +15,10 @@ type Timer struct {
 	system     bool
 	idx        int64
 	duration   time.Duration
-	expiration time.Time // 到期时间
+	expiration time.Time     // 到期时间
+	schedule   TimerSchedule // 按计划续约的系统定时器
+	absolute   bool          // 在指定时间到期的定时器，暂停期间不顺延
+	remaining  time.Duration // 暂停时剩余的时间
 	msg        any
 }
 
@@ -46,8 +51,8 @@ func (t *Timer) Equal(other *Timer) bool {
 	return t.key == other.key && t.idx == other.idx
 }
 
-func (t *Timer) expired() bool {
-	return !time.Now().Before(t.expiration)
+func (t *Timer) expired(now time.Time) bool {
+	return !now.Before(t.expiration)
 }
 
 func (t *Timer) IsSystem() bool {
@@ -58,36 +63,85 @@ func (t *Timer) GetDuration() time.Duration {
 	return t.duration
 }
 
+// GetExpiration 返回下一次到期时间
+func (t *Timer) GetExpiration() time.Time {
+	return t.expiration
+}
+
+// GetMsg 返回定时器到期时投递的消息
+func (t *Timer) GetMsg() any {
+	return t.msg
+}
+
+// relative 到期时间由间隔决定，暂停期间顺延
+func (t *Timer) relative() bool {
+	return t.schedule == nil && !t.absolute
+}
+
+// GetSchedule 返回按计划续约的系统定时器的触发计划，其他定时器返回nil
+func (t *Timer) GetSchedule() TimerSchedule {
+	return t.schedule
+}
+
 // TimerMgr 使用最小堆管理Timer对象
 //
 //	1:所有接口不允许并发操作
 //	2:管理两种定时器
 //		Timer: 普通定时器，不支持自动续约
 //			支持操作类型：Insert，Remove，Update
-//		SystemTimer: 系统定时器，支持自动续约
+//		SystemTimer: 系统定时器，支持自动续约，按固定间隔或 TimerSchedule(cron表达式、每日重置)续约
 //			支持操作类型：Insert，Remove
 //	3: 所有接口不允许并发操作
+//	4: 堆的优先级为到期时间的毫秒数，时间通过 Clock 获取，测试中可以使用 MockClock
+//	5: Pause 后定时器移出最小堆，不再到期，Resume 时按间隔定义的定时器顺延暂停的时长，
+//	   按绝对时间或计划定义的定时器不顺延，暂停期间已经到期的在恢复后立即到期
 type TimerMgr struct {
 	id        atomic.Int64
-	timer     *time.Timer
+	clock     Clock
+	timer     ClockTimer
 	systemMap map[string]*Timer // 系统定时器映射
 	items     map[string]*heap.Item[*Timer, int64]
 	timerHeap *heap.Heap[*Timer, int64] // 使用最小堆存储Timer
+	paused    map[string]*Timer         // 暂停中的定时器，为nil时未暂停
+	keep      map[string]struct{}       // 暂停期间继续运行的定时器
+}
+
+type TimerMgrOption func(mgr *TimerMgr)
+
+// WithTimerClock 设置TimerMgr使用的时钟，默认为 SystemClock
+func WithTimerClock(clock Clock) TimerMgrOption {
+	return func(mgr *TimerMgr) {
+		if clock != nil {
+			mgr.clock = clock
+		}
+	}
 }
 
 // NewTimerMgr 创建一个新的TimerMgr
-func NewTimerMgr(callback func()) *TimerMgr {
+func NewTimerMgr(callback func(), ops ...TimerMgrOption) *TimerMgr {
 	mgr := &TimerMgr{
+		clock:     SystemClock,
 		timerHeap: &heap.Heap[*Timer, int64]{},
-		timer:     time.AfterFunc(0, callback),
 		systemMap: make(map[string]*Timer),
 		items:     make(map[string]*heap.Item[*Timer, int64]),
 	}
+	for _, op := range ops {
+		op(mgr)
+	}
+	mgr.timer = mgr.clock.AfterFunc(0, callback)
 
 	mgr.id.Store(0)
 	return mgr
 }
 
+// Now 返回TimerMgr时钟的当前时间，业务中的重置判断应使用此时间以便测试
+func (t *TimerMgr) Now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock.Now()
+}
+
 // AddSystemTimer 添加一个系统定时器
 // SystemTimer：
 //
@@ -104,11 +158,55 @@ func (t *TimerMgr) AddSystemTimer(key string, duration time.Duration, msg any) *
 	}
 
 	timer := NewSystemTimer(key, t.id.Add(1), duration, msg)
+	timer.expiration = t.Now().Add(duration)
 	t.addTimer(timer)
 	t.systemMap[key] = timer
 	return timer
 }
 
+// AddScheduleTimer 添加一个按计划续约的系统定时器，每次到期后按 TimerSchedule 计算下一次到期时间
+// 与 AddSystemTimer 一样不允许重复添加，计划没有下一次触发时间时返回nil
+func (t *TimerMgr) AddScheduleTimer(key string, schedule TimerSchedule, msg any) *Timer {
+	if schedule == nil {
+		return nil
+	}
+	if _, exist := t.systemMap[key]; exist {
+		return nil
+	}
+
+	next := schedule.Next(t.Now())
+	if next.IsZero() {
+		return nil
+	}
+	timer := &Timer{
+		key:        key,
+		idx:        t.id.Add(1),
+		expiration: next,
+		schedule:   schedule,
+		msg:        msg,
+		system:     true,
+	}
+	t.addTimer(timer)
+	t.systemMap[key] = timer
+	return timer
+}
+
+// AddCronTimer 添加一个按cron表达式触发的系统定时器，loc为nil时使用本地时区
+//
+//	例如每周一5点: AddCronTimer("weekly-reset", "0 5 * * 1", loc, msg)
+func (t *TimerMgr) AddCronTimer(key, spec string, loc *time.Location, msg any) (*Timer, error) {
+	schedule, err := ParseCron(spec, loc)
+	if err != nil {
+		return nil, err
+	}
+	return t.AddScheduleTimer(key, schedule, msg), nil
+}
+
+// AddDailyTimer 添加一个每天在指定时区的 hour:minute 触发的系统定时器，用于每日重置
+func (t *TimerMgr) AddDailyTimer(key string, hour, minute int, loc *time.Location, msg any) *Timer {
+	return t.AddScheduleTimer(key, DailySchedule(hour, minute, loc), msg)
+}
+
 // AddTimerOnce 添加一个一次性定时器
 // Timer:
 //
@@ -120,12 +218,32 @@ func (t *TimerMgr) AddTimerOnce(key string, duration time.Duration, msg any) *Ti
 	}
 
 	timer := NewTimer(key, t.id.Add(1), duration, msg)
+	timer.expiration = t.Now().Add(duration)
+	return t.addTimer(timer)
+}
+
+// AddTimerAt 添加一个在指定时间到期的一次性定时器，与 AddTimerOnce 共用key，支持更新
+// 指定的时间已经过去时在下一次处理时立即到期
+func (t *TimerMgr) AddTimerAt(key string, at time.Time, msg any) *Timer {
+	if at.IsZero() {
+		return nil
+	}
+
+	timer := &Timer{
+		key:        key,
+		idx:        t.id.Add(1),
+		duration:   at.Sub(t.Now()),
+		expiration: at,
+		absolute:   true,
+		msg:        msg,
+	}
 	return t.addTimer(timer)
 }
 
 // RemoveTimer 从管理器中移除一个定时器
 func (t *TimerMgr) RemoveTimer(key string) {
 	delete(t.systemMap, key)
+	delete(t.paused, key)
 	if item := t.items[key]; item != nil {
 		t.remove(item)
 		t.schedule()
@@ -141,7 +259,17 @@ func (t *TimerMgr) renewalSystemTimer(timer *Timer) {
 	//如果定时器已经被删除，不会在续约
 	if remain := t.systemMap[timer.key]; remain != nil {
 		if timer.Equal(remain) {
-			timer.expiration = time.Now().Add(timer.duration)
+			if timer.schedule == nil {
+				timer.expiration = t.Now().Add(timer.duration)
+			} else if timer.expiration = timer.schedule.Next(t.Now()); timer.expiration.IsZero() {
+				// 计划已经结束
+				delete(t.systemMap, timer.key)
+				return
+			}
+			if t.pausing(timer.key) {
+				t.pause(timer)
+				return
+			}
 			t.initTimer(timer)
 		}
 	}
@@ -149,6 +277,10 @@ func (t *TimerMgr) renewalSystemTimer(timer *Timer) {
 
 // AddTimer 添加一个新的定时器
 func (t *TimerMgr) addTimer(timer *Timer) *Timer {
+	if t.pausing(timer.key) {
+		t.pause(timer)
+		return timer
+	}
 	if item := t.items[timer.key]; item != nil {
 		t.updateTimer(item, timer)
 	} else {
@@ -163,14 +295,14 @@ func (t *TimerMgr) addTimer(timer *Timer) *Timer {
 func (t *TimerMgr) initTimer(timer *Timer) {
 	item := &heap.Item[*Timer, int64]{
 		Value:    timer,
-		Priority: timer.expiration.Unix(),
+		Priority: timer.expiration.UnixMilli(),
 	}
 	t.push(item)
 }
 
 func (t *TimerMgr) updateTimer(item *heap.Item[*Timer, int64], timer *Timer) {
 	item.Value = timer
-	item.Priority = timer.expiration.Unix()
+	item.Priority = timer.expiration.UnixMilli()
 	t.update(item)
 }
 
@@ -178,8 +310,101 @@ func (t *TimerMgr) schedule() {
 	head := t.peek()
 	t.stopSystemTimer()
 	if head != nil {
-		t.timer.Reset(time.Until(head.expiration))
+		t.timer.Reset(head.expiration.Sub(t.Now()))
+	}
+}
+
+// Pause 暂停所有定时器，keep中的定时器继续运行，暂停期间添加的定时器同样处于暂停状态
+func (t *TimerMgr) Pause(keep ...string) {
+	if t.paused != nil {
+		return
+	}
+	t.paused = make(map[string]*Timer)
+	t.keep = make(map[string]struct{}, len(keep))
+	for _, key := range keep {
+		t.keep[key] = struct{}{}
+	}
+
+	for key, item := range t.items {
+		if t.pausing(key) {
+			t.remove(item)
+			t.pause(item.Value)
+		}
+	}
+	t.schedule()
+}
+
+// Resume 恢复暂停的定时器
+func (t *TimerMgr) Resume() {
+	if t.paused == nil {
+		return
+	}
+	paused := t.paused
+	t.paused, t.keep = nil, nil
+
+	now := t.Now()
+	for _, timer := range paused {
+		if timer.relative() {
+			timer.expiration = now.Add(max(timer.remaining, 0))
+		}
+		timer.remaining = 0
+		t.initTimer(timer)
+	}
+	t.schedule()
+}
+
+// IsPaused 定时器是否处于暂停状态
+func (t *TimerMgr) IsPaused() bool {
+	return t.paused != nil
+}
+
+// Timers 返回所有未到期的定时器，按到期时间排序，暂停中按间隔定义的定时器的到期时间在恢复时重新计算
+func (t *TimerMgr) Timers() []*Timer {
+	timers := make([]*Timer, 0, len(t.items)+len(t.paused))
+	for _, item := range t.items {
+		timers = append(timers, item.Value)
+	}
+	for _, timer := range t.paused {
+		timers = append(timers, timer)
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		if timers[i].expiration.Equal(timers[j].expiration) {
+			return timers[i].key < timers[j].key
+		}
+		return timers[i].expiration.Before(timers[j].expiration)
+	})
+	return timers
+}
+
+// Remaining 返回定时器距离到期的剩余时间，定时器不存在时返回false
+func (t *TimerMgr) Remaining(key string) (time.Duration, bool) {
+	var timer *Timer
+	if item := t.items[key]; item != nil {
+		timer = item.Value
+	} else if timer = t.paused[key]; timer == nil {
+		return 0, false
 	}
+	if t.paused != nil && timer.relative() && t.pausing(key) {
+		return max(timer.remaining, 0), true
+	}
+	return max(timer.expiration.Sub(t.Now()), 0), true
+}
+
+func (t *TimerMgr) pausing(key string) bool {
+	if t.paused == nil {
+		return false
+	}
+	_, keep := t.keep[key]
+	return !keep
+}
+
+// pause 将定时器放入暂停列表，同key的定时器被替换
+func (t *TimerMgr) pause(timer *Timer) {
+	if item := t.items[timer.key]; item != nil {
+		t.remove(item)
+	}
+	timer.remaining = timer.expiration.Sub(t.Now())
+	t.paused[timer.key] = timer
 }
 
 // Stop 停止定时器管理器
@@ -214,7 +439,10 @@ func (t *TimerMgr) peek() *Timer {
 }
 
 func (t *TimerMgr) remove(item *heap.Item[*Timer, int64]) {
-	t.timerHeap.Delete(item.Index)
+	// heap.Delete 删除非末尾元素时不会结束，先将元素调整到堆顶再弹出
+	item.Priority = math.MinInt64
+	t.timerHeap.Fix(item.Index)
+	t.timerHeap.Pop()
 	timer := item.Value
 	delete(t.items, timer.key)
 }
@@ -224,8 +452,16 @@ func (t *TimerMgr) stopSystemTimer() {
 }
 
 func (t *TimerMgr) Process(delegate func(msg any)) {
+	t.ProcessTimers(func(timer *Timer) {
+		delegate(timer.msg)
+	})
+}
+
+// ProcessTimers 处理所有到期的定时器，delegate 可以通过 Timer.GetKey 区分定时器
+func (t *TimerMgr) ProcessTimers(delegate func(timer *Timer)) {
 	var (
 		repeated []*Timer
+		now      = t.Now()
 	)
 
 	for {
@@ -236,12 +472,12 @@ func (t *TimerMgr) Process(delegate func(msg any)) {
 
 		timer := item.Value
 
-		if !timer.expired() {
+		if !timer.expired(now) {
 			break
 		}
 
 		t.pop()
-		delegate(timer.msg)
+		delegate(timer)
 
 		if timer.IsSystem() {
 			repeated = append(repeated, timer)
@@ -1,6 +1,8 @@
 package actor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -23,9 +25,13 @@ func TestTimerMgr_AddTimerOnce(t *testing.T) {
 	wg := sync.WaitGroup{}
 	wg.Add(5)
 
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		for {
 			select {
+			case <-done:
+				return
 			case <-ntf:
 				mgr.Process(func(msg any) {
 					assert.Equal(t, "test-message", msg)
@@ -33,7 +39,7 @@ func TestTimerMgr_AddTimerOnce(t *testing.T) {
 					mgr.AddTimerOnce("test-timer", 100*time.Millisecond, "test-message")
 					wg.Done()
 				})
-			case <-time.After(150 * time.Millisecond):
+			case <-time.After(time.Second):
 				assert.Fail(t, "Callback was not triggered in time")
 			}
 		}
@@ -54,9 +60,15 @@ func TestTimerMgr_AddAndRemoveTimerOnce(t *testing.T) {
 	start := time.Now()
 	timer := mgr.AddTimerOnce("test-timer", 100*time.Millisecond, "test-message")
 	assert.NotNil(t, timer)
+	mgr.RemoveTimer(timer.GetKey())
+
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		for {
 			select {
+			case <-done:
+				return
 			case <-ntf:
 				mgr.Process(func(msg any) {
 					assert.Equal(t, "test-message", msg)
@@ -67,12 +79,10 @@ func TestTimerMgr_AddAndRemoveTimerOnce(t *testing.T) {
 		}
 	}()
 
-	mgr.RemoveTimer(timer.GetKey())
-
 	select {
 	case <-result:
 		assert.Fail(t, "timer was not removed in time")
-	case <-time.After(time.Second * 5):
+	case <-time.After(time.Second):
 		fmt.Println("complete")
 	}
 }
@@ -91,9 +101,13 @@ func TestTimerMgr_AddSystemTimer(t *testing.T) {
 	var count int
 	start := time.Now()
 
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		for {
 			select {
+			case <-done:
+				return
 			case <-ntf:
 				mgr.Process(func(msg any) {
 					assert.Equal(t, "test-message", msg)
@@ -128,9 +142,13 @@ func TestTimerMgr_RemoveSystemTimer(t *testing.T) {
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 
+	done := make(chan struct{})
+	defer close(done)
 	go func() {
 		for {
 			select {
+			case <-done:
+				return
 			case <-ntf:
 				mgr.Process(func(msg any) {
 					assert.Equal(t, "test-message", msg)
@@ -140,7 +158,7 @@ func TestTimerMgr_RemoveSystemTimer(t *testing.T) {
 						count -= 999999
 					}
 				})
-			case <-time.After(5 * time.Second):
+			case <-time.After(time.Second):
 				wg.Done()
 			}
 		}
@@ -155,6 +173,7 @@ func TestTimerMgr_SimpleOperations(t *testing.T) {
 	mgr := &TimerMgr{
 		timerHeap: &heap.Heap[*Timer, int64]{},
 		items:     make(map[string]*heap.Item[*Timer, int64]),
+		systemMap: make(map[string]*Timer),
 		timer:     time.NewTimer(time.Hour), // Use a long duration to prevent automatic triggering
 	}
 	defer mgr.Stop()
@@ -209,6 +228,7 @@ func TestTimerMgr_ProcessExpiredTimers(t *testing.T) {
 	mgr := &TimerMgr{
 		timerHeap: &heap.Heap[*Timer, int64]{},
 		items:     make(map[string]*heap.Item[*Timer, int64]),
+		systemMap: make(map[string]*Timer),
 		timer:     time.NewTimer(time.Hour), // Use a long duration to prevent automatic triggering
 	}
 	defer mgr.Stop()
@@ -235,12 +255,12 @@ func TestTimerMgr_ProcessExpiredTimers(t *testing.T) {
 	// Add the timers to the heap manually
 	item1 := &heap.Item[*Timer, int64]{
 		Value:    timer1,
-		Priority: timer1.expiration.Unix(),
+		Priority: timer1.expiration.UnixMilli(),
 	}
 
 	item2 := &heap.Item[*Timer, int64]{
 		Value:    timer2,
-		Priority: timer2.expiration.Unix(),
+		Priority: timer2.expiration.UnixMilli(),
 	}
 
 	mgr.timerHeap.Push(item1)
@@ -248,6 +268,7 @@ func TestTimerMgr_ProcessExpiredTimers(t *testing.T) {
 
 	mgr.timerHeap.Push(item2)
 	mgr.items[timer2.key] = item2
+	mgr.systemMap[timer2.key] = timer2
 
 	assert.Equal(t, 2, mgr.timerHeap.Len())
 
@@ -286,27 +307,25 @@ func TestTimerMgr_SystemTimerRenewal(t *testing.T) {
 	assert.True(t, systemTimer.IsSystem())
 
 	// Setup to process expired timers
-	wg := sync.WaitGroup{}
-	wg.Add(2) // We expect the system timer to fire at least twice
-
+	// 第一次回调来自创建时的 AfterFunc(0)，此时没有到期的定时器，因此按处理次数而不是回调次数计数
+	finished := make(chan struct{})
 	count := 0
 	go func() {
-		for i := 0; i < 2; i++ {
+		defer close(finished)
+		for count < 2 { // We expect the system timer to fire at least twice
 			select {
 			case <-ntf:
 				mgr.Process(func(msg any) {
 					assert.Equal(t, "system-message", msg)
 					count++
-					wg.Done()
 				})
-			case <-time.After(200 * time.Millisecond):
-				assert.Fail(t, "Callback was not triggered in time")
-				wg.Done()
+			case <-time.After(time.Second):
+				return
 			}
 		}
 	}()
 
-	wg.Wait()
+	<-finished
 
 	// Verify that the timer was processed twice
 	assert.Equal(t, 2, count)
@@ -314,3 +333,222 @@ func TestTimerMgr_SystemTimerRenewal(t *testing.T) {
 	// The system timer should still exist because it's renewed
 	assert.NotNil(t, mgr.items["system-timer"])
 }
+
+func TestTimerMgr_MockClock(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	clock := NewMockClock(time.Date(2024, 5, 1, 4, 0, 0, 0, loc))
+
+	var mgr *TimerMgr
+	var fired []any
+	mgr = NewTimerMgr(func() {
+		// 回调在 Advance 的goroutine中同步执行
+		mgr.Process(func(msg any) {
+			fired = append(fired, msg)
+		})
+	}, WithTimerClock(clock))
+	defer mgr.Stop()
+
+	assert.NotNil(t, mgr.AddDailyTimer("daily-reset", 5, 0, loc, "daily"))
+	weekly, err := mgr.AddCronTimer("weekly-reset", "0 5 * * 1", loc, "weekly")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 6, 5, 0, 0, 0, loc), weekly.GetExpiration())
+	_, err = mgr.AddCronTimer("broken", "0 5 * *", loc, "broken")
+	assert.True(t, errors.Is(err, ErrInvalidCron))
+	assert.NotNil(t, mgr.AddTimerAt("at", time.Date(2024, 5, 1, 4, 30, 0, 0, loc), "at"))
+
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, []any{"at"}, fired)
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, []any{"at", "daily"}, fired)
+	assert.Equal(t, time.Date(2024, 5, 2, 5, 0, 0, 0, loc), mgr.items["daily-reset"].Value.GetExpiration())
+
+	// 停机期间错过的多次触发合并为一次
+	clock.Set(time.Date(2024, 5, 6, 6, 0, 0, 0, loc))
+	assert.Equal(t, []any{"at", "daily", "daily", "weekly"}, fired[:4])
+	assert.Len(t, fired, 4)
+	assert.Equal(t, time.Date(2024, 5, 7, 5, 0, 0, 0, loc), mgr.items["daily-reset"].Value.GetExpiration())
+
+	mgr.RemoveTimer("daily-reset")
+	clock.Advance(24 * time.Hour)
+	assert.Len(t, fired, 4)
+}
+
+func TestTimerMgr_MillisecondOrder(t *testing.T) {
+	clock := NewMockClock(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	var mgr *TimerMgr
+	var fired []any
+	mgr = NewTimerMgr(func() {
+		mgr.Process(func(msg any) {
+			fired = append(fired, msg)
+		})
+	}, WithTimerClock(clock))
+	defer mgr.Stop()
+
+	mgr.AddTimerOnce("c", 900*time.Millisecond, "c")
+	mgr.AddTimerOnce("a", 100*time.Millisecond, "a")
+	mgr.AddTimerOnce("b", 500*time.Millisecond, "b")
+	mgr.AddTimerOnce("d", 1200*time.Millisecond, "d")
+
+	// 删除堆中间的定时器
+	mgr.RemoveTimer("b")
+	assert.Equal(t, 3, mgr.timerHeap.Len())
+
+	clock.Advance(950 * time.Millisecond)
+	assert.Equal(t, []any{"a", "c"}, fired)
+	clock.Advance(time.Second)
+	assert.Equal(t, []any{"a", "c", "d"}, fired)
+}
+
+func TestTimerMgr_PauseResume(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewMockClock(start)
+	var mgr *TimerMgr
+	var fired []any
+	mgr = NewTimerMgr(func() {
+		mgr.Process(func(msg any) {
+			fired = append(fired, msg)
+		})
+	}, WithTimerClock(clock))
+	defer mgr.Stop()
+
+	mgr.AddTimerOnce("relative", 10*time.Second, "relative")
+	mgr.AddTimerAt("absolute", start.Add(20*time.Second), "absolute")
+	mgr.AddSystemTimer("keep", 5*time.Second, "keep")
+
+	timers := mgr.Timers()
+	assert.Len(t, timers, 3)
+	assert.Equal(t, []string{"keep", "relative", "absolute"}, []string{timers[0].GetKey(), timers[1].GetKey(), timers[2].GetKey()})
+
+	clock.Advance(4 * time.Second)
+	mgr.Pause("keep")
+	assert.True(t, mgr.IsPaused())
+	remaining, ok := mgr.Remaining("relative")
+	assert.True(t, ok)
+	assert.Equal(t, 6*time.Second, remaining)
+
+	// 暂停期间只有keep继续运行，新添加的定时器同样暂停
+	mgr.AddTimerOnce("added", time.Second, "added")
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, []any{"keep"}, fired, "missed renewals of keep are coalesced")
+	remaining, _ = mgr.Remaining("relative")
+	assert.Equal(t, 6*time.Second, remaining)
+	_, ok = mgr.Remaining("missing")
+	assert.False(t, ok)
+
+	// 恢复后绝对时间的定时器已经过期立即到期，间隔定时器顺延
+	fired = nil
+	mgr.Resume()
+	clock.Advance(0)
+	assert.Equal(t, []any{"absolute"}, fired)
+	clock.Advance(time.Second)
+	assert.Equal(t, []any{"absolute", "added"}, fired)
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, []any{"absolute", "added", "keep", "relative"}, fired)
+
+	mgr.RemoveTimer("keep")
+	assert.Empty(t, mgr.Timers())
+}
+
+type timerTick struct{}
+
+// TimerBehavior 通过 HandleTimer 接收定时器消息，通过 HandleSend 接收业务消息
+type TimerBehavior struct {
+	mu     sync.Mutex
+	timers []string
+	sends  []any
+}
+
+func (b *TimerBehavior) record(f func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f()
+}
+
+func (b *TimerBehavior) Timers() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.timers...)
+}
+
+func (b *TimerBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	switch msg {
+	case "pause":
+		ctx.PauseTimers()
+	case "resume":
+		ctx.ResumeTimers()
+	case "remaining":
+		d, _ := ctx.Remaining("tick")
+		return d, nil
+	}
+	return len(ctx.Timers()), nil
+}
+
+func (b *TimerBehavior) HandleSend(ctx IContext, msg any) {
+	b.record(func() { b.sends = append(b.sends, msg) })
+}
+
+func (b *TimerBehavior) HandleTimer(ctx IContext, key string, msg any) {
+	b.record(func() { b.timers = append(b.timers, key) })
+}
+
+func (b *TimerBehavior) HandleForward(ctx IContext, _ any) {}
+
+func (b *TimerBehavior) HandleInit(ctx IContext) error {
+	ctx.AddTimerOnce("tick", time.Minute, &timerTick{})
+	ctx.AfterFunc("callback", 2*time.Minute, func(ctx IContext) {
+		b.record(func() { b.timers = append(b.timers, "callback:"+ctx.GetActorName()) })
+	})
+	return nil
+}
+
+func (b *TimerBehavior) HandleStopping(ctx IContext) error { return nil }
+
+func (b *TimerBehavior) HandleStopped(ctx IContext) error { return nil }
+
+func TestTimerMgr_ActorTimers(t *testing.T) {
+	const pattern = "timer-behavior-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	behavior := &TimerBehavior{}
+	RegFactory(pattern, func(actorName string) Behavior {
+		return behavior
+	})
+
+	clock := NewMockClock(time.Now())
+	// 保活检测同样使用注入的时钟，推进时间超过保活超时会使Actor被动化
+	ref := NewActorRef(NewProps(), "timer-actor", pattern, WithClock(clock), WithAliveTimeout(24*time.Hour))
+	// tick、callback 以及保活检测
+	n, err := ref.RequestFuture("timers")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	_, err = ref.RequestFuture("pause")
+	assert.NoError(t, err)
+	clock.Advance(time.Hour)
+	re, err := ref.RequestFuture("remaining")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, re)
+	assert.Empty(t, behavior.Timers())
+
+	// 定时器消息在Actor中异步处理，等待重新设置下一次到期时间后再推进时钟
+	advance := func(d time.Duration) {
+		assert.Eventually(t, func() bool {
+			return len(clock.Pending()) > 0
+		}, time.Second, 10*time.Millisecond)
+		clock.Advance(d)
+	}
+	_, err = ref.RequestFuture("resume")
+	assert.NoError(t, err)
+	advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return len(behavior.Timers()) == 1
+	}, time.Second, 10*time.Millisecond)
+	advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return len(behavior.Timers()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"tick", "callback:timer-actor"}, behavior.Timers())
+	behavior.record(func() { assert.Empty(t, behavior.sends, "timers should not reach HandleSend") })
+}
@@ -0,0 +1,202 @@
+package actor
+
+import (
+	"sync"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"go.uber.org/zap"
+)
+
+/*
+	TopicRegistry 本节点的主题订阅表，用于向一组Actor(例如公会频道的所有成员)扇出消息
+
+	1. Actor在处理消息时通过 IContext.Subscribe 订阅主题，停止或重启时自动取消所有订阅
+	2. Publish 将消息作为Send消息投递给订阅者，由 Behavior.HandleSend 处理
+	3. 投递至多一次：订阅者未激活、已停止或邮箱拒绝投递时消息被丢弃，不会重新激活Actor，也不进入死信
+	4. 订阅时可以指定 TopicFilter，在发布者的goroutine中执行，过滤掉的消息不进入订阅者的邮箱
+	5. 设置 TopicRelay 后，Publish 同时将消息交给它转发到其他节点，其他节点收到后调用 PublishLocal
+*/
+
+// TopicFilter 订阅者的消息过滤函数，返回false时不投递
+// 在发布者的goroutine中执行，不允许阻塞或访问Actor的状态
+type TopicFilter func(msg any) bool
+
+// TopicRelay 跨节点转发主题消息
+type TopicRelay interface {
+	Publish(topic string, msg any) error
+}
+
+type topicSubscriber struct {
+	actorName string
+	pattern   string
+	filter    TopicFilter
+}
+
+// TopicRegistry 主题订阅表
+type TopicRegistry struct {
+	mu     sync.RWMutex
+	topics map[string]map[string]*topicSubscriber // topic -> actorName -> 订阅者
+	actors map[string]map[string]struct{}         // actorName -> 订阅的主题
+	relay  TopicRelay
+	system *ActorSystem
+}
+
+func NewTopicRegistry() *TopicRegistry {
+	return &TopicRegistry{
+		topics: make(map[string]map[string]*topicSubscriber),
+		actors: make(map[string]map[string]struct{}),
+	}
+}
+
+// sys 返回订阅表所属的ActorSystem，单独创建的订阅表使用默认的 System
+func (r *TopicRegistry) sys() *ActorSystem {
+	if r.system != nil {
+		return r.system
+	}
+	return System
+}
+
+// SetRelay 设置跨节点转发
+func (r *TopicRegistry) SetRelay(relay TopicRelay) {
+	r.mu.Lock()
+	r.relay = relay
+	r.mu.Unlock()
+}
+
+// Subscribe Actor订阅主题，重复订阅时替换过滤函数
+func (r *TopicRegistry) Subscribe(topic, actorName, pattern string, filter TopicFilter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs, ok := r.topics[topic]
+	if !ok {
+		subs = make(map[string]*topicSubscriber)
+		r.topics[topic] = subs
+	}
+	subs[actorName] = &topicSubscriber{actorName: actorName, pattern: pattern, filter: filter}
+
+	topics, ok := r.actors[actorName]
+	if !ok {
+		topics = make(map[string]struct{})
+		r.actors[actorName] = topics
+	}
+	topics[topic] = struct{}{}
+}
+
+// Unsubscribe Actor取消订阅主题
+func (r *TopicRegistry) Unsubscribe(topic, actorName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(topic, actorName)
+	if topics := r.actors[actorName]; topics != nil {
+		delete(topics, topic)
+		if len(topics) == 0 {
+			delete(r.actors, actorName)
+		}
+	}
+}
+
+// UnsubscribeAll 取消Actor的所有订阅，Actor停止时调用
+func (r *TopicRegistry) UnsubscribeAll(actorName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for topic := range r.actors[actorName] {
+		r.remove(topic, actorName)
+	}
+	delete(r.actors, actorName)
+}
+
+// Subscribers 返回主题在本节点的订阅者数量
+func (r *TopicRegistry) Subscribers(topic string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.topics[topic])
+}
+
+// Publish 向主题的所有订阅者发布消息，返回本节点投递成功的订阅者数量
+func (r *TopicRegistry) Publish(topic string, msg any) int {
+	r.mu.RLock()
+	relay := r.relay
+	r.mu.RUnlock()
+	if relay != nil {
+		if err := relay.Publish(topic, msg); err != nil {
+			logger.GetLogger().Error("[Topic] relay publish failed", zap.String("Topic", topic), zap.Error(err))
+		}
+	}
+	return r.PublishLocal(topic, msg)
+}
+
+// PublishLocal 只向本节点的订阅者发布消息，返回投递成功的订阅者数量
+func (r *TopicRegistry) PublishLocal(topic string, msg any) int {
+	r.mu.RLock()
+	subs := make([]*topicSubscriber, 0, len(r.topics[topic]))
+	for _, sub := range r.topics[topic] {
+		subs = append(subs, sub)
+	}
+	r.mu.RUnlock()
+
+	delivered := 0
+	for _, sub := range subs {
+		if sub.filter != nil && !sub.filter(msg) {
+			continue
+		}
+		p, exists := r.sys().actors.Get(sub.actorName)
+		if !exists {
+			continue
+		}
+		if err := p.Send(msg); err != nil {
+			logger.GetLogger().Debug("[Topic] drop message",
+				zap.String("Topic", topic),
+				zap.String("ActorName", sub.actorName),
+				zap.Error(err))
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+// remove 调用者持有写锁
+func (r *TopicRegistry) remove(topic, actorName string) {
+	subs := r.topics[topic]
+	if subs == nil {
+		return
+	}
+	delete(subs, actorName)
+	if len(subs) == 0 {
+		delete(r.topics, topic)
+	}
+}
+
+// Broadcast 向本节点上指定Pattern的所有已激活Actor发送消息，返回投递成功的数量
+// 与主题一样至多投递一次，不会激活新的Actor
+func (af *ActorSystem) Broadcast(pattern string, msg any) int {
+	// 遍历期间持有缓存的读锁，投递在遍历结束后进行
+	var targets []*Process
+	af.actors.Range(func(_ string, p *Process) {
+		if p.Pattern == pattern {
+			targets = append(targets, p)
+		}
+	})
+
+	delivered := 0
+	for _, p := range targets {
+		if p.Send(msg) == nil {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Subscribe 当前Actor订阅主题
+func (state *ChildActor) Subscribe(topic string, filter ...TopicFilter) {
+	var f TopicFilter
+	if len(filter) > 0 {
+		f = filter[0]
+	}
+	state.GetSystem().Topics().Subscribe(topic, state.actorName, state.pattern, f)
+}
+
+// Unsubscribe 当前Actor取消订阅主题
+func (state *ChildActor) Unsubscribe(topic string) {
+	state.GetSystem().Topics().Unsubscribe(topic, state.actorName)
+}
@@ -0,0 +1,143 @@
+package actor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type topicJoin struct {
+	topic  string
+	prefix string // 只接收以prefix开头的消息
+}
+
+type topicLeave struct {
+	topic string
+}
+
+// TopicBehavior 按请求订阅主题，记录收到的Send消息
+type TopicBehavior struct {
+	mu       sync.Mutex
+	received []string
+}
+
+func (b *TopicBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	switch m := msg.(type) {
+	case *topicJoin:
+		if m.prefix == "" {
+			ctx.Subscribe(m.topic)
+		} else {
+			ctx.Subscribe(m.topic, func(msg any) bool {
+				s, ok := msg.(string)
+				return ok && strings.HasPrefix(s, m.prefix)
+			})
+		}
+	case *topicLeave:
+		ctx.Unsubscribe(m.topic)
+	}
+	return nil, nil
+}
+
+func (b *TopicBehavior) HandleSend(ctx IContext, msg any) {
+	b.mu.Lock()
+	b.received = append(b.received, msg.(string))
+	b.mu.Unlock()
+}
+
+func (b *TopicBehavior) Received() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.received...)
+}
+
+func (b *TopicBehavior) HandleForward(ctx IContext, _ any) {}
+
+func (b *TopicBehavior) HandleInit(ctx IContext) error { return nil }
+
+func (b *TopicBehavior) HandleStopping(ctx IContext) error { return nil }
+
+func (b *TopicBehavior) HandleStopped(ctx IContext) error { return nil }
+
+func TestTopic_PublishAndFilter(t *testing.T) {
+	const pattern = "topic-member-pattern"
+	service := setup(pattern)
+	defer service.Stop(context.Background())
+
+	behaviors := make(map[string]*TopicBehavior)
+	var mu sync.Mutex
+	RegFactory(pattern, func(actorName string) Behavior {
+		mu.Lock()
+		defer mu.Unlock()
+		behaviors[actorName] = &TopicBehavior{}
+		return behaviors[actorName]
+	})
+	received := func(name string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return behaviors[name].Received()
+	}
+
+	a := NewActorRef(NewProps(), "topic-member-a", pattern)
+	b := NewActorRef(NewProps(), "topic-member-b", pattern)
+	_, err := a.RequestFuture(&topicJoin{topic: "guild-1"})
+	assert.NoError(t, err)
+	_, err = b.RequestFuture(&topicJoin{topic: "guild-1", prefix: "urgent"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, System.Topics().Subscribers("guild-1"))
+
+	assert.Equal(t, 2, System.Topics().Publish("guild-1", "urgent: boss spawned"))
+	assert.Equal(t, 1, System.Topics().Publish("guild-1", "hello"))
+	assert.Equal(t, 0, System.Topics().Publish("guild-2", "hello"))
+	assert.Eventually(t, func() bool {
+		return len(received("topic-member-a")) == 2 && len(received("topic-member-b")) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"urgent: boss spawned", "hello"}, received("topic-member-a"))
+	assert.Equal(t, []string{"urgent: boss spawned"}, received("topic-member-b"))
+
+	// 广播给Pattern的所有已激活Actor
+	assert.Equal(t, 2, System.Broadcast(pattern, "server closing"))
+	assert.Eventually(t, func() bool {
+		return len(received("topic-member-b")) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = a.RequestFuture(&topicLeave{topic: "guild-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, System.Topics().Subscribers("guild-1"))
+
+	// Actor停止后订阅自动取消，发布不会重新激活Actor
+	b.Stop()
+	assert.Eventually(t, func() bool {
+		return System.Topics().Subscribers("guild-1") == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, System.Topics().Publish("guild-1", "urgent: again"))
+	assert.Eventually(t, func() bool {
+		return !System.actors.Exist("topic-member-b")
+	}, time.Second, 10*time.Millisecond)
+}
+
+type recordRelay struct {
+	topics []string
+}
+
+func (r *recordRelay) Publish(topic string, _ any) error {
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+func TestTopic_Relay(t *testing.T) {
+	registry := NewTopicRegistry()
+	relay := &recordRelay{}
+	registry.SetRelay(relay)
+
+	registry.Subscribe("world", "not-active-actor", "pattern", nil)
+	assert.Equal(t, 0, registry.Publish("world", "hello"), "inactive subscribers are skipped")
+	assert.Equal(t, 0, registry.PublishLocal("world", "hello"))
+	assert.Equal(t, []string{"world"}, relay.topics, "PublishLocal should not relay")
+
+	registry.UnsubscribeAll("not-active-actor")
+	assert.Equal(t, 0, registry.Subscribers("world"))
+}
@@ -0,0 +1,79 @@
+package actor
+
+import (
+	"fmt"
+
+	"gitee.com/orbit-w/orbit/lib/tracing"
+)
+
+/*
+	链路追踪
+
+	1. RequestMessage.Trace 携带发送方所在的Span，Actor处理消息时以它为父节点开始一个Span，处理结束后输出
+	2. Behavior 中通过 IContext.SpanContext 获取当前处理的消息所在的Span，
+	   调用下游Actor时使用 Traced 包装消息，Send/RequestFuture/Forward 以及跨节点转发都会传递下去
+	3. 没有链路的消息不创建Span，未设置 tracing.SetExporter 时只传递上游的 SpanContext
+*/
+
+// tracedMessage Traced 包装的消息，投递时展开到 RequestMessage.Trace
+type tracedMessage struct {
+	sc  tracing.SpanContext
+	msg any
+}
+
+// Traced 将 SpanContext 附加到消息上，sc无效时直接返回msg
+//
+//	ref.RequestFuture(actor.Traced(ctx.SpanContext(), &pb.Request{}))
+func Traced(sc tracing.SpanContext, msg any) any {
+	if !sc.IsValid() {
+		return msg
+	}
+	return &tracedMessage{sc: sc, msg: msg}
+}
+
+// untrace 展开 Traced 包装的消息
+func untrace(msg any) (any, tracing.SpanContext) {
+	if tm, ok := msg.(*tracedMessage); ok {
+		return tm.msg, tm.sc
+	}
+	return msg, tracing.SpanContext{}
+}
+
+// untrace 展开 Traced 包装的消息，WithTrace 指定的链路优先
+func (rm *RequestMessage) untrace() {
+	msg, sc := untrace(rm.Message)
+	rm.Message = msg
+	if !rm.Trace.IsValid() {
+		rm.Trace = sc
+	}
+}
+
+// WithTrace 指定消息所在的链路，见 Traced
+func WithTrace(sc tracing.SpanContext) SendOption {
+	return func(msg *RequestMessage) {
+		msg.Trace = sc
+	}
+}
+
+// SpanContext 返回当前处理的消息所在的Span，消息没有链路时返回无效的 SpanContext
+func (state *ChildActor) SpanContext() tracing.SpanContext {
+	return state.handling.span.SpanContext()
+}
+
+// startSpan 以消息携带的链路为父节点开始处理消息的Span，返回之前的Span，由 endSpan 恢复
+func (state *ChildActor) startSpan(msg *RequestMessage) *tracing.Span {
+	prev := state.handling.span
+	if !msg.Trace.IsValid() {
+		state.handling.span = nil
+		return prev
+	}
+	state.handling.span = tracing.Start(msg.Trace, state.pattern+"."+msgTypeLabel(msg.MsgType),
+		tracing.KV("actor", state.actorName),
+		tracing.KV("message", fmt.Sprintf("%T", msg.Message)))
+	return prev
+}
+
+func (state *ChildActor) endSpan(prev *tracing.Span) {
+	state.handling.span.End()
+	state.handling.span = prev
+}
@@ -0,0 +1,119 @@
+package actor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gitee.com/orbit-w/orbit/app/proto/pb/pb_core"
+	"gitee.com/orbit-w/orbit/lib/tracing"
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.SpanData
+}
+
+func (r *spanRecorder) Export(span *tracing.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *spanRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.spans)
+}
+
+func (r *spanRecorder) byName(name string) *tracing.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, span := range r.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// traceBehavior 收到请求后在同一条链路上请求下游Actor
+type traceBehavior struct {
+	MockBehavior
+	downstream string
+}
+
+func (b *traceBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if b.downstream == "" {
+		return ctx.SpanContext(), nil
+	}
+	ref := ctx.GetSystem().NewActorRef(NewProps(), "trace-downstream", b.downstream)
+	return ref.RequestFuture(Traced(ctx.SpanContext(), msg))
+}
+
+func TestTrace_PropagateThroughActors(t *testing.T) {
+	recorder := &spanRecorder{}
+	tracing.SetExporter(recorder)
+	defer tracing.SetExporter(nil)
+
+	factories := NewFactoryRegistry()
+	factories.Reg("trace-upstream", func(actorName string) Behavior { return &traceBehavior{downstream: "trace-leaf"} })
+	factories.Reg("trace-leaf", func(actorName string) Behavior { return &traceBehavior{} })
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+
+	ctx, root := tracing.StartSpan(context.Background(), "test.root")
+	ref := af.NewActorRef(NewProps(), "trace-upstream", "trace-upstream")
+	re, err := ref.RequestFuture(Traced(tracing.SpanContextFromContext(ctx), "ping"))
+	assert.NoError(t, err)
+	root.End()
+
+	// Actor回复后才结束自己的Span
+	assert.Eventually(t, func() bool { return recorder.count() == 3 }, time.Second, 10*time.Millisecond)
+	upstream := recorder.byName("trace-upstream.request")
+	leaf := recorder.byName("trace-leaf.request")
+	if assert.NotNil(t, upstream) && assert.NotNil(t, leaf) {
+		rootSC := root.SpanContext()
+		assert.Equal(t, rootSC.TraceID.String(), upstream.TraceID)
+		assert.Equal(t, rootSC.SpanID.String(), upstream.ParentID)
+		assert.Equal(t, upstream.TraceID, leaf.TraceID)
+		assert.Equal(t, upstream.SpanID, leaf.ParentID)
+		assert.Equal(t, "trace-downstream", leaf.Attrs["actor"])
+		// 下游Actor处理期间看到的是自己的Span
+		assert.Equal(t, leaf.SpanID, re.(tracing.SpanContext).SpanID.String())
+	}
+
+	// 没有链路的消息不创建Span
+	_, err = ref.RequestFuture("untraced")
+	assert.NoError(t, err)
+	assert.NoError(t, af.StopActor("trace-upstream", "trace-upstream"))
+	assert.Eventually(t, func() bool { return !af.Actors().Exist("trace-upstream") }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 3, recorder.count())
+}
+
+func TestTrace_RemoteFrame(t *testing.T) {
+	sc := tracing.Start(tracing.SpanContext{}, "remote").SpanContext()
+	assert.False(t, sc.IsValid(), "tracing disabled")
+
+	sc = tracing.SpanContext{TraceID: tracing.TraceID{1, 2, 3}, SpanID: tracing.SpanID{4, 5, 6}}
+	for _, kind := range []int8{remoteFrameSend, remoteFrameRequest} {
+		in := &remoteFrame{kind: kind, actorName: "a", pattern: "p", message: &pb_core.Request_SearchBook{Query: "m"}, trace: sc}
+		data, err := encodeRemoteFrame(in)
+		assert.NoError(t, err)
+		out, err := decodeRemoteFrame(data, DefaultRemoteErrors())
+		assert.NoError(t, err)
+		assert.Equal(t, sc, out.trace)
+		assert.Equal(t, "m", out.message.(*pb_core.Request_SearchBook).Query)
+
+		// 不携带trace的帧
+		in.trace = tracing.SpanContext{}
+		data, err = encodeRemoteFrame(in)
+		assert.NoError(t, err)
+		out, err = decodeRemoteFrame(data, DefaultRemoteErrors())
+		assert.NoError(t, err)
+		assert.False(t, out.trace.IsValid())
+	}
+}
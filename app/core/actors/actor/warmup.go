@@ -0,0 +1,260 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"go.uber.org/zap"
+)
+
+/*
+	批量激活与预热
+
+	1. StartActors 以有限的并发向各个supervisor同时发送 StartActorRequest，返回与输入顺序一致的结果
+	   单个Actor失败不影响其他Actor，已经激活的Actor直接返回
+	2. 业务模块通过 RegWarmup 注册需要在服务启动时激活的Actor，Warmup 实现 service.IService，
+	   在网关之前注册到 service.Services，全部激活完成后网关才开始接受连接
+*/
+
+const (
+	DefaultActivateConcurrency = 64
+)
+
+// ActivateTarget 需要激活的Actor
+type ActivateTarget struct {
+	ActorName string
+	Pattern   string
+	Props     *Props // 为空时使用 NewProps()
+}
+
+// ActivateResult 单个Actor的激活结果
+type ActivateResult struct {
+	ActorName string
+	Pattern   string
+	Process   *Process
+	Err       error
+}
+
+// ActivateResults StartActors 的结果，与输入的顺序一致
+type ActivateResults []ActivateResult
+
+// Failed 返回激活失败的结果
+func (rs ActivateResults) Failed() ActivateResults {
+	var failed ActivateResults
+	for i := range rs {
+		if rs[i].Err != nil {
+			failed = append(failed, rs[i])
+		}
+	}
+	return failed
+}
+
+// Err 合并所有失败的原因，全部成功时返回nil
+func (rs ActivateResults) Err() error {
+	var errs []error
+	for i := range rs {
+		if rs[i].Err != nil {
+			errs = append(errs, fmt.Errorf("activate %s(%s): %w", rs[i].ActorName, rs[i].Pattern, rs[i].Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type ActivateOption func(o *activateOptions)
+
+type activateOptions struct {
+	concurrency int
+	timeout     time.Duration
+}
+
+// WithActivateConcurrency 同时等待激活的Actor数量上限，默认为 DefaultActivateConcurrency
+func WithActivateConcurrency(n int) ActivateOption {
+	return func(o *activateOptions) {
+		if n > 0 {
+			o.concurrency = n
+		}
+	}
+}
+
+// WithActivateTimeout 单个Actor激活的超时，默认与 GetOrStartActor 相同
+func WithActivateTimeout(d time.Duration) ActivateOption {
+	return func(o *activateOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
+
+// StartActors 使用默认的 System 批量激活Actor
+func StartActors(ctx context.Context, targets []ActivateTarget, ops ...ActivateOption) ActivateResults {
+	return System.StartActors(ctx, targets, ops...)
+}
+
+// StartActors 批量激活Actor，最多同时等待 concurrency 个Actor初始化
+// ctx 取消后不再发送新的激活请求，未处理的Actor的结果为 ctx.Err()
+func (af *ActorSystem) StartActors(ctx context.Context, targets []ActivateTarget, ops ...ActivateOption) ActivateResults {
+	o := &activateOptions{concurrency: DefaultActivateConcurrency}
+	for _, op := range ops {
+		op(o)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	results := make(ActivateResults, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(o.concurrency, len(targets)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = af.activate(ctx, &targets[i], o.timeout)
+			}
+		}()
+	}
+
+	for i := range targets {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			results[i] = ActivateResult{ActorName: targets[i].ActorName, Pattern: targets[i].Pattern, Err: ctx.Err()}
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+func (af *ActorSystem) activate(ctx context.Context, target *ActivateTarget, timeout time.Duration) ActivateResult {
+	result := ActivateResult{ActorName: target.ActorName, Pattern: target.Pattern}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+	props := target.Props
+	if props == nil {
+		props = NewProps()
+	}
+	if timeout > 0 {
+		result.Process, result.Err = af.getOrStartActor(&StartActorRequest{
+			ActorName: target.ActorName,
+			Pattern:   target.Pattern,
+			Props:     props,
+		}, timeout, timeout)
+	} else {
+		result.Process, result.Err = af.GetOrStartActor(target.ActorName, target.Pattern, props)
+	}
+	return result
+}
+
+// WarmupProvider 返回服务启动时需要激活的Actor
+type WarmupProvider func() []ActivateTarget
+
+// WarmupRegistry 服务启动时需要激活的Actor，每个 ActorSystem 可以使用独立的注册表(WithWarmups)
+type WarmupRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]WarmupProvider
+}
+
+func NewWarmupRegistry() *WarmupRegistry {
+	return &WarmupRegistry{
+		providers: make(map[string]WarmupProvider),
+	}
+}
+
+// defaultWarmups 未指定注册表的 ActorSystem 以及包级别的 RegWarmup 使用的注册表
+var defaultWarmups = NewWarmupRegistry()
+
+// DefaultWarmups 返回默认的预热注册表
+func DefaultWarmups() *WarmupRegistry {
+	return defaultWarmups
+}
+
+// Reg 注册服务启动时需要激活的Actor，name 用于日志，重复注册时panic
+func (r *WarmupRegistry) Reg(name string, provider WarmupProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.providers[name]; ok {
+		panic("warmup already registered: " + name)
+	}
+	r.providers[name] = provider
+}
+
+// Targets 按注册名称的顺序收集所有需要激活的Actor
+func (r *WarmupRegistry) Targets() []ActivateTarget {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	providers := make([]WarmupProvider, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		providers = append(providers, r.providers[name])
+	}
+	r.mu.RUnlock()
+
+	var targets []ActivateTarget
+	for _, provider := range providers {
+		targets = append(targets, provider()...)
+	}
+	return targets
+}
+
+// RegWarmup 注册服务启动时需要激活的Actor，name 用于日志，重复注册会panic
+func RegWarmup(name string, provider WarmupProvider) {
+	defaultWarmups.Reg(name, provider)
+}
+
+// Warmup 服务启动时激活 RegWarmup 注册的Actor，实现 service.IService
+//
+//	services.Reg(actor.NewWarmup(af)).Reg(new(stream.AgentStream))
+type Warmup struct {
+	system        *ActorSystem
+	ops           []ActivateOption
+	allowFailures int
+}
+
+func NewWarmup(af *ActorSystem, ops ...ActivateOption) *Warmup {
+	return &Warmup{system: af, ops: ops}
+}
+
+// AllowFailures 允许激活失败的Actor数量，超过时 Start 返回错误，默认不允许失败
+func (w *Warmup) AllowFailures(n int) *Warmup {
+	w.allowFailures = n
+	return w
+}
+
+// Start 按注册名称的顺序收集所有Actor后批量激活
+func (w *Warmup) Start() error {
+	targets := w.system.Warmups().Targets()
+	begin := time.Now()
+	results := w.system.StartActors(context.Background(), targets, w.ops...)
+	failed := results.Failed()
+	for i := range failed {
+		logger.GetLogger().Error("Warmup actor failed",
+			zap.String("ActorName", failed[i].ActorName),
+			zap.String("Pattern", failed[i].Pattern),
+			zap.Error(failed[i].Err))
+	}
+	logger.GetLogger().Info("Warmup actors complete",
+		zap.Int("Total", len(results)),
+		zap.Int("Failed", len(failed)),
+		zap.Duration("Elapsed", time.Since(begin)))
+
+	if len(failed) > w.allowFailures {
+		return results.Err()
+	}
+	return nil
+}
+
+// Stop 预热的Actor随 ActorSystem 一起停止
+func (w *Warmup) Stop() error {
+	return nil
+}
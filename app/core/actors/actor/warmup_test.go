@@ -0,0 +1,98 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowInitBehavior 初始化耗时20ms，记录同时初始化的Actor数量
+type slowInitBehavior struct {
+	MockBehavior
+	inflight, peak *atomic.Int32
+}
+
+func (b *slowInitBehavior) HandleInit(ctx IContext) error {
+	n := b.inflight.Add(1)
+	defer b.inflight.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+
+func TestStartActors(t *testing.T) {
+	var inflight, peak atomic.Int32
+	factories := NewFactoryRegistry()
+	factories.Reg("warmup-region", func(actorName string) Behavior {
+		return &slowInitBehavior{inflight: &inflight, peak: &peak}
+	})
+	factories.Reg("warmup-fail", func(actorName string) Behavior { return &failInitBehavior{} })
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+
+	var targets []ActivateTarget
+	for i := 0; i < 16; i++ {
+		targets = append(targets, ActivateTarget{ActorName: fmt.Sprintf("region-%d", i), Pattern: "warmup-region"})
+	}
+	targets = append(targets, ActivateTarget{ActorName: "region-broken", Pattern: "warmup-fail"})
+	// 重复的Actor返回同一个实例
+	targets = append(targets, ActivateTarget{ActorName: "region-0", Pattern: "warmup-region"})
+
+	results := af.StartActors(context.Background(), targets, WithActivateConcurrency(4))
+	assert.Len(t, results, len(targets))
+	for i := range results {
+		assert.Equal(t, targets[i].ActorName, results[i].ActorName)
+	}
+	assert.Same(t, results[0].Process, results[17].Process)
+	assert.True(t, af.Actors().Exist("region-15"))
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+	assert.Greater(t, peak.Load(), int32(1), "start requests are pipelined")
+
+	failed := results.Failed()
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, "region-broken", failed[0].ActorName)
+		assert.Nil(t, failed[0].Process)
+	}
+	assert.True(t, errors.Is(results.Err(), errMetricsInit))
+
+	// ctx 取消后不再激活
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = af.StartActors(ctx, []ActivateTarget{{ActorName: "region-late", Pattern: "warmup-region"}})
+	assert.True(t, errors.Is(results[0].Err, context.Canceled))
+	assert.False(t, af.Actors().Exist("region-late"))
+}
+
+func TestWarmup(t *testing.T) {
+	factories := NewFactoryRegistry()
+	factories.Reg("warmup-boss", MockBehaviorFactory)
+	factories.Reg("warmup-boss-fail", func(actorName string) Behavior { return &failInitBehavior{} })
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories))
+	defer af.Stop(context.Background())
+
+	RegWarmup("world-boss", func() []ActivateTarget {
+		return []ActivateTarget{
+			{ActorName: "boss-1", Pattern: "warmup-boss"},
+			{ActorName: "boss-broken", Pattern: "warmup-boss-fail"},
+		}
+	})
+	assert.Panics(t, func() { RegWarmup("world-boss", nil) })
+
+	warmup := NewWarmup(af, WithActivateTimeout(time.Second))
+	assert.True(t, errors.Is(warmup.Start(), errMetricsInit))
+	assert.True(t, af.Actors().Exist("boss-1"))
+
+	assert.NoError(t, warmup.AllowFailures(1).Start())
+	assert.NoError(t, warmup.Stop())
+}
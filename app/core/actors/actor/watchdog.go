@@ -0,0 +1,387 @@
+package actor
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitee.com/orbit-w/orbit/lib/logger"
+	"go.uber.org/zap"
+)
+
+/*
+	慢处理检测
+
+	1. 为Pattern注册 WatchdogPolicy 后，Actor处理每条消息(包括定时器)时登记开始时间，watchdog 定期检查
+	2. 处理时间超过 Threshold 的消息报告一次：输出消息类型、ActorName以及处理消息的协程的调用栈，记录 Metrics.SlowHandler
+	   在两次检查之间完成的慢消息在处理结束时报告，此时没有调用栈
+	   采样调用栈需要导出所有协程，同一次检查中的慢消息共用一次导出，两次导出至少间隔 watchdogStackInterval，期间的报告没有调用栈
+	   处理消息的协程ID在 startWatch 时记录，邮箱被调度到新的协程时更新，处理消息时不需要获取
+	3. Trip 为true时，慢消息处理期间及结束后 Cooldown 时间内Actor处于熔断状态，新的Request直接返回 ErrActorOverloaded，
+	   Send 消息仍然进入邮箱
+*/
+
+const (
+	DefaultWatchdogInterval = 100 * time.Millisecond
+	watchdogMaxStack        = 8 << 20     // 采样调用栈时的最大缓冲区
+	watchdogStackInterval   = time.Second // 两次导出所有协程调用栈的最小间隔
+)
+
+// WatchdogPolicy 慢处理检测策略，按Pattern注册
+type WatchdogPolicy struct {
+	Threshold time.Duration            // 单条消息的处理时间超过此值视为慢处理
+	Trip      bool                     // 慢处理期间熔断，拒绝新的Request
+	Cooldown  time.Duration            // 慢消息处理结束后熔断保持的时间
+	OnSlow    func(record *SlowRecord) // 报告慢处理时调用，为空时输出警告日志
+}
+
+// SlowRecord 一次慢处理的现场信息
+type SlowRecord struct {
+	ActorName string
+	Pattern   string
+	MsgType   string // request/send/forward/timer
+	Message   string // 消息的类型
+	Elapsed   time.Duration
+	Stack     []byte // 处理消息的协程的调用栈，消息已经处理完成时为空
+	Tripped   bool   // 是否触发了熔断
+	Time      time.Time
+}
+
+// WatchdogPolicyRegistry 按Pattern注册的慢处理检测策略，每个 ActorSystem 可以使用独立的注册表(WithWatchdogPolicies)
+type WatchdogPolicyRegistry struct {
+	mu       sync.RWMutex
+	policies map[string]*WatchdogPolicy
+}
+
+func NewWatchdogPolicyRegistry() *WatchdogPolicyRegistry {
+	return &WatchdogPolicyRegistry{
+		policies: make(map[string]*WatchdogPolicy),
+	}
+}
+
+// defaultWatchdogPolicies 未指定注册表的 ActorSystem 以及包级别的 RegWatchdogPolicy 使用的注册表
+var defaultWatchdogPolicies = NewWatchdogPolicyRegistry()
+
+// DefaultWatchdogPolicies 返回默认的慢处理检测策略注册表
+func DefaultWatchdogPolicies() *WatchdogPolicyRegistry {
+	return defaultWatchdogPolicies
+}
+
+// Reg 为Pattern注册慢处理检测策略，重复注册时panic
+func (r *WatchdogPolicyRegistry) Reg(pattern string, policy *WatchdogPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.policies[pattern]; ok {
+		panic("watchdog policy already registered: " + pattern)
+	}
+	r.policies[pattern] = policy
+}
+
+// Get 返回Pattern的慢处理检测策略，未注册或 Threshold 无效时返回nil
+func (r *WatchdogPolicyRegistry) Get(pattern string) *WatchdogPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policy := r.policies[pattern]
+	if policy == nil || policy.Threshold <= 0 {
+		return nil
+	}
+	return policy
+}
+
+// RegWatchdogPolicy 为Pattern注册慢处理检测策略，未注册的Pattern不检测
+func RegWatchdogPolicy(pattern string, policy *WatchdogPolicy) {
+	defaultWatchdogPolicies.Reg(pattern, policy)
+}
+
+// GetWatchdogPolicy 返回Pattern的慢处理检测策略，未注册时返回nil
+func GetWatchdogPolicy(pattern string) *WatchdogPolicy {
+	return defaultWatchdogPolicies.Get(pattern)
+}
+
+// WithWatchdogInterval 设置慢处理检查的间隔，默认为 DefaultWatchdogInterval
+func WithWatchdogInterval(d time.Duration) SystemOption {
+	return func(af *ActorSystem) {
+		af.watchdogInterval = d
+	}
+}
+
+// watchdog 一个 ActorSystem 中所有被检测Actor的处理状态，第一个Actor登记时开始检查
+type watchdog struct {
+	system   *ActorSystem
+	interval time.Duration
+	actors   sync.Map // actorName -> *handlerWatch
+	once     sync.Once
+	stopOnce sync.Once
+	stop     chan struct{}
+	lastDump time.Time // 上一次导出调用栈的时间，只在检查协程中访问
+}
+
+func newWatchdog(system *ActorSystem, interval time.Duration) *watchdog {
+	if interval <= 0 {
+		interval = DefaultWatchdogInterval
+	}
+	return &watchdog{
+		system:   system,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (w *watchdog) register(actorName, pattern string, policy *WatchdogPolicy, goid *atomic.Int64) *handlerWatch {
+	h := &handlerWatch{watchdog: w, actorName: actorName, pattern: pattern, policy: policy, goid: goid}
+	w.actors.Store(actorName, h)
+	w.once.Do(func() {
+		go w.run()
+	})
+	return h
+}
+
+func (w *watchdog) unregister(h *handlerWatch) {
+	w.actors.CompareAndDelete(h.actorName, h)
+}
+
+// overloaded Actor是否处于熔断状态
+func (w *watchdog) overloaded(actorName string) bool {
+	v, ok := w.actors.Load(actorName)
+	return ok && v.(*handlerWatch).open(time.Now())
+}
+
+func (w *watchdog) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+func (w *watchdog) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case now := <-ticker.C:
+			w.tick(now)
+		}
+	}
+}
+
+// tick 检查一次所有被检测的Actor，报告的慢消息共用一次调用栈导出
+func (w *watchdog) tick(now time.Time) {
+	var (
+		records []*SlowRecord
+		goids   []int64
+	)
+	w.actors.Range(func(_, v any) bool {
+		if record, goid := v.(*handlerWatch).check(now); record != nil {
+			records = append(records, record)
+			goids = append(goids, goid)
+		}
+		return true
+	})
+	if len(records) == 0 {
+		return
+	}
+	if now.Sub(w.lastDump) >= watchdogStackInterval {
+		w.lastDump = now
+		dump := dumpGoroutines()
+		for i, record := range records {
+			record.Stack = goroutineStack(dump, goids[i])
+		}
+	}
+	for _, record := range records {
+		w.report(record)
+	}
+}
+
+func (w *watchdog) report(record *SlowRecord) {
+	w.system.Metrics().SlowHandler(record.Pattern, record.MsgType)
+	if record.Tripped {
+		w.system.Metrics().CircuitTripped(record.Pattern)
+	}
+	if policy := w.system.WatchdogPolicies().Get(record.Pattern); policy != nil && policy.OnSlow != nil {
+		policy.OnSlow(record)
+		return
+	}
+	logger.GetLogger().Warn("Slow actor handler",
+		zap.String("ActorName", record.ActorName),
+		zap.String("Pattern", record.Pattern),
+		zap.String("MsgType", record.MsgType),
+		zap.String("Message", record.Message),
+		zap.Duration("Elapsed", record.Elapsed),
+		zap.Bool("Tripped", record.Tripped),
+		zap.ByteString("Stack", record.Stack))
+}
+
+// handlerWatch 一个Actor当前处理的消息，由Actor的协程更新，watchdog 的协程读取
+type handlerWatch struct {
+	watchdog  *watchdog
+	actorName string
+	pattern   string
+	policy    *WatchdogPolicy
+
+	mu        sync.Mutex
+	seq       uint64    // 已开始处理的消息数
+	reported  uint64    // 已报告的慢消息的seq
+	since     time.Time // 当前消息的开始时间，空闲时为零值
+	msgType   string
+	message   string
+	goid      *atomic.Int64 // 处理消息的协程ID，与邮箱共享
+	tripping  bool          // 当前慢消息处理中的熔断
+	openUntil time.Time     // 慢消息结束后熔断保持到的时间
+}
+
+// begin 开始处理一条消息，与 end 配对: defer state.handling.watch.end(state.handling.watch.begin(...))
+func (h *handlerWatch) begin(msgType string, msg any) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	now := time.Now()
+	h.mu.Lock()
+	h.seq++
+	h.since, h.msgType, h.message = now, msgType, fmt.Sprintf("%T", msg)
+	h.mu.Unlock()
+	return now
+}
+
+func (h *handlerWatch) end(start time.Time) {
+	if h == nil {
+		return
+	}
+	now := time.Now()
+	var record *SlowRecord
+	h.mu.Lock()
+	if !h.since.Equal(start) {
+		// 嵌套处理(例如恢复暂存的消息)时只统计最外层
+		h.mu.Unlock()
+		return
+	}
+	elapsed := now.Sub(h.since)
+	if elapsed > h.policy.Threshold && h.reported != h.seq {
+		h.reported = h.seq
+		record = h.record(now, elapsed)
+	}
+	if h.tripping || (record != nil && record.Tripped) {
+		h.tripping = false
+		h.openUntil = now.Add(h.policy.Cooldown)
+	}
+	h.since = time.Time{}
+	h.mu.Unlock()
+
+	if record != nil {
+		h.watchdog.report(record)
+	}
+}
+
+// check 返回超过阈值且尚未报告的消息，以及处理消息的协程ID
+func (h *handlerWatch) check(now time.Time) (*SlowRecord, int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.since.IsZero() || h.reported == h.seq {
+		return nil, 0
+	}
+	elapsed := now.Sub(h.since)
+	if elapsed <= h.policy.Threshold {
+		return nil, 0
+	}
+	h.reported = h.seq
+	record := h.record(now, elapsed)
+	h.tripping = record.Tripped
+	return record, h.goid.Load()
+}
+
+func (h *handlerWatch) record(now time.Time, elapsed time.Duration) *SlowRecord {
+	return &SlowRecord{
+		ActorName: h.actorName,
+		Pattern:   h.pattern,
+		MsgType:   h.msgType,
+		Message:   h.message,
+		Elapsed:   elapsed,
+		Tripped:   h.policy.Trip,
+		Time:      now,
+	}
+}
+
+func (h *handlerWatch) open(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.tripping || now.Before(h.openUntil)
+}
+
+// overloaded Actor是否处于慢处理的熔断状态
+func (af *ActorSystem) overloaded(actorName string) bool {
+	if af == nil || af.watchdog == nil {
+		return false
+	}
+	return af.watchdog.overloaded(actorName)
+}
+
+// startWatch 为注册了 WatchdogPolicy 的Pattern登记处理状态，在Actor的协程中调用
+// 记录当前的协程ID，之后由邮箱在每次调度到新的协程时更新
+func (state *ChildActor) startWatch() {
+	system := state.GetSystem()
+	if system == nil || system.watchdog == nil {
+		return
+	}
+	policy := system.WatchdogPolicies().Get(state.pattern)
+	if policy == nil {
+		return
+	}
+	goid := new(atomic.Int64)
+	if state.mailbox != nil {
+		goid = &state.mailbox.goid
+		state.mailbox.watched.Store(true)
+	}
+	goid.Store(currentGoroutineID())
+	state.handling.watch = system.watchdog.register(state.actorName, state.pattern, policy, goid)
+}
+
+func (state *ChildActor) stopWatch() {
+	if state.handling.watch != nil {
+		state.handling.watch.watchdog.unregister(state.handling.watch)
+		state.handling.watch = nil
+	}
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// currentGoroutineID 从调用栈的第一行解析当前协程的ID
+func currentGoroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		id, _ := strconv.ParseInt(string(b[:i]), 10, 64)
+		return id
+	}
+	return 0
+}
+
+// dumpGoroutines 导出所有协程的调用栈，会短暂暂停所有协程，调用方需要限制频率
+func dumpGoroutines() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= watchdogMaxStack {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+// goroutineStack 从导出的调用栈中找到指定协程的调用栈，协程已经不存在时返回nil
+func goroutineStack(dump []byte, goid int64) []byte {
+	if goid <= 0 {
+		return nil
+	}
+	header := []byte("goroutine " + strconv.FormatInt(goid, 10) + " [")
+	for _, stack := range bytes.Split(dump, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return stack
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package actor
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/protoactor-go/actor"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowBehavior 收到 "block" 后阻塞到 release 关闭
+type slowBehavior struct {
+	MockBehavior
+	release chan struct{}
+}
+
+func (b *slowBehavior) HandleSend(ctx IContext, msg any) {
+	if msg == "block" {
+		<-b.release
+	}
+}
+
+func (b *slowBehavior) HandleRequest(ctx IContext, msg any) (any, error) {
+	if msg == "sleep" {
+		time.Sleep(30 * time.Millisecond)
+	}
+	return msg, nil
+}
+
+// watchdogMetrics 只记录慢处理相关的指标
+type watchdogMetrics struct {
+	nopMetrics
+	slow  atomic.Int32
+	trips atomic.Int32
+}
+
+func (m *watchdogMetrics) SlowHandler(string, string) { m.slow.Add(1) }
+func (m *watchdogMetrics) CircuitTripped(string)      { m.trips.Add(1) }
+
+func TestWatchdog_SlowHandler(t *testing.T) {
+	records := make(chan *SlowRecord, 4)
+	RegWatchdogPolicy("watchdog-slow", &WatchdogPolicy{
+		Threshold: 20 * time.Millisecond,
+		Trip:      true,
+		Cooldown:  100 * time.Millisecond,
+		OnSlow:    func(record *SlowRecord) { records <- record },
+	})
+	assert.Panics(t, func() { RegWatchdogPolicy("watchdog-slow", &WatchdogPolicy{}) })
+
+	release := make(chan struct{})
+	factories := NewFactoryRegistry()
+	factories.Reg("watchdog-slow", func(actorName string) Behavior { return &slowBehavior{release: release} })
+	metrics := &watchdogMetrics{}
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories), WithMetrics(metrics),
+		WithWatchdogInterval(10*time.Millisecond))
+	defer af.Stop(context.Background())
+
+	ref := af.NewActorRef(NewProps(), "watchdog-actor", "watchdog-slow")
+	_, err := ref.RequestFuture("ping")
+	assert.NoError(t, err)
+
+	// 处理中的慢消息由 watchdog 报告，附带处理协程的调用栈
+	assert.NoError(t, ref.Send("block"))
+	var record *SlowRecord
+	select {
+	case record = <-records:
+	case <-time.After(time.Second):
+		t.Fatal("slow handler not reported")
+	}
+	assert.Equal(t, "watchdog-actor", record.ActorName)
+	assert.Equal(t, MetricMsgSend, record.MsgType)
+	assert.Equal(t, "string", record.Message)
+	assert.True(t, record.Tripped)
+	assert.Contains(t, string(record.Stack), "slowBehavior")
+
+	// 熔断期间拒绝新的Request
+	_, err = ref.RequestFuture("ping")
+	assert.True(t, errors.Is(err, ErrActorOverloaded))
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, err := ref.RequestFuture("ping")
+		return err == nil
+	}, time.Second, 20*time.Millisecond)
+
+	// 慢请求处理结束后同样进入熔断，同一条消息只报告一次
+	_, err = ref.RequestFuture("sleep")
+	assert.NoError(t, err)
+	select {
+	case record = <-records:
+		assert.Equal(t, MetricMsgRequest, record.MsgType)
+		assert.GreaterOrEqual(t, record.Elapsed, 20*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("slow request not reported")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, records, 0)
+	assert.Equal(t, int32(2), metrics.slow.Load())
+	assert.Equal(t, int32(2), metrics.trips.Load())
+
+	// 未注册策略的Pattern不检测
+	assert.Nil(t, GetWatchdogPolicy("watchdog-unknown"))
+}
+
+// 调用栈导出限制频率，间隔内的慢消息仍然报告但没有调用栈；邮箱调度到新的协程后仍能找到处理协程
+func TestWatchdog_StackRateLimit(t *testing.T) {
+	records := make(chan *SlowRecord, 4)
+	policies := NewWatchdogPolicyRegistry()
+	policies.Reg("watchdog-rate", &WatchdogPolicy{
+		Threshold: 20 * time.Millisecond,
+		OnSlow:    func(record *SlowRecord) { records <- record },
+	})
+	var release atomic.Pointer[chan struct{}]
+	factories := NewFactoryRegistry()
+	factories.Reg("watchdog-rate", func(actorName string) Behavior { return &rateBehavior{release: &release} })
+	af := NewActorFacade(actor.NewActorSystem(), WithFactories(factories), WithWatchdogPolicies(policies),
+		WithWatchdogInterval(10*time.Millisecond))
+	defer af.Stop(context.Background())
+
+	ref := af.NewActorRef(NewProps(), "watchdog-rate-actor", "watchdog-rate")
+	block := func() *SlowRecord {
+		ch := make(chan struct{})
+		release.Store(&ch)
+		assert.NoError(t, ref.Send("block"))
+		defer close(ch)
+		select {
+		case record := <-records:
+			return record
+		case <-time.After(time.Second):
+			t.Fatal("slow handler not reported")
+			return nil
+		}
+	}
+
+	_, err := ref.RequestFuture("ping")
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond) // 邮箱空闲，下一条消息在新的协程中处理
+	assert.Contains(t, string(block().Stack), "rateBehavior")
+	assert.Nil(t, block().Stack, "stack dump should be rate limited")
+}
+
+// rateBehavior 收到 "block" 后阻塞到当前的 release 关闭
+type rateBehavior struct {
+	MockBehavior
+	release *atomic.Pointer[chan struct{}]
+}
+
+func (b *rateBehavior) HandleSend(ctx IContext, msg any) {
+	if msg == "block" {
+		<-*b.release.Load()
+	}
+}
@@ -1,11 +1,21 @@
 package dispatch
 
 import (
+	"context"
+
 	"gitee.com/orbit-w/orbit/app/controller"
 	"gitee.com/orbit-w/orbit/app/proto/pb"
+	"gitee.com/orbit-w/orbit/lib/tracing"
 	"google.golang.org/protobuf/proto"
 )
 
-func Dispatch(pid uint32, data []byte) (proto.Message, uint32, error) {
-	return pb.DispatchCoreRequestByID(controller.GlobalManager(), pid, data)
+// Dispatch 按协议ID分发客户端请求，在ctx的链路上记录分发的Span
+// 处理函数收到携带该Span的ctx，调用Actor时传入 ActorRef.RequestFutureContext，链路和取消信号沿调用链传递
+func Dispatch(ctx context.Context, pid uint32, data []byte) (proto.Message, uint32, error) {
+	ctx, span := tracing.StartSpan(ctx, "dispatch", tracing.KV("pid", pid))
+	defer span.End()
+
+	response, rspPid, err := pb.DispatchCoreRequestByID(ctx, controller.GlobalManager(), pid, data)
+	span.SetError(err)
+	return response, rspPid, err
 }
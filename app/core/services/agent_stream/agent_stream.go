@@ -11,6 +11,7 @@ import (
 	"gitee.com/orbit-w/orbit/app/core/network"
 	"gitee.com/orbit-w/orbit/app/modules/config"
 	"gitee.com/orbit-w/orbit/lib/logger"
+	"gitee.com/orbit-w/orbit/lib/tracing"
 	"github.com/orbit-w/mux-go/metadata"
 	"go.uber.org/zap"
 )
@@ -59,12 +60,31 @@ var streamHandle = func(stream mux.IServerConn) error {
 		}
 
 		for _, msg := range msgList {
-			requestHandler(session, msg.Data, msg.Seq, msg.Pid)
+			handleRequest(session, msg.Data, msg.Seq, msg.Pid)
 		}
 	}
 	return nil
 }
 
+// handleRequest 以会话的uid和请求的seq开始一条链路，处理客户端请求
+func handleRequest(session *network.Session, data []byte, seq, pid uint32) {
+	ctx, span := tracing.StartSpan(context.Background(), "gateway.request",
+		tracing.KV("uid", session.Uid()),
+		tracing.KV("session", session.Id()),
+		tracing.KV("seq", seq),
+		tracing.KV("pid", pid))
+	defer span.End()
+
+	if err := requestHandler(ctx, session, data, seq, pid); err != nil {
+		span.SetError(err)
+		logger.GetLogger().Error("handle request failed",
+			zap.Int64("uid", session.Uid()),
+			zap.Uint32("seq", seq),
+			zap.Uint32("pid", pid),
+			zap.Error(err))
+	}
+}
+
 type AgentStream struct {
 	server *mux.Server
 }
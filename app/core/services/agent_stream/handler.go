@@ -1,11 +1,16 @@
 package agent_stream
 
-import "gitee.com/orbit-w/orbit/app/core/network"
+import (
+	"context"
+
+	"gitee.com/orbit-w/orbit/app/core/network"
+)
 
 var (
-	requestHandler func(session *network.Session, data []byte, seq, pid uint32) error
+	requestHandler func(ctx context.Context, session *network.Session, data []byte, seq, pid uint32) error
 )
 
-func RegisterRequestHandler(handler func(session *network.Session, data []byte, seq, pid uint32) error) {
+// RegisterRequestHandler 注册客户端请求的处理函数，ctx 携带该请求在网关开始的链路
+func RegisterRequestHandler(handler func(ctx context.Context, session *network.Session, data []byte, seq, pid uint32) error) {
 	requestHandler = handler
 }
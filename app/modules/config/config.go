@@ -13,7 +13,10 @@ var (
 )
 
 type Config struct {
-	Server Server
+	Server  Server
+	Cluster Cluster
+	Trace   Trace
+	Admin   Admin
 }
 
 type Server struct {
@@ -22,6 +25,32 @@ type Server struct {
 	Port  string `toml:"port"`
 }
 
+// Cluster 集群节点的静态配置，用于Actor跨节点通信的节点发现，Nodes 为空时单节点运行
+// 只有 Nodes 中的节点可以建立跨节点连接，Secret 不为空时还需要持有相同的密钥
+type Cluster struct {
+	Secret string `toml:"secret"`
+	Nodes  []Node `toml:"nodes"`
+}
+
+type Node struct {
+	Id     string `toml:"id"`
+	Host   string `toml:"host"`
+	Port   string `toml:"port"`
+	Region string `toml:"region"` // 所属区域，按区域放置的Actor只会分配到同区域的节点
+}
+
+// Trace 链路追踪的输出，Exporter 为空时不记录
+type Trace struct {
+	Exporter string `toml:"exporter"` // stdout / file
+	File     string `toml:"file"`     // Exporter 为 file 时写入的文件
+}
+
+// Admin Actor管理端点的HTTP服务，Addr 为空时不启用
+// 管理端点没有鉴权，只应监听内网或本机地址
+type Admin struct {
+	Addr string `toml:"addr"` // 例如 127.0.0.1:8970
+}
+
 func GetConfig() *Config {
 	return &cfg
 }
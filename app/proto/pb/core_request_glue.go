@@ -2,21 +2,22 @@
 package pb
 
 import (
+	"context"
 	"fmt"
 	"google.golang.org/protobuf/proto"
 	"gitee.com/orbit-w/orbit/app/proto/pb/pb_core"
 )
 
-// CoreRequestHandler 处理Core包的请求消息
+// CoreRequestHandler 处理Core包的请求消息，ctx 携带请求的链路和取消信号
 type CoreRequestHandler interface {
 	// HandleSearchBook 处理SearchBook请求
-	HandleSearchBook(req *pb_core.Request_SearchBook) proto.Message
+	HandleSearchBook(ctx context.Context, req *pb_core.Request_SearchBook) proto.Message
 	// HandleHeartBeat 处理HeartBeat请求
-	HandleHeartBeat(req *pb_core.Request_HeartBeat) proto.Message
+	HandleHeartBeat(ctx context.Context, req *pb_core.Request_HeartBeat) proto.Message
 }
 
 // DispatchCoreRequestByID 根据协议ID分发请求到对应处理函数
-func DispatchCoreRequestByID(handler CoreRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {
+func DispatchCoreRequestByID(ctx context.Context, handler CoreRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {
 	var response proto.Message
 	switch pid {
 	case PID_Core_Request_SearchBook: // Request_SearchBook
@@ -25,7 +26,7 @@ func DispatchCoreRequestByID(handler CoreRequestHandler, pid uint32, data []byte
 			return nil, 0, fmt.Errorf("unmarshal Request_SearchBook failed: %w", err)
 		}
 
-		response = handler.HandleSearchBook(req)
+		response = handler.HandleSearchBook(ctx, req)
 	
 	case PID_Core_Request_HeartBeat: // Request_HeartBeat
 		req := &pb_core.Request_HeartBeat{}
@@ -33,7 +34,7 @@ func DispatchCoreRequestByID(handler CoreRequestHandler, pid uint32, data []byte
 			return nil, 0, fmt.Errorf("unmarshal Request_HeartBeat failed: %w", err)
 		}
 
-		response = handler.HandleHeartBeat(req)
+		response = handler.HandleHeartBeat(ctx, req)
 	
 	default:
 		return nil, 0, fmt.Errorf("unknown request protocol ID: 0x%08x", pid)
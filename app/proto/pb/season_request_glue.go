@@ -2,19 +2,20 @@
 package pb
 
 import (
+	"context"
 	"fmt"
 	"google.golang.org/protobuf/proto"
 	"gitee.com/orbit-w/orbit/app/proto/pb/pb_season"
 )
 
-// SeasonRequestHandler 处理Season包的请求消息
+// SeasonRequestHandler 处理Season包的请求消息，ctx 携带请求的链路和取消信号
 type SeasonRequestHandler interface {
 	// HandleSeasonInfo 处理SeasonInfo请求
-	HandleSeasonInfo(req *pb_season.Request_SeasonInfo) proto.Message
+	HandleSeasonInfo(ctx context.Context, req *pb_season.Request_SeasonInfo) proto.Message
 }
 
 // DispatchSeasonRequestByID 根据协议ID分发请求到对应处理函数
-func DispatchSeasonRequestByID(handler SeasonRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {
+func DispatchSeasonRequestByID(ctx context.Context, handler SeasonRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {
 	var response proto.Message
 	switch pid {
 	case PID_Season_Request_SeasonInfo: // Request_SeasonInfo
@@ -23,7 +24,7 @@ func DispatchSeasonRequestByID(handler SeasonRequestHandler, pid uint32, data []
 			return nil, 0, fmt.Errorf("unmarshal Request_SeasonInfo failed: %w", err)
 		}
 
-		response = handler.HandleSeasonInfo(req)
+		response = handler.HandleSeasonInfo(ctx, req)
 	
 	default:
 		return nil, 0, fmt.Errorf("unknown request protocol ID: 0x%08x", pid)
@@ -2,20 +2,27 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"gitee.com/orbit-w/orbit/app/controller"
+	"gitee.com/orbit-w/orbit/app/modules/config"
 
+	"gitee.com/orbit-w/orbit/app/core/actors/actor"
 	"gitee.com/orbit-w/orbit/app/core/dispatch"
 	"gitee.com/orbit-w/orbit/app/core/network"
 	stream "gitee.com/orbit-w/orbit/app/core/services/agent_stream"
 	"gitee.com/orbit-w/orbit/app/modules/service"
 	"gitee.com/orbit-w/orbit/lib/logger"
+	"gitee.com/orbit-w/orbit/lib/tracing"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -27,12 +34,13 @@ import (
 
 func Serve(nodeId string) {
 	//cfg := config.GetConfig()
+	initTracing(config.GetConfig().Trace)
 
 	// Init services
 	services := service.NewServices()
 
 	// Register services
-	RegServices(services)
+	RegServices(nodeId, services)
 
 	if err := services.Start(); err != nil {
 		panic(fmt.Sprintf("services start error: %v", err))
@@ -46,14 +54,99 @@ func Serve(nodeId string) {
 	})
 }
 
-func RegServices(services *service.Services) {
+func RegServices(nodeId string, services *service.Services) {
 	controller.Init()
 
 	stream.RegisterRequestHandler(requestHandler)
 
+	// Actor预热完成后网关才开始接受连接
+	af := actor.NewSystem()
+	services.Reg(service.Wrapper("actor").
+		WrapStart(func() error {
+			if err := af.Start(); err != nil {
+				return err
+			}
+			return startRemote(af, nodeId, config.GetConfig().Cluster)
+		}).
+		WrapStop(af.StopWithDefaultTimeout))
+	services.Reg(actor.NewWarmup(af))
+	if addr := config.GetConfig().Admin.Addr; addr != "" {
+		services.Reg(adminService(af, addr))
+	}
+
 	services.Reg(new(stream.AgentStream))
 }
 
+// startRemote 按集群配置启用Actor跨节点通信，没有配置集群节点时单节点运行
+func startRemote(af *actor.ActorSystem, nodeId string, conf config.Cluster) error {
+	if len(conf.Nodes) == 0 {
+		return nil
+	}
+	nodes := make([]actor.NodeConfig, 0, len(conf.Nodes))
+	for _, node := range conf.Nodes {
+		nodes = append(nodes, actor.NodeConfig{
+			NodeId: node.Id,
+			Addr:   net.JoinHostPort(node.Host, node.Port),
+			Region: node.Region,
+		})
+	}
+	discovery := actor.NewStaticDiscovery(nodes...)
+	if _, ok := discovery.Lookup(nodeId); !ok {
+		return fmt.Errorf("node %s not found in cluster config", nodeId)
+	}
+	return af.StartRemote(&actor.RemoteConfig{
+		NodeId:    nodeId,
+		Discovery: discovery,
+		Secret:    conf.Secret,
+	})
+}
+
+// adminService 在独立的HTTP服务上挂载Actor管理端点，路径前缀为 /debug/actor
+func adminService(af *actor.ActorSystem, addr string) service.IService {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/actor/", http.StripPrefix("/debug/actor", af.AdminHandler()))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return service.Wrapper("actor-admin").
+		WrapStart(func() error {
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					logger.GetLogger().Error("actor admin server exit", zap.Error(err))
+				}
+			}()
+			return nil
+		}).
+		WrapStop(func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return server.Shutdown(ctx)
+		})
+}
+
+// initTracing 按配置设置链路追踪的输出
+func initTracing(conf config.Trace) {
+	switch conf.Exporter {
+	case "":
+	case "stdout":
+		tracing.SetExporter(tracing.NewStdoutExporter())
+	case "file":
+		exporter, err := tracing.NewFileExporter(conf.File)
+		if err != nil {
+			panic(fmt.Sprintf("trace exporter error: %v", err))
+		}
+		tracing.SetExporter(exporter)
+	default:
+		panic(fmt.Sprintf("unknown trace exporter: %s", conf.Exporter))
+	}
+}
+
 // gracefulShutdown 优雅关闭服务
 func gracefulShutdown(stopper func(ctx context.Context) error) {
 	// 等待中断信号
@@ -76,8 +169,8 @@ func gracefulShutdown(stopper func(ctx context.Context) error) {
 	log.Println("Server exiting")
 }
 
-var requestHandler = func(session *network.Session, data []byte, seq, pid uint32) error {
-	response, pid, err := dispatch.Dispatch(pid, data)
+var requestHandler = func(ctx context.Context, session *network.Session, data []byte, seq, pid uint32) error {
+	response, pid, err := dispatch.Dispatch(ctx, pid, data)
 	if err != nil {
 		return err
 	}
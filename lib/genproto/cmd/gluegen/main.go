@@ -677,6 +677,7 @@ func generateRequestGlueCode(messages []Message, packageName, pbDir string) {
 
 	// 导入必要的包
 	fmt.Fprintf(file, "import (\n")
+	fmt.Fprintf(file, "\t\"context\"\n")
 	fmt.Fprintf(file, "\t\"fmt\"\n")
 	fmt.Fprintf(file, "\t\"google.golang.org/protobuf/proto\"\n")
 
@@ -708,7 +709,7 @@ func generateRequestGlueCode(messages []Message, packageName, pbDir string) {
 	fmt.Fprintf(file, ")\n\n")
 
 	// 写入请求处理器接口
-	fmt.Fprintf(file, "// %sRequestHandler 处理%s包的请求消息\n", packageName, packageName)
+	fmt.Fprintf(file, "// %sRequestHandler 处理%s包的请求消息，ctx 携带请求的链路和取消信号\n", packageName, packageName)
 	fmt.Fprintf(file, "type %sRequestHandler interface {\n", packageName)
 	for _, msg := range messages {
 		if msg.Name == "Request" {
@@ -718,13 +719,13 @@ func generateRequestGlueCode(messages []Message, packageName, pbDir string) {
 		if msg.Comment != "" {
 			fmt.Fprintf(file, "\t// %s\n", msg.Comment)
 		}
-		fmt.Fprintf(file, "\tHandle%s(req *%s.%s) proto.Message\n", msg.Name, goPackage, msg.FullName)
+		fmt.Fprintf(file, "\tHandle%s(ctx context.Context, req *%s.%s) proto.Message\n", msg.Name, goPackage, msg.FullName)
 	}
 	fmt.Fprintf(file, "}\n\n")
 
 	// 生成分发函数
 	fmt.Fprintf(file, "// Dispatch%sRequestByID 根据协议ID分发请求到对应处理函数\n", packageName)
-	fmt.Fprintf(file, "func Dispatch%sRequestByID(handler %sRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {\n", packageName, packageName)
+	fmt.Fprintf(file, "func Dispatch%sRequestByID(ctx context.Context, handler %sRequestHandler, pid uint32, data []byte) (proto.Message, uint32, error) {\n", packageName, packageName)
 	fmt.Fprintf(file, "\tvar response proto.Message\n")
 	fmt.Fprintf(file, "\tswitch pid {\n")
 
@@ -743,7 +744,7 @@ func generateRequestGlueCode(messages []Message, packageName, pbDir string) {
 		fmt.Fprintf(file, "\t\tif err := proto.Unmarshal(data, req); err != nil {\n")
 		fmt.Fprintf(file, "\t\t\treturn nil, 0, fmt.Errorf(\"unmarshal %s failed: %%w\", err)\n", msg.FullName)
 		fmt.Fprintf(file, "\t\t}\n\n")
-		fmt.Fprintf(file, "\t\tresponse = handler.Handle%s(req)\n", msg.Name)
+		fmt.Fprintf(file, "\t\tresponse = handler.Handle%s(ctx, req)\n", msg.Name)
 		fmt.Fprintf(file, "\t\n")
 	}
 
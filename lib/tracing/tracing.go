@@ -0,0 +1,256 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+	轻量的链路追踪
+
+	1. 一次客户端请求从网关开始一条链路(Trace)，经过的每个处理环节(分发、Actor处理、下游Actor调用)为一个Span
+	2. SpanContext 在进程内通过 context.Context 传递，跨Actor时放在消息上，跨节点时编码在消息帧中
+	3. 结束的Span交给 Exporter 输出，未设置 Exporter 时不创建Span，只传递上游的 SpanContext
+*/
+
+type TraceID [16]byte
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+type SpanID [8]byte
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext 需要跨环节传递的Span标识
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Attr Span的属性
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func KV(key string, value any) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// SpanData 结束的Span，交给 Exporter 输出
+type SpanData struct {
+	TraceID  string         `json:"traceId"`
+	SpanID   string         `json:"spanId"`
+	ParentID string         `json:"parentId,omitempty"`
+	Name     string         `json:"name"`
+	Start    time.Time      `json:"start"`
+	Duration time.Duration  `json:"duration"`
+	Attrs    map[string]any `json:"attrs,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Exporter 输出结束的Span，实现需要并发安全
+type Exporter interface {
+	Export(span *SpanData)
+}
+
+type exporterHolder struct {
+	exporter Exporter
+}
+
+var exporter atomic.Pointer[exporterHolder]
+
+// SetExporter 设置全局的 Exporter，传入nil时关闭追踪
+func SetExporter(e Exporter) {
+	if e == nil {
+		exporter.Store(nil)
+		return
+	}
+	exporter.Store(&exporterHolder{exporter: e})
+}
+
+// Enabled 是否设置了 Exporter
+func Enabled() bool {
+	return exporter.Load() != nil
+}
+
+// Span 一个处理环节，所有方法都可以在nil上调用
+// 未开启追踪时 Start 返回不记录的Span，只携带上游的 SpanContext
+type Span struct {
+	mu       sync.Mutex
+	sc       SpanContext
+	parent   SpanID
+	name     string
+	start    time.Time
+	attrs    []Attr
+	err      error
+	ended    bool
+	exporter Exporter
+}
+
+// Start 开始一个Span，parent无效时开始一条新的链路
+func Start(parent SpanContext, name string, attrs ...Attr) *Span {
+	holder := exporter.Load()
+	if holder == nil {
+		if !parent.IsValid() {
+			return nil
+		}
+		return &Span{sc: parent, ended: true}
+	}
+
+	s := &Span{
+		sc:       SpanContext{TraceID: parent.TraceID, SpanID: newSpanID()},
+		name:     name,
+		start:    time.Now(),
+		attrs:    attrs,
+		exporter: holder.exporter,
+	}
+	if parent.IsValid() {
+		s.parent = parent.SpanID
+	} else {
+		s.sc.TraceID = newTraceID()
+	}
+	return s
+}
+
+// StartSpan 以ctx中的Span为父节点开始一个Span，返回携带新Span的ctx
+func StartSpan(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	s := Start(SpanContextFromContext(ctx), name, attrs...)
+	if s == nil {
+		return ctx, nil
+	}
+	return ContextWithSpanContext(ctx, s.sc), s
+}
+
+// SpanContext 返回Span的标识，nil返回无效的 SpanContext
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording Span是否会被输出
+func (s *Span) IsRecording() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.ended
+}
+
+func (s *Span) SetAttrs(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.attrs = append(s.attrs, attrs...)
+	}
+}
+
+// SetError 记录处理失败的原因，err为nil时忽略
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ended {
+		s.err = err
+	}
+}
+
+// End 结束Span并输出，重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := &SpanData{
+		TraceID:  s.sc.TraceID.String(),
+		SpanID:   s.sc.SpanID.String(),
+		Name:     s.name,
+		Start:    s.start,
+		Duration: time.Since(s.start),
+	}
+	if s.parent.IsValid() {
+		data.ParentID = s.parent.String()
+	}
+	if len(s.attrs) > 0 {
+		data.Attrs = make(map[string]any, len(s.attrs))
+		for _, attr := range s.attrs {
+			data.Attrs[attr.Key] = attr.Value
+		}
+	}
+	if s.err != nil {
+		data.Error = s.err.Error()
+	}
+	s.mu.Unlock()
+
+	s.exporter.Export(data)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpanContext 返回携带 SpanContext 的ctx
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext 返回ctx携带的 SpanContext，没有时返回无效的 SpanContext
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(id[8:], rand.Uint64())
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		binary.BigEndian.PutUint64(id[:], rand.Uint64())
+	}
+	return id
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpan_Disabled(t *testing.T) {
+	SetExporter(nil)
+	assert.Nil(t, Start(SpanContext{}, "root"))
+
+	// 未开启追踪时只传递上游的 SpanContext
+	parent := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{2}}
+	s := Start(parent, "child")
+	assert.Equal(t, parent, s.SpanContext())
+	assert.False(t, s.IsRecording())
+	s.End()
+
+	ctx, span := StartSpan(context.Background(), "root")
+	assert.Nil(t, span)
+	assert.False(t, SpanContextFromContext(ctx).IsValid())
+}
+
+func TestSpan_Export(t *testing.T) {
+	var buf bytes.Buffer
+	SetExporter(NewWriterExporter(&buf))
+	defer SetExporter(nil)
+
+	ctx, root := StartSpan(context.Background(), "root", KV("uid", 1001))
+	_, child := StartSpan(ctx, "child")
+	child.SetError(errors.New("boom"))
+	child.End()
+	child.End()
+	root.End()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	var c, r SpanData
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &c))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &r))
+
+	assert.Equal(t, "child", c.Name)
+	assert.Equal(t, "boom", c.Error)
+	assert.Equal(t, r.TraceID, c.TraceID)
+	assert.Equal(t, r.SpanID, c.ParentID)
+	assert.Empty(t, r.ParentID)
+	assert.Equal(t, float64(1001), r.Attrs["uid"])
+	assert.Equal(t, root.SpanContext(), SpanContextFromContext(ctx))
+}
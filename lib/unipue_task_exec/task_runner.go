@@ -19,8 +19,9 @@ func NewTaskRunner() *TaskRunner {
 // Execute 执行任务并存储其结果
 func (t *TaskRunner) Execute(do func() any) {
 	result := do()
-	t.Done()
+	// 先保存结果再通知完成，避免等待者读到空结果
 	t.result.Store(result)
+	t.Done()
 }
 
 // Done 设置任务的完成结果
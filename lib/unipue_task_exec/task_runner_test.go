@@ -0,0 +1,32 @@
+package unipue_task_exec
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestTaskRunner_ResultVisibleOnDone(t *testing.T) {
+	// 完成通知之后必须能立即读到任务结果
+	for i := 0; i < 2000; i++ {
+		runner := NewTaskRunner()
+		result := make(chan any, 1)
+		go func() {
+			// 不阻塞在done上，收到完成通知后第一时间读取结果
+			for {
+				select {
+				case <-runner.done:
+					result <- runner.result.Load()
+					return
+				default:
+					runtime.Gosched()
+				}
+			}
+		}()
+		runner.Execute(func() any {
+			return i
+		})
+		if v := <-result; v != i {
+			t.Fatalf("预期结果为%d，实际获得: %v", i, v)
+		}
+	}
+}